@@ -2,97 +2,352 @@ package batch
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+const instrumentationName = "github.com/pokt-foundation/transaction-http-db/batch"
+
+// ErrFull is returned when adding to a paused batch whose channel is full, as the item
+// would wait until the batch is resumed
+var ErrFull = errors.New("batch is paused and full")
+
+// writerGrace is how long a save whose timeout is over waits for its writer to return, for
+// instance while a fan out keeps the items in its dead letter, before abandoning the write
+const writerGrace = 5 * time.Second
+
+// maxSaveLinks bounds the requests a save span is linked to
+const maxSaveLinks = 128
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
 type Validator interface {
 	Validate() error
 }
 
 type writerFunc[T Validator] func(context.Context, []T) error
 
+// entry is an item of a batch along with the span of the request that added it and its arrival time
+type entry[T Validator] struct {
+	item        T
+	spanContext trace.SpanContext
+	addedAt     time.Time
+}
+
+// Option configures optional features of a Batch
+type Option[T Validator] func(*Batch[T])
+
+// WithItemTime sets the time the gateway produced each item at, such as the return time of
+// a relay, to measure how long items take to arrive. Zero times are not measured.
+func WithItemTime[T Validator](itemTime func(T) time.Time) Option[T] {
+	return func(b *Batch[T]) {
+		b.itemTime = itemTime
+	}
+}
+
 type Batch[T Validator] struct {
-	items       []T
+	items       []entry[T]
 	rwMutex     sync.RWMutex
-	batchChan   chan T
-	maxSize     int
+	batchChan   chan entry[T]
+	maxSize     atomic.Int64
 	name        string
-	maxDuration time.Duration
+	maxDuration atomic.Int64
 	timeoutDB   time.Duration
 	writer      writerFunc[T]
 	log         *zap.Logger
 	index       atomic.Int32
+	// lastSave holds the unix nano time of the last save that did not fail
+	lastSave atomic.Int64
+	// changed wakes up the batcher when the limits change, the batch is paused or resumed,
+	// or it is flushed
+	changed chan struct{}
+	paused  atomic.Bool
+	// itemTime returns the gateway time of an item, nil when items have none
+	itemTime func(T) time.Time
+	// oldest and savingOldest hold the unix nano arrival time of the oldest item of the
+	// batch and of the save in progress, zero when there is none
+	oldest       atomic.Int64
+	savingOldest atomic.Int64
+	// done stops the batcher, which closes stopped once it returns
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// Status is the state of a batch and its settings
+type Status struct {
+	Name string `json:"name"`
+	// Size is the number of items in the batch, waiting to be saved
+	Size int `json:"size"`
+	// ChanSize is the number of items waiting in the channel to be added to the batch
+	ChanSize     int       `json:"chanSize"`
+	ChanCapacity int       `json:"chanCapacity"`
+	MaxSize      int       `json:"maxSize"`
+	MaxDuration  string    `json:"maxDuration"`
+	Paused       bool      `json:"paused"`
+	LastSave     time.Time `json:"lastSave"`
+	// OldestItemAge is the age of the oldest item that is not saved yet
+	OldestItemAge string `json:"oldestItemAge"`
 }
 
 func (b *Batch[T]) logError(err error) {
 	b.log.Error(err.Error(), zap.String("err", err.Error()), zap.String("name", b.name))
 }
 
-func NewBatch[T Validator](maxSize, chanSize int, name string, maxDuration, timeoutDB time.Duration, writer writerFunc[T], logger *zap.Logger) *Batch[T] {
+func NewBatch[T Validator](maxSize, chanSize int, name string, maxDuration, timeoutDB time.Duration, writer writerFunc[T], logger *zap.Logger, opts ...Option[T]) *Batch[T] {
 	batch := &Batch[T]{
-		maxSize:     maxSize,
-		name:        name,
-		maxDuration: maxDuration,
-		timeoutDB:   timeoutDB,
-		writer:      writer,
-		batchChan:   make(chan T, chanSize),
-		log:         logger,
-		items:       make([]T, maxSize),
-		index:       atomic.Int32{},
+		name:      name,
+		timeoutDB: timeoutDB,
+		writer:    writer,
+		batchChan: make(chan entry[T], chanSize),
+		log:       logger,
+		items:     make([]entry[T], maxSize),
+		index:     atomic.Int32{},
+		changed:   make(chan struct{}, 1),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+
+	batch.maxSize.Store(int64(maxSize))
+	batch.maxDuration.Store(int64(maxDuration))
+	batch.lastSave.Store(time.Now().UnixNano())
+
+	for _, opt := range opts {
+		opt(batch)
 	}
 
+	oldestItemAges.set(name, batch.OldestItemAge)
+
 	go batch.Batcher()
 
 	return batch
 }
 
-func (b *Batch[T]) Add(item T) error {
+// Add validates the item and queues it to be added to the batch, blocking while the channel
+// is full until ctx is done. A paused batch whose channel is full refuses the item with
+// ErrFull instead. The save of the item is linked to the span of ctx.
+func (b *Batch[T]) Add(ctx context.Context, item T) error {
+	spanContext := trace.SpanContextFromContext(ctx)
+
+	_, span := tracer().Start(ctx, "Batch.Add", trace.WithAttributes(attribute.String("batch.name", b.name)))
+	defer span.End()
+
 	if err := item.Validate(); err != nil {
+		span.SetStatus(codes.Error, err.Error())
 		return err
 	}
 
-	b.batchChan <- item
+	addedAt := time.Now()
+	if b.itemTime != nil {
+		observeGatewayLag(b.name, b.itemTime(item), addedAt)
+	}
+
+	queued := entry[T]{item: item, spanContext: spanContext, addedAt: addedAt}
 
-	return nil
+	// A paused batch that is full only takes items again once it is resumed or flushed
+	if b.Paused() {
+		select {
+		case b.batchChan <- queued:
+			return nil
+		default:
+		}
+
+		if b.full() {
+			span.SetStatus(codes.Error, ErrFull.Error())
+			return ErrFull
+		}
+	}
+
+	select {
+	case b.batchChan <- queued:
+		return nil
+	case <-ctx.Done():
+		span.SetStatus(codes.Error, ctx.Err().Error())
+		return ctx.Err()
+	}
 }
 
 func (b *Batch[T]) Size() int {
 	return int(b.index.Load())
 }
 
-func (b *Batch[T]) add(item T) {
+// Name returns the name of the batch
+func (b *Batch[T]) Name() string {
+	return b.name
+}
+
+// ChanSize returns the number of items waiting in the channel to be added to the batch
+func (b *Batch[T]) ChanSize() int {
+	return len(b.batchChan)
+}
+
+// ChanCapacity returns the number of items the channel can hold before Add blocks
+func (b *Batch[T]) ChanCapacity() int {
+	return cap(b.batchChan)
+}
+
+// LastSave returns the time of the last save that did not fail, or the creation
+// time of the batch if it was never saved
+func (b *Batch[T]) LastSave() time.Time {
+	return time.Unix(0, b.lastSave.Load())
+}
+
+// MaxSize returns the number of items that triggers a save
+func (b *Batch[T]) MaxSize() int {
+	return int(b.maxSize.Load())
+}
+
+// MaxDuration returns the time after which the batch is saved whatever its size
+func (b *Batch[T]) MaxDuration() time.Duration {
+	return time.Duration(b.maxDuration.Load())
+}
+
+// SetLimits changes the max size and duration of the running batch. The batch is saved
+// right away if it already holds maxSize items, and the duration starts over.
+func (b *Batch[T]) SetLimits(maxSize int, maxDuration time.Duration) {
+	b.maxSize.Store(int64(maxSize))
+	b.maxDuration.Store(int64(maxDuration))
+
+	b.wakeUp()
+}
+
+// Pause stops saving the batch when it is full or its duration is over, for instance while
+// the database is being repaired. Items are still added until the batch reaches its max size,
+// then wait in the channel until it is full, Add refusing them from then on.
+func (b *Batch[T]) Pause() {
+	b.paused.Store(true)
+	b.wakeUp()
+}
+
+// Resume saves the batch again, right away if it reached its max size while paused
+func (b *Batch[T]) Resume() {
+	b.paused.Store(false)
+	b.wakeUp()
+}
+
+// Paused reports whether the batch is paused
+func (b *Batch[T]) Paused() bool {
+	return b.paused.Load()
+}
+
+// OldestItemAge returns how long ago the oldest item of the batch or of the save in progress
+// arrived, zero when there is none. The items waiting in the channel are not counted.
+func (b *Batch[T]) OldestItemAge() time.Duration {
+	oldest := b.oldest.Load()
+	if saving := b.savingOldest.Load(); saving != 0 && (oldest == 0 || saving < oldest) {
+		oldest = saving
+	}
+
+	if oldest == 0 {
+		return 0
+	}
+
+	return time.Since(time.Unix(0, oldest))
+}
+
+// Status returns the current state of the batch
+func (b *Batch[T]) Status() Status {
+	return Status{
+		Name:          b.name,
+		Size:          b.Size(),
+		ChanSize:      b.ChanSize(),
+		ChanCapacity:  b.ChanCapacity(),
+		MaxSize:       b.MaxSize(),
+		MaxDuration:   b.MaxDuration().String(),
+		Paused:        b.Paused(),
+		LastSave:      b.LastSave(),
+		OldestItemAge: b.OldestItemAge().String(),
+	}
+}
+
+func (b *Batch[T]) wakeUp() {
+	select {
+	case b.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (b *Batch[T]) add(item entry[T]) {
 	b.rwMutex.Lock()
 	defer b.rwMutex.Unlock()
 
-	b.items[b.index.Load()] = item
+	index := int(b.index.Load())
+	if index == 0 {
+		b.oldest.Store(item.addedAt.UnixNano())
+	}
+
+	// The items only outgrow the slice when the max size was raised
+	if index < len(b.items) {
+		b.items[index] = item
+	} else {
+		b.items = append(b.items, item)
+	}
+
 	b.index.Add(1)
 }
 
+// full reports whether the batch holds max size items, in which case a paused batch stops
+// taking items from the channel
+func (b *Batch[T]) full() bool {
+	return b.Size() >= b.MaxSize()
+}
+
 func (b *Batch[T]) Batcher() {
-	ticker := time.NewTicker(b.maxDuration)
+	defer close(b.stopped)
+
+	ticker := time.NewTicker(b.MaxDuration())
 	defer ticker.Stop()
 
 	for {
+		batchChan := b.batchChan
+		if b.Paused() && b.full() {
+			batchChan = nil
+		}
+
 		select {
-		case item := <-b.batchChan:
+		case item := <-batchChan:
 			b.log.Debug(fmt.Sprintf("item received in %s batch", b.name))
 			b.add(item)
 
-			if b.Size() >= b.maxSize {
+			if b.full() && !b.Paused() {
 				b.log.Debug(fmt.Sprintf("max size on %s batcher reached", b.name))
 				if err := b.Save(); err != nil {
 					b.logError(fmt.Errorf("error saving %s batch: %s", b.name, err))
 				}
 				// Reset the ticker when max size is reached
-				ticker = time.NewTicker(b.maxDuration)
+				ticker.Reset(b.MaxDuration())
+			}
+
+		case <-b.changed:
+			b.log.Debug(fmt.Sprintf("%s batcher changed", b.name))
+			if b.full() && !b.Paused() {
+				if err := b.Save(); err != nil {
+					b.logError(fmt.Errorf("error saving %s batch: %s", b.name, err))
+				}
 			}
 
+			ticker.Reset(b.MaxDuration())
+
+		case <-b.done:
+			return
+
 		case <-ticker.C:
+			if b.Paused() {
+				b.log.Debug(fmt.Sprintf("max duration on paused %s batcher reached", b.name))
+				continue
+			}
+
 			b.log.Debug(fmt.Sprintf("max duration on %s batcher reached", b.name))
 			if err := b.Save(); err != nil {
 				b.logError(fmt.Errorf("error saving %s batch: %s", b.name, err))
@@ -101,22 +356,92 @@ func (b *Batch[T]) Batcher() {
 	}
 }
 
+// Close stops the batcher and saves the items of the batch along with those waiting in the
+// channel, whether it is paused or not. It is called once nothing adds items anymore, the
+// items added after it never being saved.
+func (b *Batch[T]) Close() error {
+	b.closeOnce.Do(func() {
+		close(b.done)
+	})
+
+	// A save the batcher started is over once it returns
+	<-b.stopped
+
+	var errs []error
+	for {
+		if b.full() {
+			if err := b.Save(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		select {
+		case item := <-b.batchChan:
+			b.add(item)
+		default:
+			if err := b.Save(); err != nil {
+				errs = append(errs, err)
+			}
+
+			return errors.Join(errs...)
+		}
+	}
+}
+
+// Save writes the items of the batch, whether it is paused or not, and empties it
 func (b *Batch[T]) Save() error {
+	_, err := b.Flush()
+	return err
+}
+
+// SaveTimeout returns the longest a save of the batch takes
+func (b *Batch[T]) SaveTimeout() time.Duration {
+	return b.timeoutDB + writerGrace
+}
+
+// Flush saves the batch like Save, returning the number of items written. The items are
+// dropped from the batch even when the write fails, the number of items dropped being
+// returned along with the error, which tells whether the writer kept them elsewhere.
+func (b *Batch[T]) Flush() (int, error) {
 	b.rwMutex.Lock()
 
 	size := b.index.Load()
-	items := make([]T, size)
-	copy(items, b.items[:size])
+	entries := make([]entry[T], size)
+	copy(entries, b.items[:size])
 	b.index.Store(0)
 
+	// A save started meanwhile replaces the oldest item of this one, which it leaves alone
+	oldest := b.oldest.Swap(0)
+	b.savingOldest.Store(oldest)
+	defer b.savingOldest.CompareAndSwap(oldest, 0)
+
 	b.rwMutex.Unlock()
 
-	if len(items) == 0 {
+	// A paused batch that was full takes items again
+	if b.Paused() {
+		b.wakeUp()
+	}
+
+	if len(entries) == 0 {
 		b.log.Warn(fmt.Sprintf("no item was saved on %s", b.name))
-		return nil
+		b.lastSave.Store(time.Now().UnixNano())
+		return 0, nil
+	}
+
+	items := make([]T, len(entries))
+	for i, entry := range entries {
+		items[i] = entry.item
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), b.timeoutDB)
+	// Saves are not part of a single request, so they start their own trace linked to the requests
+	ctx, span := tracer().Start(context.Background(), "Batch.Save",
+		trace.WithNewRoot(),
+		trace.WithLinks(saveLinks(entries)...),
+		trace.WithAttributes(attribute.String("batch.name", b.name), attribute.Int("batch.items", len(items))),
+	)
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, b.timeoutDB)
 	defer cancel()
 
 	errChan := make(chan error, 1)
@@ -125,14 +450,55 @@ func (b *Batch[T]) Save() error {
 		errChan <- b.writer(ctx, items)
 	}()
 
+	var err error
+
 	select {
-	case err := <-errChan:
-		if err != nil {
-			return err
-		}
+	case err = <-errChan:
 	case <-ctx.Done():
-		return ctx.Err()
+		// The writer still gets to report what became of the items
+		select {
+		case err = <-errChan:
+		case <-time.After(writerGrace):
+			err = ctx.Err()
+		}
+	}
+
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return len(items), err
+	}
+
+	savedAt := time.Now()
+	b.lastSave.Store(savedAt.UnixNano())
+
+	observeSaveLags(b.name, entries, savedAt)
+
+	return len(items), nil
+}
+
+// saveLinks returns a link to each of the requests the entries were added by, up to maxSaveLinks
+func saveLinks[T Validator](entries []entry[T]) []trace.Link {
+	type spanID struct {
+		trace trace.TraceID
+		span  trace.SpanID
+	}
+
+	var links []trace.Link
+	linked := make(map[spanID]bool)
+
+	for _, entry := range entries {
+		if len(links) == maxSaveLinks {
+			break
+		}
+
+		id := spanID{trace: entry.spanContext.TraceID(), span: entry.spanContext.SpanID()}
+		if !entry.spanContext.IsValid() || linked[id] {
+			continue
+		}
+
+		linked[id] = true
+		links = append(links, trace.Link{SpanContext: entry.spanContext})
 	}
 
-	return nil
+	return links
 }
@@ -1,47 +1,60 @@
 package batch
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	mock "github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.uber.org/zap"
 )
 
+// newTestRelay returns a relay with every required field set
+func newTestRelay() *types.Relay {
+	return &types.Relay{
+		PoktChainID:          "21",
+		EndpointID:           "21",
+		SessionKey:           "21",
+		ProtocolAppPublicKey: "21",
+		RelaySourceURL:       "pablo.com",
+		PoktNodeAddress:      "21",
+		PoktNodeDomain:       "pablos.com",
+		PoktNodePublicKey:    "aaa",
+		RelayStartDatetime:   time.Now(),
+		RelayReturnDatetime:  time.Now(),
+		RelayRoundtripTime:   1,
+		RelayChainMethodIDs:  []string{"get_height"},
+		RelayDataSize:        21,
+		RelayPortalTripTime:  21,
+		RelayNodeTripTime:    21,
+		PortalRegionName:     "La Colombia",
+		RequestID:            "21",
+		PoktTxID:             "21",
+	}
+}
+
 func TestBatch_RelayBatcher(t *testing.T) {
 	c := require.New(t)
 
-	validRelay := types.Relay{
-		PoktChainID:              "21",
-		EndpointID:               "21",
-		SessionKey:               "21",
-		ProtocolAppPublicKey:     "21",
-		RelaySourceURL:           "pablo.com",
-		PoktNodeAddress:          "21",
-		PoktNodeDomain:           "pablos.com",
-		PoktNodePublicKey:        "aaa",
-		RelayStartDatetime:       time.Now(),
-		RelayReturnDatetime:      time.Now(),
-		IsError:                  true,
-		ErrorCode:                21,
-		ErrorName:                "favorite number",
-		ErrorMessage:             "just Pablo can use it",
-		ErrorType:                "chain_check",
-		ErrorSource:              "internal",
-		RelayRoundtripTime:       1,
-		RelayChainMethodIDs:      []string{"get_height"},
-		RelayDataSize:            21,
-		RelayPortalTripTime:      21,
-		RelayNodeTripTime:        21,
-		RelayURLIsPublicEndpoint: false,
-		PortalRegionName:         "La Colombia",
-		IsAltruistRelay:          false,
-		IsUserRelay:              false,
-		RequestID:                "21",
-		PoktTxID:                 "21",
-	}
+	validRelay := newTestRelay()
+	validRelay.IsError = true
+	validRelay.ErrorCode = 21
+	validRelay.ErrorName = "favorite number"
+	validRelay.ErrorMessage = "just Pablo can use it"
+	validRelay.ErrorType = "chain_check"
+	validRelay.ErrorSource = "internal"
 
 	tests := []struct {
 		name        string
@@ -49,7 +62,7 @@ func TestBatch_RelayBatcher(t *testing.T) {
 		maxDuration time.Duration
 		timeToWait  time.Duration
 		relaysToAdd int
-		relayToAdd  types.Relay
+		relayToAdd  *types.Relay
 	}{
 		{
 			name:        "Save Relays For Size",
@@ -73,7 +86,7 @@ func TestBatch_RelayBatcher(t *testing.T) {
 		writerMock.On("WriteRelays", mock.Anything, mock.Anything).Return(nil).Once()
 
 		for i := 0; i < tt.relaysToAdd; i++ {
-			err := batch.Add(&tt.relayToAdd)
+			err := batch.Add(context.Background(), tt.relayToAdd)
 			c.NoError(err)
 		}
 
@@ -82,3 +95,316 @@ func TestBatch_RelayBatcher(t *testing.T) {
 		c.Equal(0, batch.Size())
 	}
 }
+
+func TestBatch_SetLimits(t *testing.T) {
+	c := require.New(t)
+
+	relay := newTestRelay()
+
+	// The writes are counted as the batch is emptied before its items are written
+	var writes atomic.Int32
+
+	writerMock := &MockRelayWriter{}
+	batch := NewBatch(2, 21, "relay", time.Hour, time.Hour, writerMock.WriteRelays, zap.NewNop())
+
+	// Raising the max size lets the batch hold more items than it was created for
+	batch.SetLimits(5, time.Hour)
+	c.Equal(5, batch.MaxSize())
+
+	for i := 0; i < 4; i++ {
+		c.NoError(batch.Add(context.Background(), relay))
+	}
+
+	c.Eventually(func() bool { return batch.Size() == 4 }, time.Second, 10*time.Millisecond)
+
+	// Lowering it under the current size saves the batch right away
+	writerMock.On("WriteRelays", mock.Anything, mock.MatchedBy(func(relays []*types.Relay) bool {
+		return len(relays) == 4
+	})).Run(func(mock.Arguments) { writes.Add(1) }).Return(nil).Once()
+
+	batch.SetLimits(3, time.Hour)
+
+	c.Eventually(func() bool { return writes.Load() == 1 }, time.Second, 10*time.Millisecond)
+	writerMock.AssertExpectations(t)
+
+	// A shorter duration applies without waiting for the previous one
+	writerMock.On("WriteRelays", mock.Anything, mock.Anything).Run(func(mock.Arguments) { writes.Add(1) }).Return(nil).Once()
+
+	c.NoError(batch.Add(context.Background(), relay))
+	c.Eventually(func() bool { return batch.Size() == 1 }, time.Second, 10*time.Millisecond)
+
+	batch.SetLimits(3, 50*time.Millisecond)
+	c.Equal(50*time.Millisecond, batch.MaxDuration())
+
+	c.Eventually(func() bool { return writes.Load() == 2 }, time.Second, 10*time.Millisecond)
+	writerMock.AssertExpectations(t)
+}
+
+func TestBatch_Pause(t *testing.T) {
+	c := require.New(t)
+
+	relay := newTestRelay()
+
+	var writes atomic.Int32
+
+	writerMock := &MockRelayWriter{}
+	batch := NewBatch(2, 3, "relay", 20*time.Millisecond, time.Hour, writerMock.WriteRelays, zap.NewNop())
+
+	batch.Pause()
+	c.True(batch.Status().Paused)
+
+	// A paused batch is not saved, the items filling the batch and then the channel
+	for i := 0; i < 5; i++ {
+		c.NoError(batch.Add(context.Background(), relay))
+	}
+
+	c.Eventually(func() bool { return batch.Size() == 2 && batch.ChanSize() == 3 }, time.Second, 10*time.Millisecond)
+
+	time.Sleep(100 * time.Millisecond)
+	c.Equal(2, batch.Size())
+
+	// Once full, the items are refused rather than waiting for the batch to be resumed
+	c.ErrorIs(batch.Add(context.Background(), relay), ErrFull)
+
+	// A flush saves the batch anyway, making room for the items of the channel
+	writerMock.On("WriteRelays", mock.Anything, mock.MatchedBy(func(relays []*types.Relay) bool {
+		return len(relays) == 2
+	})).Run(func(mock.Arguments) { writes.Add(1) }).Return(nil).Once()
+
+	saved, err := batch.Flush()
+	c.NoError(err)
+	c.Equal(2, saved)
+
+	c.Eventually(func() bool { return batch.Size() == 2 && batch.ChanSize() == 1 }, time.Second, 10*time.Millisecond)
+	writerMock.AssertExpectations(t)
+
+	// Resuming saves the full batch right away, then the rest of the items once the duration is over
+	writerMock.On("WriteRelays", mock.Anything, mock.Anything).Run(func(mock.Arguments) { writes.Add(1) }).Return(nil).Twice()
+
+	batch.Resume()
+	c.False(batch.Status().Paused)
+
+	c.Eventually(func() bool { return writes.Load() == 3 }, time.Second, 10*time.Millisecond)
+	writerMock.AssertExpectations(t)
+}
+
+func TestBatch_Add_cancelled(t *testing.T) {
+	c := require.New(t)
+
+	unblock := make(chan struct{})
+	defer close(unblock)
+
+	writerMock := &MockRelayWriter{}
+	writerMock.On("WriteRelays", mock.Anything, mock.Anything).Run(func(mock.Arguments) { <-unblock }).Return(nil)
+
+	batch := NewBatch(1, 1, "relay", time.Hour, time.Hour, writerMock.WriteRelays, zap.NewNop())
+
+	relay := newTestRelay()
+
+	// The first relay is being saved and the second one fills the channel
+	c.NoError(batch.Add(context.Background(), relay))
+	c.Eventually(func() bool { return batch.ChanSize() == 0 && batch.OldestItemAge() > 0 }, time.Second, 10*time.Millisecond)
+	c.NoError(batch.Add(context.Background(), relay))
+
+	// Adding blocks until the request is cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	c.ErrorIs(batch.Add(ctx, relay), context.DeadlineExceeded)
+}
+
+func TestBatch_Flush_timeout(t *testing.T) {
+	c := require.New(t)
+
+	// The writer keeps the items elsewhere once the save times out
+	batch := NewBatch(2, 3, "relay", time.Hour, 10*time.Millisecond, func(ctx context.Context, relays []*types.Relay) error {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+
+		return fmt.Errorf("%w, %w", ctx.Err(), ErrKeptForReplay)
+	}, zap.NewNop())
+
+	relay := newTestRelay()
+
+	c.NoError(batch.Add(context.Background(), relay))
+	c.Eventually(func() bool { return batch.Size() == 1 }, time.Second, 10*time.Millisecond)
+
+	// The save reports what the writer did with the items rather than just its timeout
+	dropped, err := batch.Flush()
+	c.Equal(1, dropped)
+	c.ErrorIs(err, context.DeadlineExceeded)
+	c.ErrorIs(err, ErrKeptForReplay)
+	c.Equal(10*time.Millisecond+writerGrace, batch.SaveTimeout())
+}
+
+func TestBatch_Close(t *testing.T) {
+	c := require.New(t)
+
+	relay := newTestRelay()
+
+	var saved atomic.Int32
+
+	writerMock := &MockRelayWriter{}
+	writerMock.On("WriteRelays", mock.Anything, mock.MatchedBy(func(relays []*types.Relay) bool {
+		return len(relays) <= 2
+	})).Run(func(args mock.Arguments) {
+		saved.Add(int32(len(args.Get(1).([]*types.Relay))))
+	}).Return(nil)
+
+	batch := NewBatch(2, 3, "relay", time.Hour, time.Hour, writerMock.WriteRelays, zap.NewNop())
+	batch.Pause()
+
+	for i := 0; i < 5; i++ {
+		c.NoError(batch.Add(context.Background(), relay))
+	}
+
+	c.Eventually(func() bool { return batch.Size() == 2 && batch.ChanSize() == 3 }, time.Second, 10*time.Millisecond)
+
+	// The items of the channel are saved too, in batches of the max size
+	c.NoError(batch.Close())
+	c.Equal(int32(5), saved.Load())
+	c.Zero(batch.Size())
+	c.Zero(batch.ChanSize())
+	c.NoError(batch.Close())
+
+	writerMock.AssertNumberOfCalls(t, "WriteRelays", 3)
+}
+
+func TestBatch_Tracing(t *testing.T) {
+	c := require.New(t)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	relay := newTestRelay()
+
+	var secondaryWrites atomic.Int32
+
+	fanOut := NewFanOut("relay", Sink[*types.Relay]{
+		Name:  "postgres",
+		Write: func(ctx context.Context, relays []*types.Relay) error { return nil },
+	}, []Sink[*types.Relay]{{
+		Name: "archive",
+		Write: func(ctx context.Context, relays []*types.Relay) error {
+			secondaryWrites.Add(1)
+			return nil
+		},
+	}}, zap.NewNop())
+
+	batch := NewBatch(3, 21, "relay", time.Hour, time.Hour, fanOut.Write, zap.NewNop())
+
+	// Two requests add the items of a single save
+	firstCtx, firstRequest := provider.Tracer("test").Start(context.Background(), "POST /v0/relays")
+	secondCtx, secondRequest := provider.Tracer("test").Start(context.Background(), "POST /v0/relay")
+
+	c.NoError(batch.Add(firstCtx, relay))
+	c.NoError(batch.Add(firstCtx, relay))
+	c.Error(batch.Add(secondCtx, &types.Relay{}))
+	c.NoError(batch.Add(secondCtx, relay))
+
+	firstRequest.End()
+	secondRequest.End()
+
+	c.Eventually(func() bool { return secondaryWrites.Load() == 1 }, time.Second, 10*time.Millisecond)
+	c.NoError(fanOut.Close(context.Background()))
+
+	spans := make(map[string][]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = append(spans[span.Name()], span)
+	}
+
+	c.Len(spans["Batch.Add"], 4)
+	for _, span := range spans["Batch.Add"] {
+		c.True(span.Parent().IsValid())
+	}
+
+	c.Len(spans["Batch.Save"], 1)
+	save := spans["Batch.Save"][0]
+	c.Contains(save.Attributes(), attribute.Int("batch.items", 3))
+	c.False(save.Parent().IsValid())
+
+	// The save is linked to each request once
+	c.Len(save.Links(), 2)
+	c.Equal(firstRequest.SpanContext().SpanID(), save.Links()[0].SpanContext.SpanID())
+	c.Equal(secondRequest.SpanContext().SpanID(), save.Links()[1].SpanContext.SpanID())
+
+	// The writes of both sinks are part of the trace of the save
+	c.Len(spans["Sink.Write"], 2)
+	for _, span := range spans["Sink.Write"] {
+		c.Equal(save.SpanContext().SpanID(), span.Parent().SpanID())
+		c.Contains(span.Attributes(), attribute.Int("batch.items", 3))
+	}
+}
+
+func TestBatch_Lag(t *testing.T) {
+	c := require.New(t)
+
+	relay := newTestRelay()
+	relay.RelayStartDatetime = time.Now().Add(-3 * time.Second)
+	relay.RelayReturnDatetime = time.Now().Add(-2 * time.Second)
+
+	writerMock := &MockRelayWriter{}
+	writerMock.On("WriteRelays", mock.Anything, mock.Anything).Return(nil).Once()
+
+	batch := NewBatch(5, 21, "lag_relay", time.Hour, time.Hour, writerMock.WriteRelays, zap.NewNop(),
+		WithItemTime(func(relay *types.Relay) time.Time { return relay.RelayReturnDatetime }))
+
+	c.Zero(batch.OldestItemAge())
+
+	// The histograms are shared by the runs of the test
+	gatewayLagBefore := histogram(t, gatewayLagSeconds.WithLabelValues("lag_relay"))
+	saveLagBefore := histogram(t, saveLagSeconds.WithLabelValues("lag_relay"))
+
+	for i := 0; i < 3; i++ {
+		c.NoError(batch.Add(context.Background(), relay))
+	}
+
+	c.Eventually(func() bool { return batch.Size() == 3 }, time.Second, 10*time.Millisecond)
+	c.Positive(batch.OldestItemAge())
+	c.NotEqual("0s", batch.Status().OldestItemAge)
+
+	// The gateway lag is measured on arrival
+	gatewayLag := histogram(t, gatewayLagSeconds.WithLabelValues("lag_relay"))
+	c.Equal(uint64(3), gatewayLag.GetSampleCount()-gatewayLagBefore.GetSampleCount())
+	c.GreaterOrEqual(gatewayLag.GetSampleSum()-gatewayLagBefore.GetSampleSum(), 6.0)
+
+	saved, err := batch.Flush()
+	c.NoError(err)
+	c.Equal(3, saved)
+
+	// The save lag once saved, after which no item is left
+	saveLag := histogram(t, saveLagSeconds.WithLabelValues("lag_relay"))
+	c.Equal(uint64(3), saveLag.GetSampleCount()-saveLagBefore.GetSampleCount())
+	c.Less(saveLag.GetSampleSum()-saveLagBefore.GetSampleSum(), 3.0)
+	c.Zero(batch.OldestItemAge())
+
+	writerMock.AssertExpectations(t)
+}
+
+func TestAgeCollector(t *testing.T) {
+	c := require.New(t)
+
+	collector := newAgeCollector()
+	collector.set("relay", func() time.Duration { return 90 * time.Second })
+	collector.set("service_record", func() time.Duration { return 0 })
+
+	c.NoError(testutil.CollectAndCompare(collector, strings.NewReader(`
+# HELP transaction_http_db_batch_oldest_item_age_seconds Age of the oldest item of each batch that is not saved yet, zero when there is none.
+# TYPE transaction_http_db_batch_oldest_item_age_seconds gauge
+transaction_http_db_batch_oldest_item_age_seconds{batch="relay"} 90
+transaction_http_db_batch_oldest_item_age_seconds{batch="service_record"} 0
+`)))
+}
+
+func histogram(t *testing.T, observer prometheus.Observer) *dto.Histogram {
+	t.Helper()
+
+	var metric dto.Metric
+	require.NoError(t, observer.(prometheus.Metric).Write(&metric))
+
+	return metric.GetHistogram()
+}
@@ -0,0 +1,371 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	sinkResultSuccess = "success"
+	sinkResultFailure = "failure"
+
+	defaultSinkQueueSize = 16
+)
+
+var (
+	sinkWritesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "transaction_http_db",
+		Name:      "sink_writes_total",
+		Help:      "Number of batch writes to each sink, by result. Retries count as separate writes.",
+	}, []string{"batch", "sink", "result"})
+	sinkDroppedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "transaction_http_db",
+		Name:      "sink_dropped_batches_total",
+		Help:      "Number of batches a secondary sink dropped because its queue was full or it ran out of retries.",
+	}, []string{"batch", "sink"})
+	sinkHealthy = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "transaction_http_db",
+		Name:      "sink_healthy",
+		Help:      "Whether the last write to each sink succeeded.",
+	}, []string{"batch", "sink"})
+)
+
+var errFanOutClosed = errors.New("fan out is closed")
+
+// ErrKeptForReplay is joined to the error of a write the primary sink failed when the items
+// were kept in the dead letter, to be replayed later
+var ErrKeptForReplay = errors.New("kept in the dead letter for a replay")
+
+// Sink is a destination of the batches of a FanOut along with its failure handling
+type Sink[T Validator] struct {
+	Name  string
+	Write func(ctx context.Context, items []T) error
+	// Timeout bounds each write attempt, no timeout other than the caller's if zero
+	Timeout time.Duration
+	// MaxRetries is how many times a failed write is retried
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry, doubled on each retry after it
+	RetryBackoff time.Duration
+	// QueueSize is how many batches a secondary sink holds while writing before dropping
+	// new ones, defaulting to 16
+	QueueSize int
+}
+
+// SinkHealth is the state of a sink of a FanOut
+type SinkHealth struct {
+	Name                string    `json:"name"`
+	Primary             bool      `json:"primary"`
+	Healthy             bool      `json:"healthy"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	LastSuccess         time.Time `json:"lastSuccess,omitempty"`
+	LastError           string    `json:"lastError,omitempty"`
+	Queued              int       `json:"queued"`
+	Dropped             int64     `json:"dropped"`
+}
+
+// queuedBatch is a batch waiting to be written by a secondary sink, along with the span of its save
+type queuedBatch[T Validator] struct {
+	items       []T
+	spanContext trace.SpanContext
+}
+
+type sinkState[T Validator] struct {
+	Sink[T]
+	primary bool
+	queue   chan queuedBatch[T]
+	mu      sync.Mutex
+	health  SinkHealth
+}
+
+// FanOut delivers each batch to a primary sink and to any number of secondary sinks.
+// The primary is written synchronously and its error is the one returned to the batch,
+// while each secondary gets its own queue and goroutine so a slow or failing secondary
+// never blocks nor fails the primary write.
+type FanOut[T Validator] struct {
+	name        string
+	primary     *sinkState[T]
+	secondaries []*sinkState[T]
+	mu          sync.RWMutex
+	closed      bool
+	wg          sync.WaitGroup
+	// deadLetter keeps the batches the primary failed to write, nil when they are dropped
+	deadLetter func(ctx context.Context, items []T) error
+	log        *zap.Logger
+}
+
+// FanOutOption configures optional features of a FanOut
+type FanOutOption[T Validator] func(*FanOut[T])
+
+// WithDeadLetter keeps the batches the primary sink failed to write with deadLetter, for
+// them to be written to the primary again later
+func WithDeadLetter[T Validator](deadLetter func(ctx context.Context, items []T) error) FanOutOption[T] {
+	return func(f *FanOut[T]) {
+		f.deadLetter = deadLetter
+	}
+}
+
+// NewFanOut returns a FanOut for the batch with the given name and starts the secondary sinks
+func NewFanOut[T Validator](name string, primary Sink[T], secondaries []Sink[T], logger *zap.Logger, opts ...FanOutOption[T]) *FanOut[T] {
+	f := &FanOut[T]{
+		name:    name,
+		primary: newSinkState(primary, true),
+		log:     logger,
+	}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	sinkHealthy.WithLabelValues(name, primary.Name).Set(1)
+
+	for _, secondary := range secondaries {
+		if secondary.QueueSize <= 0 {
+			secondary.QueueSize = defaultSinkQueueSize
+		}
+
+		state := newSinkState(secondary, false)
+		state.queue = make(chan queuedBatch[T], secondary.QueueSize)
+		f.secondaries = append(f.secondaries, state)
+
+		sinkHealthy.WithLabelValues(name, secondary.Name).Set(1)
+
+		f.wg.Add(1)
+		go f.runSecondary(state)
+	}
+
+	return f
+}
+
+func newSinkState[T Validator](sink Sink[T], primary bool) *sinkState[T] {
+	return &sinkState[T]{
+		Sink:    sink,
+		primary: primary,
+		health:  SinkHealth{Name: sink.Name, Primary: primary, Healthy: true},
+	}
+}
+
+// Write writes the items to the primary sink and, once it succeeds, queues them for the
+// secondary sinks, so they never hold items the primary failed to write.
+// Its signature matches the batch writers so it can be given to a batch.
+func (f *FanOut[T]) Write(ctx context.Context, items []T) error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.closed {
+		return errFanOutClosed
+	}
+
+	if err := f.write(ctx, f.primary, items); err != nil {
+		if f.keepDeadLetter(ctx, items) {
+			return fmt.Errorf("%w, %w", err, ErrKeptForReplay)
+		}
+
+		return err
+	}
+
+	queued := queuedBatch[T]{items: items, spanContext: trace.SpanContextFromContext(ctx)}
+
+	for _, secondary := range f.secondaries {
+		select {
+		case secondary.queue <- queued:
+		default:
+			f.drop(secondary, "queue is full")
+		}
+	}
+
+	return nil
+}
+
+// Close stops accepting batches and waits until the secondary sinks write the batches
+// they have queued or ctx is done
+func (f *FanOut[T]) Close(ctx context.Context) error {
+	f.mu.Lock()
+	if !f.closed {
+		f.closed = true
+		for _, secondary := range f.secondaries {
+			close(secondary.queue)
+		}
+	}
+	f.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("error waiting for %s secondary sinks: %w", f.name, ctx.Err())
+	}
+}
+
+// Name returns the name of the batch the FanOut writes
+func (f *FanOut[T]) Name() string {
+	return f.name
+}
+
+// Health returns the state of the primary sink followed by the secondary ones
+func (f *FanOut[T]) Health() []SinkHealth {
+	health := []SinkHealth{f.primary.snapshot()}
+	for _, secondary := range f.secondaries {
+		health = append(health, secondary.snapshot())
+	}
+
+	return health
+}
+
+func (s *sinkState[T]) snapshot() SinkHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	health := s.health
+	health.Queued = len(s.queue)
+
+	return health
+}
+
+func (f *FanOut[T]) runSecondary(state *sinkState[T]) {
+	defer f.wg.Done()
+
+	for queued := range state.queue {
+		// Secondary writes are not tied to the batch save, so they get their own context,
+		// their spans still being part of the trace of the save
+		ctx := trace.ContextWithSpanContext(context.Background(), queued.spanContext)
+
+		if err := f.write(ctx, state, queued.items); err != nil {
+			f.drop(state, err.Error())
+		}
+	}
+}
+
+// write writes the items to the sink, retrying with backoff until it succeeds,
+// runs out of retries or ctx is done
+func (f *FanOut[T]) write(ctx context.Context, state *sinkState[T], items []T) (err error) {
+	ctx, span := tracer().Start(ctx, "Sink.Write", trace.WithAttributes(
+		attribute.String("batch.name", f.name),
+		attribute.String("sink.name", state.Name),
+		attribute.Bool("sink.primary", state.primary),
+		attribute.Int("batch.items", len(items)),
+	))
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+
+		span.End()
+	}()
+
+	backoff := state.RetryBackoff
+
+	for attempt := 0; attempt <= state.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			backoff *= 2
+		}
+
+		span.SetAttributes(attribute.Int("sink.attempts", attempt+1))
+
+		err = f.attempt(ctx, state, items)
+		if err == nil {
+			return nil
+		}
+
+		span.RecordError(err)
+
+		f.log.Warn(fmt.Sprintf("error writing %s batch to %s sink: %s", f.name, state.Name, err),
+			zap.String("name", f.name),
+			zap.String("sink", state.Name),
+			zap.Int("attempt", attempt+1),
+		)
+	}
+
+	return err
+}
+
+func (f *FanOut[T]) attempt(ctx context.Context, state *sinkState[T], items []T) error {
+	if state.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, state.Timeout)
+		defer cancel()
+	}
+
+	err := state.Write(ctx, items)
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if err != nil {
+		state.health.Healthy = false
+		state.health.ConsecutiveFailures++
+		state.health.LastError = err.Error()
+
+		sinkWritesTotal.WithLabelValues(f.name, state.Name, sinkResultFailure).Inc()
+		sinkHealthy.WithLabelValues(f.name, state.Name).Set(0)
+
+		return err
+	}
+
+	state.health.Healthy = true
+	state.health.ConsecutiveFailures = 0
+	state.health.LastSuccess = time.Now()
+
+	sinkWritesTotal.WithLabelValues(f.name, state.Name, sinkResultSuccess).Inc()
+	sinkHealthy.WithLabelValues(f.name, state.Name).Set(1)
+
+	return nil
+}
+
+// keepDeadLetter writes the items the primary failed to write to the dead letter sink,
+// which is done even when the save timed out, returning whether they were kept
+func (f *FanOut[T]) keepDeadLetter(ctx context.Context, items []T) bool {
+	if f.deadLetter == nil {
+		return false
+	}
+
+	if err := f.deadLetter(context.WithoutCancel(ctx), items); err != nil {
+		f.log.Error(fmt.Sprintf("error keeping %s batch the %s sink failed to write: %s", f.name, f.primary.Name, err),
+			zap.String("name", f.name),
+			zap.Int("items", len(items)),
+		)
+
+		return false
+	}
+
+	f.log.Warn(fmt.Sprintf("%s batch the %s sink failed to write kept for a replay", f.name, f.primary.Name),
+		zap.String("name", f.name),
+		zap.Int("items", len(items)),
+	)
+
+	return true
+}
+
+func (f *FanOut[T]) drop(state *sinkState[T], reason string) {
+	state.mu.Lock()
+	state.health.Dropped++
+	state.mu.Unlock()
+
+	sinkDroppedTotal.WithLabelValues(f.name, state.Name).Inc()
+
+	f.log.Error(fmt.Sprintf("%s batch dropped by %s sink: %s", f.name, state.Name, reason),
+		zap.String("name", f.name),
+		zap.String("sink", state.Name),
+	)
+}
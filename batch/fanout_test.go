@@ -0,0 +1,183 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestFanOut_Write(t *testing.T) {
+	c := require.New(t)
+
+	errDummy := errors.New("dummy")
+
+	relays := []*types.Relay{{PoktChainID: "21"}}
+
+	tests := []struct {
+		name                    string
+		primaryErr              error
+		secondaryErr            error
+		expectedPrimaryCalls    int32
+		expectedSecondaryCalls  int32
+		expectedSecondaryHealth SinkHealth
+	}{
+		{
+			name:                   "All sinks succeed",
+			expectedPrimaryCalls:   1,
+			expectedSecondaryCalls: 1,
+			expectedSecondaryHealth: SinkHealth{
+				Name:    "archive",
+				Healthy: true,
+			},
+		},
+		{
+			name:                   "Failing secondary does not fail the primary",
+			secondaryErr:           errDummy,
+			expectedPrimaryCalls:   1,
+			expectedSecondaryCalls: 3,
+			expectedSecondaryHealth: SinkHealth{
+				Name:                "archive",
+				ConsecutiveFailures: 3,
+				LastError:           errDummy.Error(),
+				Dropped:             1,
+			},
+		},
+		{
+			name:                   "Failing primary is retried and not delivered to the secondary",
+			primaryErr:             errDummy,
+			expectedPrimaryCalls:   2,
+			expectedSecondaryCalls: 0,
+			expectedSecondaryHealth: SinkHealth{
+				Name:    "archive",
+				Healthy: true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		var primaryCalls, secondaryCalls atomic.Int32
+
+		fanOut := NewFanOut("relay", Sink[*types.Relay]{
+			Name: "postgres",
+			Write: func(ctx context.Context, items []*types.Relay) error {
+				primaryCalls.Add(1)
+				return tt.primaryErr
+			},
+			MaxRetries: 1,
+		}, []Sink[*types.Relay]{{
+			Name: "archive",
+			Write: func(ctx context.Context, items []*types.Relay) error {
+				c.Equal(relays, items, tt.name)
+				secondaryCalls.Add(1)
+				return tt.secondaryErr
+			},
+			MaxRetries:   2,
+			RetryBackoff: time.Millisecond,
+		}}, zap.NewNop())
+
+		err := fanOut.Write(context.Background(), relays)
+		c.ErrorIs(err, tt.primaryErr, tt.name)
+
+		c.NoError(fanOut.Close(context.Background()), tt.name)
+		c.ErrorIs(fanOut.Write(context.Background(), relays), errFanOutClosed, tt.name)
+
+		c.Equal(tt.expectedPrimaryCalls, primaryCalls.Load(), tt.name)
+		c.Equal(tt.expectedSecondaryCalls, secondaryCalls.Load(), tt.name)
+
+		health := fanOut.Health()
+		c.Len(health, 2, tt.name)
+		c.True(health[0].Primary, tt.name)
+		c.Equal(tt.primaryErr == nil, health[0].Healthy, tt.name)
+
+		secondaryHealth := health[1]
+		secondaryHealth.LastSuccess = time.Time{}
+		c.Equal(tt.expectedSecondaryHealth, secondaryHealth, tt.name)
+	}
+}
+
+func TestFanOut_DeadLetter(t *testing.T) {
+	c := require.New(t)
+
+	errDummy := errors.New("dummy")
+
+	var primaryErr, deadLetterErr error
+	var deadLetters [][]*types.Relay
+
+	fanOut := NewFanOut("relay", Sink[*types.Relay]{
+		Name: "postgres",
+		Write: func(ctx context.Context, items []*types.Relay) error {
+			return primaryErr
+		},
+	}, nil, zap.NewNop(), WithDeadLetter(func(ctx context.Context, items []*types.Relay) error {
+		if deadLetterErr != nil {
+			return deadLetterErr
+		}
+
+		deadLetters = append(deadLetters, items)
+		return nil
+	}))
+
+	relays := []*types.Relay{{PoktChainID: "21"}}
+
+	c.NoError(fanOut.Write(context.Background(), relays))
+	c.Empty(deadLetters)
+
+	// Only the batches the primary failed to write are kept
+	primaryErr = errDummy
+	err := fanOut.Write(context.Background(), relays)
+	c.ErrorIs(err, errDummy)
+	c.ErrorIs(err, ErrKeptForReplay)
+	c.Equal([][]*types.Relay{relays}, deadLetters)
+
+	// A batch the dead letter failed to keep is reported as such
+	deadLetterErr = errors.New("disk full")
+	err = fanOut.Write(context.Background(), relays)
+	c.ErrorIs(err, errDummy)
+	c.NotErrorIs(err, ErrKeptForReplay)
+	c.Len(deadLetters, 1)
+
+	c.NoError(fanOut.Close(context.Background()))
+}
+
+func TestFanOut_SlowSecondary(t *testing.T) {
+	c := require.New(t)
+
+	release := make(chan struct{})
+
+	fanOut := NewFanOut("relay", Sink[*types.Relay]{
+		Name:  "postgres",
+		Write: func(ctx context.Context, items []*types.Relay) error { return nil },
+	}, []Sink[*types.Relay]{{
+		Name: "archive",
+		Write: func(ctx context.Context, items []*types.Relay) error {
+			<-release
+			return nil
+		},
+		QueueSize: 1,
+	}}, zap.NewNop())
+
+	// The first batch is being written, the second one is queued and the rest are dropped
+	// without blocking the primary
+	for i := 0; i < 4; i++ {
+		c.NoError(fanOut.Write(context.Background(), []*types.Relay{{}}))
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	health := fanOut.Health()[1]
+	c.Equal(1, health.Queued)
+	c.Equal(int64(2), health.Dropped)
+
+	// Close gives up waiting once its context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	c.Error(fanOut.Close(ctx))
+
+	close(release)
+	c.NoError(fanOut.Close(context.Background()))
+}
@@ -0,0 +1,95 @@
+package batch
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// lagBuckets go from the latency of a request to the longest batch durations and retries
+var lagBuckets = []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 120, 300, 600, 1800}
+
+var (
+	gatewayLagSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "transaction_http_db",
+		Name:      "batch_gateway_lag_seconds",
+		Help:      "Time between the gateway time of an item, such as the return time of a relay, and its arrival at the service.",
+		Buckets:   lagBuckets,
+	}, []string{"batch"})
+	saveLagSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "transaction_http_db",
+		Name:      "batch_save_lag_seconds",
+		Help:      "Time between the arrival of an item at the service and its save to the primary sink.",
+		Buckets:   lagBuckets,
+	}, []string{"batch"})
+)
+
+var oldestItemAges = newAgeCollector()
+
+func init() {
+	prometheus.MustRegister(oldestItemAges)
+}
+
+// ageCollector exports the age of the oldest unsaved item of each batch, read when scraped
+type ageCollector struct {
+	mu      sync.Mutex
+	batches map[string]func() time.Duration
+
+	oldestItemAge *prometheus.Desc
+}
+
+func newAgeCollector() *ageCollector {
+	return &ageCollector{
+		batches: make(map[string]func() time.Duration),
+		oldestItemAge: prometheus.NewDesc(prometheus.BuildFQName("transaction_http_db", "batch", "oldest_item_age_seconds"),
+			"Age of the oldest item of each batch that is not saved yet, zero when there is none.", []string{"batch"}, nil),
+	}
+}
+
+// set exports the oldest item age of the batch with the name, replacing the batch created
+// before with the same name if any
+func (c *ageCollector) set(name string, oldestItemAge func() time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.batches[name] = oldestItemAge
+}
+
+func (c *ageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.oldestItemAge
+}
+
+func (c *ageCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for name, oldestItemAge := range c.batches {
+		ch <- prometheus.MustNewConstMetric(c.oldestItemAge, prometheus.GaugeValue, oldestItemAge().Seconds(), name)
+	}
+}
+
+// observeGatewayLag records how long the item took to arrive since its gateway time,
+// negative lags from clock skew between the gateway and the service counting as none
+func observeGatewayLag(name string, itemTime, addedAt time.Time) {
+	if itemTime.IsZero() {
+		return
+	}
+
+	lag := addedAt.Sub(itemTime)
+	if lag < 0 {
+		lag = 0
+	}
+
+	gatewayLagSeconds.WithLabelValues(name).Observe(lag.Seconds())
+}
+
+// observeSaveLags records how long each of the entries took to be saved since its arrival
+func observeSaveLags[T Validator](name string, entries []entry[T], savedAt time.Time) {
+	observer := saveLagSeconds.WithLabelValues(name)
+
+	for _, entry := range entries {
+		observer.Observe(savedAt.Sub(entry.addedAt).Seconds())
+	}
+}
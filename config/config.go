@@ -0,0 +1,561 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pokt-foundation/transaction-http-db/router"
+	"github.com/pokt-foundation/transaction-http-db/sink/archive"
+	"github.com/pokt-foundation/transaction-http-db/sink/kafka"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	"github.com/pokt-foundation/transaction-http-db/storage/cache"
+	"github.com/pokt-foundation/transaction-http-db/tracing"
+	"gopkg.in/yaml.v3"
+)
+
+// The schema check either stops the service, keeps it not ready or is skipped
+const (
+	SchemaCheckFail      = "fail"
+	SchemaCheckReadiness = "readiness"
+	SchemaCheckOff       = "off"
+)
+
+// The storage backends with settings of their own. The backends are registered by the
+// packages imported by the binary, the config only knowing the names and keys they read.
+const (
+	BackendPostgres = "postgres"
+	BackendSQLite   = "sqlite"
+)
+
+// The readiness max save age defaults to this many times the longest batch duration
+const defaultReadinessSaveAgeFactor = 3
+
+type (
+	// Config holds every setting of the service. Each setting is read from the environment
+	// variable in its env tag, which takes precedence over the file key in its yaml tag.
+	Config struct {
+		APIKeys      []string `yaml:"api_keys" env:"API_KEYS"`
+		AdminAPIKeys []string `yaml:"admin_api_keys" env:"ADMIN_API_KEYS"`
+		// APIKeyLabels names the API keys and admin API keys in limits and reports
+		APIKeyLabels KeyLabels `yaml:"api_key_labels" env:"API_KEY_LABELS"`
+		Port         string    `yaml:"port" env:"PORT"`
+		ChanSize     int       `yaml:"chan_size" env:"CHAN_SIZE"`
+		DBTimeout    Duration  `yaml:"db_timeout" env:"DB_TIMEOUT"`
+		Debug        bool      `yaml:"debug" env:"DEBUG"`
+		SchemaCheck  string    `yaml:"schema_check" env:"SCHEMA_CHECK"`
+
+		Log         Log         `yaml:"log"`
+		Storage     Storage     `yaml:"storage"`
+		Batches     Batches     `yaml:"batches"`
+		RateLimits  RateLimits  `yaml:"rate_limits"`
+		Usage       Usage       `yaml:"usage"`
+		Readiness   Readiness   `yaml:"readiness"`
+		Server      Server      `yaml:"server"`
+		Sinks       Sinks       `yaml:"sinks"`
+		Archive     Archive     `yaml:"archive"`
+		Kafka       Kafka       `yaml:"kafka"`
+		Cache       Cache       `yaml:"cache"`
+		Tracing     Tracing     `yaml:"tracing"`
+		Diagnostics Diagnostics `yaml:"diagnostics"`
+	}
+
+	// Log sets the sampling of the debug logs: each second, the first SamplingInitial debug logs
+	// with the same message are written, then every SamplingThereafter one. Sampling is disabled
+	// when SamplingInitial is zero.
+	Log struct {
+		SamplingInitial    int `yaml:"sampling_initial" env:"LOG_SAMPLING_INITIAL"`
+		SamplingThereafter int `yaml:"sampling_thereafter" env:"LOG_SAMPLING_THEREAFTER"`
+	}
+
+	Storage struct {
+		Backend  string   `yaml:"backend" env:"STORAGE_BACKEND"`
+		Postgres Postgres `yaml:"postgres"`
+		SQLite   SQLite   `yaml:"sqlite"`
+	}
+
+	Postgres struct {
+		User     string `yaml:"user" env:"PG_USER"`
+		Password string `yaml:"password" env:"PG_PASSWORD"`
+		Database string `yaml:"database" env:"PG_DATABASE"`
+		// Local DB - Required for development/test Env.
+		Host string `yaml:"host" env:"PG_HOST"`
+		Port string `yaml:"port" env:"PG_PORT"`
+		// CloudSQL DB - Required for production Env.
+		InstanceConnectionName string `yaml:"instance_connection_name" env:"DB_INSTANCE_CONNECTION_NAME"`
+		PrivateIP              bool   `yaml:"private_ip" env:"PRIVATE_IP"`
+		// Read replica - Optional, CloudSQL or local like the primary.
+		ReplicaHost                   string   `yaml:"replica_host" env:"PG_REPLICA_HOST"`
+		ReplicaPort                   string   `yaml:"replica_port" env:"PG_REPLICA_PORT"`
+		ReplicaInstanceConnectionName string   `yaml:"replica_instance_connection_name" env:"DB_REPLICA_INSTANCE_CONNECTION_NAME"`
+		ReplicaMaxStaleness           Duration `yaml:"replica_max_staleness" env:"REPLICA_MAX_STALENESS"`
+		// Connection pool - Optional, pgx defaults being used when zero.
+		MaxConns          int      `yaml:"max_conns" env:"PG_MAX_CONNS"`
+		MinConns          int      `yaml:"min_conns" env:"PG_MIN_CONNS"`
+		MaxConnLifetime   Duration `yaml:"max_conn_lifetime" env:"PG_MAX_CONN_LIFETIME"`
+		MaxConnIdleTime   Duration `yaml:"max_conn_idle_time" env:"PG_MAX_CONN_IDLE_TIME"`
+		HealthCheckPeriod Duration `yaml:"health_check_period" env:"PG_HEALTH_CHECK_PERIOD"`
+	}
+
+	SQLite struct {
+		Path string `yaml:"path" env:"SQLITE_PATH"`
+	}
+
+	Batches struct {
+		MaxRelayBatchSize             int      `yaml:"max_relay_batch_size" env:"MAX_RELAY_BATCH_SIZE"`
+		MaxRelayBatchDuration         Duration `yaml:"max_relay_batch_duration" env:"MAX_RELAY_BATCH_DURATION"`
+		MaxServiceRecordBatchSize     int      `yaml:"max_service_record_batch_size" env:"MAX_SERVICE_RECORD_BATCH_SIZE"`
+		MaxServiceRecordBatchDuration Duration `yaml:"max_service_record_batch_duration" env:"MAX_SERVICE_RECORD_BATCH_DURATION"`
+	}
+
+	RateLimits struct {
+		DefaultRequestsPerSecond float64 `yaml:"default_requests_per_second" env:"DEFAULT_REQUESTS_PER_SECOND"`
+		DefaultItemsPerSecond    float64 `yaml:"default_items_per_second" env:"DEFAULT_ITEMS_PER_SECOND"`
+		// The bursts default to one second worth of requests or items when zero
+		DefaultRequestsBurst int `yaml:"default_requests_burst" env:"DEFAULT_REQUESTS_BURST"`
+		DefaultItemsBurst    int `yaml:"default_items_burst" env:"DEFAULT_ITEMS_BURST"`
+		// PerKeyLabel overrides the default limits of the API keys with the label
+		PerKeyLabel KeyRateLimits `yaml:"per_key_label" env:"API_KEY_RATE_LIMITS"`
+	}
+
+	Usage struct {
+		File          string   `yaml:"file" env:"USAGE_FILE"`
+		FlushInterval Duration `yaml:"flush_interval" env:"USAGE_FLUSH_INTERVAL"`
+		RetentionDays int      `yaml:"retention_days" env:"USAGE_RETENTION_DAYS"`
+	}
+
+	Readiness struct {
+		DBTimeout       Duration `yaml:"db_timeout" env:"READINESS_DB_TIMEOUT"`
+		MaxBacklogRatio float64  `yaml:"max_backlog_ratio" env:"READINESS_MAX_BACKLOG_RATIO"`
+		// MaxSaveAge defaults to 3 times the longest batch duration when zero
+		MaxSaveAge Duration `yaml:"max_save_age" env:"READINESS_MAX_SAVE_AGE"`
+		DrainDelay Duration `yaml:"drain_delay" env:"READINESS_DRAIN_DELAY"`
+	}
+
+	Server struct {
+		ReadHeaderTimeout Duration `yaml:"read_header_timeout" env:"READ_HEADER_TIMEOUT"`
+		ReadTimeout       Duration `yaml:"read_timeout" env:"READ_TIMEOUT"`
+		WriteTimeout      Duration `yaml:"write_timeout" env:"WRITE_TIMEOUT"`
+		IdleTimeout       Duration `yaml:"idle_timeout" env:"IDLE_TIMEOUT"`
+		MaxHeaderBytes    int      `yaml:"max_header_bytes" env:"MAX_HEADER_BYTES"`
+		MaxBodyBytes      int64    `yaml:"max_body_bytes" env:"MAX_BODY_BYTES"`
+		ShutdownTimeout   Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	}
+
+	Sinks struct {
+		PrimaryMaxRetries int      `yaml:"primary_max_retries" env:"PRIMARY_SINK_MAX_RETRIES"`
+		Timeout           Duration `yaml:"timeout" env:"SINK_TIMEOUT"`
+		MaxRetries        int      `yaml:"max_retries" env:"SINK_MAX_RETRIES"`
+		RetryBackoff      Duration `yaml:"retry_backoff" env:"SINK_RETRY_BACKOFF"`
+		QueueSize         int      `yaml:"queue_size" env:"SINK_QUEUE_SIZE"`
+		// DeadLetterDir keeps the batches the primary sink failed to write, for the replay command
+		DeadLetterDir string `yaml:"dead_letter_dir" env:"DEAD_LETTER_DIR"`
+	}
+
+	Archive struct {
+		Dir          string   `yaml:"dir" env:"ARCHIVE_DIR"`
+		Format       string   `yaml:"format" env:"ARCHIVE_FORMAT"`
+		MaxFileBytes int64    `yaml:"max_file_bytes" env:"ARCHIVE_MAX_FILE_BYTES"`
+		MaxFileAge   Duration `yaml:"max_file_age" env:"ARCHIVE_MAX_FILE_AGE"`
+	}
+
+	Kafka struct {
+		Brokers            []string `yaml:"brokers" env:"KAFKA_BROKERS"`
+		RelayTopic         string   `yaml:"relay_topic" env:"KAFKA_RELAY_TOPIC"`
+		ServiceRecordTopic string   `yaml:"service_record_topic" env:"KAFKA_SERVICE_RECORD_TOPIC"`
+		ClientID           string   `yaml:"client_id" env:"KAFKA_CLIENT_ID"`
+		Acks               string   `yaml:"acks" env:"KAFKA_ACKS"`
+		Idempotent         bool     `yaml:"idempotent" env:"KAFKA_IDEMPOTENT"`
+		Compression        string   `yaml:"compression" env:"KAFKA_COMPRESSION"`
+		KeyBy              string   `yaml:"key_by" env:"KAFKA_KEY_BY"`
+	}
+
+	Cache struct {
+		Size int      `yaml:"size" env:"CACHE_SIZE"`
+		TTL  Duration `yaml:"ttl" env:"CACHE_TTL"`
+	}
+
+	Tracing struct {
+		Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT"`
+		SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+		ServiceName string  `yaml:"service_name" env:"TRACING_SERVICE_NAME"`
+	}
+
+	// Diagnostics serves pprof and the runtime stats under the admin routes, or on Addr
+	// without API keys when it is set
+	Diagnostics struct {
+		Enabled bool   `yaml:"enabled" env:"DIAGNOSTICS_ENABLED"`
+		Addr    string `yaml:"addr" env:"DIAGNOSTICS_ADDR"`
+	}
+
+	RateLimit struct {
+		RequestsPerSecond float64 `yaml:"requests_per_second"`
+		RequestsBurst     int     `yaml:"requests_burst"`
+		ItemsPerSecond    float64 `yaml:"items_per_second"`
+		ItemsBurst        int     `yaml:"items_burst"`
+	}
+
+	// KeyRateLimits holds the rate limits by API key label, read from the environment with
+	// the format "label:requestsPerSecond:itemsPerSecond[:requestsBurst:itemsBurst],..."
+	KeyRateLimits map[string]RateLimit
+
+	// KeyLabels holds the API keys by label, read from the environment with the format
+	// "label:key,...", where the key is everything after the first colon
+	KeyLabels map[string][]string
+)
+
+// Default returns the settings used for what is neither in the file nor in the environment
+func Default() Config {
+	return Config{
+		Port:        "8080",
+		ChanSize:    10000,
+		DBTimeout:   Seconds(60),
+		SchemaCheck: SchemaCheckFail,
+		Log: Log{
+			SamplingInitial:    100,
+			SamplingThereafter: 100,
+		},
+		Storage: Storage{
+			Backend: BackendPostgres,
+		},
+		Batches: Batches{
+			MaxRelayBatchSize:             1000,
+			MaxRelayBatchDuration:         Seconds(60),
+			MaxServiceRecordBatchSize:     1000,
+			MaxServiceRecordBatchDuration: Seconds(60),
+		},
+		Usage: Usage{
+			FlushInterval: Seconds(60),
+			RetentionDays: 90,
+		},
+		Readiness: Readiness{
+			DBTimeout:       Seconds(2),
+			MaxBacklogRatio: 0.9,
+			DrainDelay:      Seconds(5),
+		},
+		Server: Server{
+			ReadHeaderTimeout: Seconds(10),
+			ReadTimeout:       Seconds(30),
+			WriteTimeout:      Seconds(30),
+			IdleTimeout:       Seconds(120),
+			MaxHeaderBytes:    1 << 20,
+			MaxBodyBytes:      10 << 20,
+			ShutdownTimeout:   Seconds(30),
+		},
+		Sinks: Sinks{
+			Timeout:      Seconds(30),
+			MaxRetries:   3,
+			RetryBackoff: Seconds(1),
+			QueueSize:    16,
+		},
+		Archive: Archive{
+			Format:       string(archive.FormatNDJSON),
+			MaxFileBytes: 128 << 20,
+			MaxFileAge:   Seconds(3600),
+		},
+		Kafka: Kafka{
+			RelayTopic:         "relays",
+			ServiceRecordTopic: "service_records",
+			ClientID:           "transaction-http-db",
+			Acks:               string(kafka.AcksAll),
+			Idempotent:         true,
+			Compression:        string(kafka.CompressionSnappy),
+			KeyBy:              string(kafka.KeyBySessionKey),
+		},
+		Cache: Cache{
+			TTL: Seconds(3600),
+		},
+		Tracing: Tracing{
+			SampleRatio: 1,
+			ServiceName: "transaction-http-db",
+		},
+	}
+}
+
+// Load returns the default settings overridden by the YAML file at path, if any,
+// and then by the environment. The settings are not validated.
+func Load(path string) (Config, error) {
+	return load(path, os.LookupEnv)
+}
+
+func load(path string, lookupEnv func(string) (string, bool)) (Config, error) {
+	config, err := loadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	if err := applyEnv(&config, lookupEnv); err != nil {
+		return Config{}, err
+	}
+
+	if config.Readiness.MaxSaveAge == 0 {
+		longestBatchDuration := config.Batches.MaxRelayBatchDuration
+		if config.Batches.MaxServiceRecordBatchDuration > longestBatchDuration {
+			longestBatchDuration = config.Batches.MaxServiceRecordBatchDuration
+		}
+
+		config.Readiness.MaxSaveAge = defaultReadinessSaveAgeFactor * longestBatchDuration
+	}
+
+	return config, nil
+}
+
+// loadFile returns the default settings overridden by the YAML file at path, if any
+func loadFile(path string) (Config, error) {
+	config := Default()
+
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("error reading config file: %w", err)
+		}
+
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+
+		if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+			return Config{}, fmt.Errorf("error parsing config file %s: %w", path, err)
+		}
+	}
+
+	return config, nil
+}
+
+// DecodeEnv parses the "label:requestsPerSecond:itemsPerSecond[:requestsBurst:itemsBurst],..." format
+func (l *KeyRateLimits) DecodeEnv(value string) error {
+	limits := make(KeyRateLimits)
+
+	for _, rawLimit := range parseList(value) {
+		parts := strings.Split(rawLimit, ":")
+		if len(parts) != 3 && len(parts) != 5 {
+			return fmt.Errorf("invalid entry %q, expected label:requestsPerSecond:itemsPerSecond[:requestsBurst:itemsBurst]", rawLimit)
+		}
+
+		var (
+			limit RateLimit
+			err   error
+		)
+
+		limit.RequestsPerSecond, err = strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return fmt.Errorf("invalid requests per second in entry %q", rawLimit)
+		}
+
+		limit.ItemsPerSecond, err = strconv.ParseFloat(parts[2], 64)
+		if err != nil {
+			return fmt.Errorf("invalid items per second in entry %q", rawLimit)
+		}
+
+		if len(parts) == 5 {
+			limit.RequestsBurst, err = strconv.Atoi(parts[3])
+			if err != nil {
+				return fmt.Errorf("invalid requests burst in entry %q", rawLimit)
+			}
+
+			limit.ItemsBurst, err = strconv.Atoi(parts[4])
+			if err != nil {
+				return fmt.Errorf("invalid items burst in entry %q", rawLimit)
+			}
+		}
+
+		limits[parts[0]] = limit
+	}
+
+	*l = limits
+
+	return nil
+}
+
+// DecodeEnv parses the "label:key,..." format, a label being repeated for each of its keys
+func (l *KeyLabels) DecodeEnv(value string) error {
+	labels := make(KeyLabels)
+
+	for _, entry := range parseList(value) {
+		label, key, found := strings.Cut(entry, ":")
+		if !found || label == "" || key == "" {
+			return errors.New("expected label:key entries")
+		}
+
+		labels[label] = append(labels[label], key)
+	}
+
+	*l = labels
+
+	return nil
+}
+
+// Keys returns the API keys, the admin API keys and the label of each labeled key
+func (c Config) Keys() (apiKeys, adminKeys map[string]bool, keyLabels map[string]string) {
+	keyLabels = make(map[string]string)
+	for label, keys := range c.APIKeyLabels {
+		for _, key := range keys {
+			keyLabels[key] = label
+		}
+	}
+
+	return keySet(c.APIKeys), keySet(c.AdminAPIKeys), keyLabels
+}
+
+func keySet(keys []string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[key] = true
+	}
+
+	return set
+}
+
+// StorageConfig returns the settings of the selected storage backend, keyed as read by
+// the backend packages
+func (c Config) StorageConfig() storage.Config {
+	switch c.Storage.Backend {
+	case BackendPostgres:
+		pg := c.Storage.Postgres
+
+		return storage.Config{
+			"user":                             pg.User,
+			"password":                         pg.Password,
+			"database":                         pg.Database,
+			"host":                             pg.Host,
+			"port":                             pg.Port,
+			"instance_connection_name":         pg.InstanceConnectionName,
+			"private_ip":                       strconv.FormatBool(pg.PrivateIP),
+			"replica_host":                     pg.ReplicaHost,
+			"replica_port":                     pg.ReplicaPort,
+			"replica_instance_connection_name": pg.ReplicaInstanceConnectionName,
+			"replica_max_staleness":            pg.ReplicaMaxStaleness.Duration().String(),
+			"max_conns":                        strconv.Itoa(pg.MaxConns),
+			"min_conns":                        strconv.Itoa(pg.MinConns),
+			"max_conn_lifetime":                pg.MaxConnLifetime.Duration().String(),
+			"max_conn_idle_time":               pg.MaxConnIdleTime.Duration().String(),
+			"health_check_period":              pg.HealthCheckPeriod.Duration().String(),
+		}
+
+	case BackendSQLite:
+		return storage.Config{
+			"path": c.Storage.SQLite.Path,
+		}
+
+	default:
+		return storage.Config{}
+	}
+}
+
+// DefaultRateLimit returns the rate limit of the API keys without a specific one
+func (c Config) DefaultRateLimit() router.RateLimit {
+	return router.RateLimit{
+		RequestsPerSecond: c.RateLimits.DefaultRequestsPerSecond,
+		RequestsBurst:     c.RateLimits.DefaultRequestsBurst,
+		ItemsPerSecond:    c.RateLimits.DefaultItemsPerSecond,
+		ItemsBurst:        c.RateLimits.DefaultItemsBurst,
+	}
+}
+
+// LabelRateLimits returns the rate limits by API key label
+func (c Config) LabelRateLimits() map[string]router.RateLimit {
+	limits := make(map[string]router.RateLimit, len(c.RateLimits.PerKeyLabel))
+	for label, limit := range c.RateLimits.PerKeyLabel {
+		limits[label] = router.RateLimit{
+			RequestsPerSecond: limit.RequestsPerSecond,
+			RequestsBurst:     limit.RequestsBurst,
+			ItemsPerSecond:    limit.ItemsPerSecond,
+			ItemsBurst:        limit.ItemsBurst,
+		}
+	}
+
+	return limits
+}
+
+func (c Config) ReadinessConfig() router.ReadinessConfig {
+	return router.ReadinessConfig{
+		DBPingTimeout:   c.Readiness.DBTimeout.Duration(),
+		MaxBacklogRatio: c.Readiness.MaxBacklogRatio,
+		MaxSaveAge:      c.Readiness.MaxSaveAge.Duration(),
+		DrainDelay:      c.Readiness.DrainDelay.Duration(),
+	}
+}
+
+func (c Config) ServerConfig() router.ServerConfig {
+	return router.ServerConfig{
+		ReadHeaderTimeout: c.Server.ReadHeaderTimeout.Duration(),
+		ReadTimeout:       c.Server.ReadTimeout.Duration(),
+		WriteTimeout:      c.Server.WriteTimeout.Duration(),
+		IdleTimeout:       c.Server.IdleTimeout.Duration(),
+		MaxHeaderBytes:    c.Server.MaxHeaderBytes,
+		MaxBodyBytes:      c.Server.MaxBodyBytes,
+		ShutdownTimeout:   c.Server.ShutdownTimeout.Duration(),
+	}
+}
+
+// TracingConfig returns the settings of the span exporter, the version being the one of the binary
+func (c Config) TracingConfig(version string) tracing.Config {
+	return tracing.Config{
+		Endpoint:       c.Tracing.Endpoint,
+		SampleRatio:    c.Tracing.SampleRatio,
+		ServiceName:    c.Tracing.ServiceName,
+		ServiceVersion: version,
+	}
+}
+
+func (c Config) DiagnosticsConfig() router.DiagnosticsConfig {
+	return router.DiagnosticsConfig{
+		Enabled: c.Diagnostics.Enabled,
+		Addr:    c.Diagnostics.Addr,
+	}
+}
+
+func (c Config) ArchiveConfig() archive.Config {
+	return archive.Config{
+		Dir:          c.Archive.Dir,
+		Format:       archive.Format(c.Archive.Format),
+		MaxFileBytes: c.Archive.MaxFileBytes,
+		MaxFileAge:   c.Archive.MaxFileAge.Duration(),
+	}
+}
+
+// DeadLetterConfig returns the settings of the archive keeping the batches the primary sink
+// failed to write. Each batch is a file of its own, closed right away so it can be replayed
+// while the service runs.
+func (c Config) DeadLetterConfig() archive.Config {
+	return archive.Config{
+		Dir:          c.Sinks.DeadLetterDir,
+		Format:       archive.FormatNDJSON,
+		FilePerWrite: true,
+	}
+}
+
+// KafkaConfigs returns the settings of the relay and service record producers
+func (c Config) KafkaConfigs() (relays, serviceRecords kafka.Config) {
+	relays = kafka.Config{
+		Brokers:     c.Kafka.Brokers,
+		Topic:       c.Kafka.RelayTopic,
+		ClientID:    c.Kafka.ClientID,
+		Acks:        kafka.Acks(c.Kafka.Acks),
+		Idempotent:  c.Kafka.Idempotent,
+		Compression: kafka.Compression(c.Kafka.Compression),
+		KeyBy:       kafka.KeyBy(c.Kafka.KeyBy),
+	}
+
+	serviceRecords = relays
+	serviceRecords.Topic = c.Kafka.ServiceRecordTopic
+
+	return relays, serviceRecords
+}
+
+func (c Config) CacheConfig() cache.Config {
+	return cache.Config{
+		Size:        c.Cache.Size,
+		TTL:         c.Cache.TTL.Duration(),
+		ReadTimeout: c.DBTimeout.Duration(),
+	}
+}
+
+// UsageRetention returns how long the usage is kept
+func (c Config) UsageRetention() time.Duration {
+	return time.Duration(c.Usage.RetentionDays) * 24 * time.Hour
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-http-db/router"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	"github.com/pokt-foundation/transaction-http-db/storage/postgres"
+	"github.com/pokt-foundation/transaction-http-db/storage/sqlite"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	c := require.New(t)
+
+	path := writeConfigFile(t, `
+api_keys: [file-key]
+api_key_labels:
+  file: [file-key]
+port: "9090"
+chan_size: 500
+storage:
+  backend: sqlite
+  sqlite:
+    path: /tmp/transactions.db
+batches:
+  max_relay_batch_size: 200
+  max_relay_batch_duration: 1m30s
+  max_service_record_batch_duration: 45
+rate_limits:
+  per_key_label:
+    gateway:
+      requests_per_second: 10
+      items_per_second: 100
+      items_burst: 500
+`)
+
+	t.Setenv("CHAN_SIZE", "2000")
+	t.Setenv("API_KEYS", "key1,key2,team:key3")
+	t.Setenv("ADMIN_API_KEYS", "admin")
+	t.Setenv("API_KEY_LABELS", "gateway:key1,ops:admin,backfill:team:key3")
+	t.Setenv("MAX_RELAY_BATCH_SIZE", "")
+
+	config, err := Load(path)
+	c.NoError(err)
+
+	// The env vars take precedence over the file, which takes precedence over the defaults
+	c.Equal(2000, config.ChanSize)
+	c.Equal("9090", config.Port)
+	c.Equal(200, config.Batches.MaxRelayBatchSize)
+	c.Equal(90*time.Second, config.Batches.MaxRelayBatchDuration.Duration())
+	c.Equal(45*time.Second, config.Batches.MaxServiceRecordBatchDuration.Duration())
+	c.Equal(1000, config.Batches.MaxServiceRecordBatchSize)
+	c.Equal(60*time.Second, config.DBTimeout.Duration())
+	c.Equal(3*90*time.Second, config.Readiness.MaxSaveAge.Duration())
+	c.Equal(storage.Config{sqlite.ConfigPath: "/tmp/transactions.db"}, config.StorageConfig())
+	c.Equal(map[string]router.RateLimit{"gateway": {RequestsPerSecond: 10, ItemsPerSecond: 100, ItemsBurst: 500}}, config.LabelRateLimits())
+
+	apiKeys, adminKeys, keyLabels := config.Keys()
+	// Keys with a colon are kept whole, their labels being set apart
+	c.Equal(map[string]bool{"key1": true, "key2": true, "team:key3": true}, apiKeys)
+	c.Equal(map[string]bool{"admin": true}, adminKeys)
+	c.Equal(map[string]string{"key1": "gateway", "admin": "ops", "team:key3": "backfill"}, keyLabels)
+
+	c.NoError(config.Validate())
+}
+
+func TestConfig_StorageConfig(t *testing.T) {
+	c := require.New(t)
+
+	// The config only knows the names and keys of the backends, which must match theirs
+	c.Equal(postgres.Name, BackendPostgres)
+	c.Equal(sqlite.Name, BackendSQLite)
+
+	config := Default()
+	config.Storage.Postgres = Postgres{
+		User:                "user",
+		Password:            "password",
+		Database:            "database",
+		Host:                "localhost",
+		Port:                "5432",
+		ReplicaMaxStaleness: Seconds(5),
+		MaxConns:            10,
+	}
+
+	c.Equal(storage.Config{
+		postgres.ConfigUser:                          "user",
+		postgres.ConfigPassword:                      "password",
+		postgres.ConfigDatabase:                      "database",
+		postgres.ConfigHost:                          "localhost",
+		postgres.ConfigPort:                          "5432",
+		postgres.ConfigInstanceConnectionName:        "",
+		postgres.ConfigPrivateIP:                     "false",
+		postgres.ConfigReplicaHost:                   "",
+		postgres.ConfigReplicaPort:                   "",
+		postgres.ConfigReplicaInstanceConnectionName: "",
+		postgres.ConfigReplicaMaxStaleness:           "5s",
+		postgres.ConfigMaxConns:                      "10",
+		postgres.ConfigMinConns:                      "0",
+		postgres.ConfigMaxConnLifetime:               "0s",
+		postgres.ConfigMaxConnIdleTime:               "0s",
+		postgres.ConfigHealthCheckPeriod:             "0s",
+	}, config.StorageConfig())
+}
+
+func TestLoad_errors(t *testing.T) {
+	c := require.New(t)
+
+	_, err := Load(writeConfigFile(t, "chan_sise: 500\n"))
+	c.ErrorContains(err, "field chan_sise not found")
+
+	_, err = Load(writeConfigFile(t, "db_timeout: soon\n"))
+	c.ErrorContains(err, "must be a number of seconds or a duration")
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	c.ErrorIs(err, os.ErrNotExist)
+
+	// Every invalid env var is reported at once
+	t.Setenv("CHAN_SIZE", "many")
+	t.Setenv("DEBUG", "maybe")
+	t.Setenv("API_KEY_RATE_LIMITS", "gateway:10")
+
+	_, err = Load("")
+	c.ErrorContains(err, `invalid CHAN_SIZE "many": must be an integer`)
+	c.ErrorContains(err, `invalid DEBUG "maybe": must be true or false`)
+	c.ErrorContains(err, `invalid API_KEY_RATE_LIMITS "gateway:10"`)
+}
+
+func TestKeyRateLimits_DecodeEnv(t *testing.T) {
+	c := require.New(t)
+
+	var limits KeyRateLimits
+	c.NoError(limits.DecodeEnv("gateway:10:100,backfill:1.5:0,bulk:1:100:2:1000"))
+	c.Equal(KeyRateLimits{
+		"gateway":  {RequestsPerSecond: 10, ItemsPerSecond: 100},
+		"backfill": {RequestsPerSecond: 1.5},
+		"bulk":     {RequestsPerSecond: 1, ItemsPerSecond: 100, RequestsBurst: 2, ItemsBurst: 1000},
+	}, limits)
+
+	c.Error(limits.DecodeEnv("gateway:ten:100"))
+	c.Error(limits.DecodeEnv("gateway:10:hundred"))
+	c.Error(limits.DecodeEnv("gateway:10:100:2"))
+	c.Error(limits.DecodeEnv("gateway:10:100:2:many"))
+}
+
+func TestKeyLabels_DecodeEnv(t *testing.T) {
+	c := require.New(t)
+
+	var labels KeyLabels
+	c.NoError(labels.DecodeEnv("gateway:key1,gateway:key2,ops:admin:key"))
+	c.Equal(KeyLabels{
+		"gateway": {"key1", "key2"},
+		"ops":     {"admin:key"},
+	}, labels)
+
+	c.Error(labels.DecodeEnv("key1"))
+	c.Error(labels.DecodeEnv(":key1"))
+	c.Error(labels.DecodeEnv("gateway:"))
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Duration is read from the environment as a number of seconds and from the file
+// either as a number of seconds or as a duration string such as "1m30s"
+type Duration time.Duration
+
+// Seconds returns a Duration of n seconds
+func Seconds(n int64) Duration {
+	return Duration(time.Duration(n) * time.Second)
+}
+
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+func (d *Duration) DecodeEnv(value string) error {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return errors.New("must be a number of seconds")
+	}
+
+	*d = Seconds(seconds)
+
+	return nil
+}
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	if seconds, err := strconv.ParseInt(node.Value, 10, 64); err == nil {
+		*d = Seconds(seconds)
+		return nil
+	}
+
+	duration, err := time.ParseDuration(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %q must be a number of seconds or a duration such as 1m30s", node.Line, node.Value)
+	}
+
+	*d = Duration(duration)
+
+	return nil
+}
+
+// envDecoder is implemented by the settings with their own environment variable format
+type envDecoder interface {
+	DecodeEnv(value string) error
+}
+
+var envDecoderType = reflect.TypeOf((*envDecoder)(nil)).Elem()
+
+// applyEnv overrides the settings whose environment variable is set and not empty,
+// reporting every variable that can't be parsed
+func applyEnv(config *Config, lookup func(string) (string, bool)) error {
+	var errs []error
+
+	walkSettings(reflect.ValueOf(config).Elem(), "", func(field reflect.Value, env, _ string) {
+		value, _ := lookup(env)
+		if value == "" {
+			return
+		}
+
+		if err := decodeEnv(field, value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s %q: %w", env, value, err))
+		}
+	})
+
+	return errors.Join(errs...)
+}
+
+// walkSettings calls fn with each setting of the struct v, its environment
+// variable and its dotted path in the file
+func walkSettings(v reflect.Value, prefix string, fn func(field reflect.Value, env, path string)) {
+	for i := 0; i < v.NumField(); i++ {
+		structField := v.Type().Field(i)
+		path := prefix + structField.Tag.Get("yaml")
+
+		if env, ok := structField.Tag.Lookup("env"); ok {
+			fn(v.Field(i), env, path)
+			continue
+		}
+
+		if structField.Type.Kind() == reflect.Struct {
+			walkSettings(v.Field(i), path+".", fn)
+		}
+	}
+}
+
+func decodeEnv(field reflect.Value, value string) error {
+	if field.Addr().Type().Implements(envDecoderType) {
+		return field.Addr().Interface().(envDecoder).DecodeEnv(value)
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+
+		field.SetBool(parsed)
+
+	case reflect.Int, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+
+		field.SetInt(parsed)
+
+	case reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return errors.New("must be a number")
+		}
+
+		field.SetFloat(parsed)
+
+	case reflect.Slice:
+		field.Set(reflect.ValueOf(parseList(value)))
+
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+
+	return nil
+}
+
+// parseList splits a comma separated list, skipping empty entries
+func parseList(rawList string) []string {
+	var list []string
+	for _, entry := range strings.Split(rawList, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+
+	return list
+}
+
+// envNames returns the dotted file path of each setting by environment variable
+func envNames() map[string]string {
+	names := make(map[string]string)
+
+	walkSettings(reflect.ValueOf(&Config{}).Elem(), "", func(_ reflect.Value, env, path string) {
+		names[env] = path
+	})
+
+	return names
+}
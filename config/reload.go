@@ -0,0 +1,228 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// reloadable holds the env vars of the settings applied without a restart
+var reloadable = map[string]bool{
+	"API_KEYS":                          true,
+	"ADMIN_API_KEYS":                    true,
+	"API_KEY_LABELS":                    true,
+	"DEBUG":                             true,
+	"MAX_RELAY_BATCH_SIZE":              true,
+	"MAX_RELAY_BATCH_DURATION":          true,
+	"MAX_SERVICE_RECORD_BATCH_SIZE":     true,
+	"MAX_SERVICE_RECORD_BATCH_DURATION": true,
+	"DEFAULT_REQUESTS_PER_SECOND":       true,
+	"DEFAULT_ITEMS_PER_SECOND":          true,
+	"DEFAULT_REQUESTS_BURST":            true,
+	"DEFAULT_ITEMS_BURST":               true,
+	"API_KEY_RATE_LIMITS":               true,
+}
+
+// secrets holds the env vars of the settings whose values are never reported
+var secrets = map[string]bool{
+	"API_KEYS":       true,
+	"ADMIN_API_KEYS": true,
+	"API_KEY_LABELS": true,
+	"PG_PASSWORD":    true,
+}
+
+// Change is a setting that differs between two configs
+type Change struct {
+	Setting    string
+	Old, New   string
+	Reloadable bool
+}
+
+func (c Change) String() string {
+	if !c.Reloadable {
+		return fmt.Sprintf("%s: %s -> %s (requires a restart, ignored)", c.Setting, c.Old, c.New)
+	}
+
+	return fmt.Sprintf("%s: %s -> %s", c.Setting, c.Old, c.New)
+}
+
+// Diff returns the settings that differ between old and new sorted by env var,
+// with the values of the secret ones masked
+func Diff(old, new Config) []Change {
+	oldValues := make(map[string]reflect.Value)
+	walkSettings(reflect.ValueOf(&old).Elem(), "", func(field reflect.Value, env, _ string) {
+		oldValues[env] = field
+	})
+
+	var changes []Change
+
+	walkSettings(reflect.ValueOf(&new).Elem(), "", func(field reflect.Value, env, _ string) {
+		oldValue := oldValues[env]
+		if reflect.DeepEqual(oldValue.Interface(), field.Interface()) {
+			return
+		}
+
+		change := Change{
+			Setting:    env,
+			Old:        formatSetting(oldValue),
+			New:        formatSetting(field),
+			Reloadable: reloadable[env],
+		}
+
+		if secrets[env] {
+			change.Old, change.New = "***", "***"
+		}
+
+		changes = append(changes, change)
+	})
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Setting < changes[j].Setting
+	})
+
+	return changes
+}
+
+func formatSetting(value reflect.Value) string {
+	if duration, ok := value.Interface().(Duration); ok {
+		return duration.Duration().String()
+	}
+
+	return fmt.Sprintf("%v", value.Interface())
+}
+
+// overridden returns the reloadable settings that changed in the file since the last
+// reload while their env var is set
+func (r *Reloader) overridden(file Config) []string {
+	var settings []string
+
+	for _, change := range Diff(r.file, file) {
+		if value, _ := r.lookupEnv(change.Setting); change.Reloadable && value != "" {
+			settings = append(settings, change.Setting)
+		}
+	}
+
+	return settings
+}
+
+// withReloadable returns c with the reloadable settings of next
+func (c Config) withReloadable(next Config) Config {
+	c.APIKeys = next.APIKeys
+	c.AdminAPIKeys = next.AdminAPIKeys
+	c.APIKeyLabels = next.APIKeyLabels
+	c.Debug = next.Debug
+	c.Batches = next.Batches
+	c.RateLimits = next.RateLimits
+
+	return c
+}
+
+// Reloader loads the settings again on demand and hands the reloadable ones to the
+// components using them, keeping the current settings when the new ones are invalid.
+// The env vars of a running process can't change, so the settings they set keep their
+// value, a change of those settings in the file being reported as overridden.
+type Reloader struct {
+	mu      sync.Mutex
+	current Config
+	path    string
+	// file holds the settings of the file alone as of the last reload
+	file      Config
+	lookupEnv func(string) (string, bool)
+	// load is Load, replaced in tests
+	load     func(path string, lookupEnv func(string) (string, bool)) (Config, error)
+	appliers []func(Config)
+	log      *zap.Logger
+}
+
+// NewReloader returns a Reloader of the current settings, loaded like Load from the
+// file at path and the environment
+func NewReloader(current Config, path string, logger *zap.Logger) *Reloader {
+	// Without a readable file every setting of the file is a change on the next reload
+	file, err := loadFile(path)
+	if err != nil {
+		file = Default()
+	}
+
+	return &Reloader{
+		current:   current,
+		path:      path,
+		file:      file,
+		lookupEnv: os.LookupEnv,
+		load:      load,
+		log:       logger,
+	}
+}
+
+// OnReload registers a function applying the settings after each reload that changed any
+func (r *Reloader) OnReload(apply func(Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.appliers = append(r.appliers, apply)
+}
+
+// Current returns the settings in use
+func (r *Reloader) Current() Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.current
+}
+
+// Reload loads and validates the settings, applies the reloadable ones that changed and
+// returns every change. Nothing is applied when the new settings are invalid.
+func (r *Reloader) Reload() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := r.load(r.path, r.lookupEnv)
+	if err == nil {
+		err = next.Validate()
+	}
+
+	if err != nil {
+		r.log.Error("Configuration reload rejected", zap.Error(err))
+		return nil, err
+	}
+
+	changes := Diff(r.current, next)
+
+	descriptions := make([]string, 0, len(changes))
+	applied := false
+
+	// The file loaded fine along with the env vars, so it loads fine alone
+	if file, err := loadFile(r.path); err == nil {
+		for _, setting := range r.overridden(file) {
+			descriptions = append(descriptions, fmt.Sprintf("%s: changed in the config file but overridden by the env var, ignored", setting))
+			r.log.Warn("Setting changed in the config file but overridden by the env var", zap.String("setting", setting))
+		}
+
+		r.file = file
+	}
+
+	for _, change := range changes {
+		descriptions = append(descriptions, change.String())
+
+		if !change.Reloadable {
+			r.log.Warn("Setting changed but requires a restart", zap.String("setting", change.Setting))
+			continue
+		}
+
+		applied = true
+		r.log.Info("Setting reloaded", zap.String("setting", change.Setting), zap.String("old", change.Old), zap.String("new", change.New))
+	}
+
+	if applied {
+		r.current = r.current.withReloadable(next)
+
+		for _, apply := range r.appliers {
+			apply(r.current)
+		}
+	}
+
+	return descriptions, nil
+}
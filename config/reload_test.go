@@ -0,0 +1,156 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestDiff(t *testing.T) {
+	c := require.New(t)
+
+	old := Default()
+	old.APIKeys = []string{"key"}
+
+	new := old
+	new.APIKeys = []string{"key", "other-key"}
+	new.Batches.MaxRelayBatchDuration = Seconds(30)
+	new.Port = "9090"
+
+	c.Equal([]Change{
+		{Setting: "API_KEYS", Old: "***", New: "***", Reloadable: true},
+		{Setting: "MAX_RELAY_BATCH_DURATION", Old: "1m0s", New: "30s", Reloadable: true},
+		{Setting: "PORT", Old: "8080", New: "9090"},
+	}, Diff(old, new))
+
+	c.Empty(Diff(old, old))
+}
+
+func TestReloader_Reload(t *testing.T) {
+	c := require.New(t)
+
+	current := Default()
+	current.APIKeys = []string{"key"}
+	current.Storage.Postgres = Postgres{User: "user", Password: "password", Database: "database", Host: "localhost", Port: "5432"}
+
+	var (
+		next    Config
+		loadErr error
+		applied []Config
+	)
+
+	reloader := NewReloader(current, "", zap.NewNop())
+	reloader.load = func(string, func(string) (string, bool)) (Config, error) { return next, loadErr }
+	reloader.OnReload(func(config Config) {
+		applied = append(applied, config)
+	})
+
+	// Invalid settings are rejected as a whole
+	next = current
+	next.Batches.MaxRelayBatchSize = 500
+	next.ChanSize = 0
+
+	_, err := reloader.Reload()
+	c.Error(err)
+	c.Empty(applied)
+	c.Equal(current, reloader.Current())
+
+	loadErr = errors.New("dummy")
+
+	_, err = reloader.Reload()
+	c.ErrorIs(err, loadErr)
+
+	loadErr = nil
+
+	// Only the reloadable settings are applied
+	next = current
+	next.Batches.MaxRelayBatchSize = 500
+	next.Debug = true
+	next.Port = "9090"
+
+	changes, err := reloader.Reload()
+	c.NoError(err)
+	c.Equal([]string{
+		"DEBUG: false -> true",
+		"MAX_RELAY_BATCH_SIZE: 1000 -> 500",
+		"PORT: 8080 -> 9090 (requires a restart, ignored)",
+	}, changes)
+
+	c.Len(applied, 1)
+	c.Equal(500, applied[0].Batches.MaxRelayBatchSize)
+	c.True(applied[0].Debug)
+	c.Equal("8080", applied[0].Port)
+	c.Equal(applied[0], reloader.Current())
+
+	// The settings requiring a restart are reported but kept
+	next = reloader.Current()
+	next.Storage.Postgres.MaxConns = 50
+	next.Batches.MaxServiceRecordBatchDuration = Duration(90 * time.Second)
+	next.Port = "8080"
+
+	changes, err = reloader.Reload()
+	c.NoError(err)
+	c.Equal([]string{
+		"MAX_SERVICE_RECORD_BATCH_DURATION: 1m0s -> 1m30s",
+		"PG_MAX_CONNS: 0 -> 50 (requires a restart, ignored)",
+	}, changes)
+	c.Len(applied, 2)
+	c.Equal(0, reloader.Current().Storage.Postgres.MaxConns)
+}
+
+func TestReloader_Reload_envOverride(t *testing.T) {
+	c := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile := func(content string) {
+		c.NoError(os.WriteFile(path, []byte(content+`
+api_keys: [key]
+storage:
+  postgres: {user: user, password: password, database: database, host: localhost, port: "5432"}
+`), 0o644))
+	}
+
+	env := map[string]string{"MAX_RELAY_BATCH_SIZE": "200"}
+	lookupEnv := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	writeFile("batches: {max_relay_batch_size: 100}")
+
+	current, err := load(path, lookupEnv)
+	c.NoError(err)
+	c.Equal(200, current.Batches.MaxRelayBatchSize)
+
+	reloader := NewReloader(current, path, zap.NewNop())
+	reloader.lookupEnv = lookupEnv
+
+	var applied []Config
+	reloader.OnReload(func(config Config) {
+		applied = append(applied, config)
+	})
+
+	// The env var keeps its value, the change of the file being reported instead
+	writeFile("debug: true\nbatches: {max_relay_batch_size: 300}")
+
+	changes, err := reloader.Reload()
+	c.NoError(err)
+	c.Equal([]string{
+		"MAX_RELAY_BATCH_SIZE: changed in the config file but overridden by the env var, ignored",
+		"DEBUG: false -> true",
+	}, changes)
+
+	c.Len(applied, 1)
+	c.Equal(200, applied[0].Batches.MaxRelayBatchSize)
+	c.True(applied[0].Debug)
+
+	// An unchanged file reports nothing
+	changes, err = reloader.Reload()
+	c.NoError(err)
+	c.Empty(changes)
+}
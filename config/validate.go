@@ -0,0 +1,218 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pokt-foundation/transaction-http-db/storage"
+)
+
+// validator collects the problems of a config, naming each setting by its
+// environment variable and its file key
+type validator struct {
+	names  map[string]string
+	errors []error
+}
+
+func (v *validator) check(ok bool, env, format string, args ...any) {
+	if ok {
+		return
+	}
+
+	v.errors = append(v.errors, fmt.Errorf("%s (%s) %s", env, v.names[env], fmt.Sprintf(format, args...)))
+}
+
+func (v *validator) checkErr(err error, section string) {
+	if err != nil {
+		v.errors = append(v.errors, fmt.Errorf("%s: %w", section, err))
+	}
+}
+
+// Validate reports every invalid setting at once, one per line
+func (c Config) Validate() error {
+	v := &validator{names: envNames()}
+
+	v.check(len(c.APIKeys) > 0, "API_KEYS", "must have at least one key")
+	v.check(validPort(c.Port), "PORT", "must be a port number, got %q", c.Port)
+	v.check(c.ChanSize > 0, "CHAN_SIZE", "must be positive")
+	v.check(c.DBTimeout > 0, "DB_TIMEOUT", "must be positive")
+	v.check(c.SchemaCheck == SchemaCheckFail || c.SchemaCheck == SchemaCheckReadiness || c.SchemaCheck == SchemaCheckOff,
+		"SCHEMA_CHECK", "must be %s, %s or %s, got %q", SchemaCheckFail, SchemaCheckReadiness, SchemaCheckOff, c.SchemaCheck)
+
+	c.validateKeyLabels(v)
+
+	v.check(c.Log.SamplingInitial >= 0, "LOG_SAMPLING_INITIAL", "must not be negative")
+	v.check(c.Log.SamplingThereafter >= 0, "LOG_SAMPLING_THEREAFTER", "must not be negative")
+
+	c.validateStorage(v)
+
+	v.check(c.Batches.MaxRelayBatchSize > 0, "MAX_RELAY_BATCH_SIZE", "must be positive")
+	v.check(c.Batches.MaxRelayBatchDuration > 0, "MAX_RELAY_BATCH_DURATION", "must be positive")
+	v.check(c.Batches.MaxServiceRecordBatchSize > 0, "MAX_SERVICE_RECORD_BATCH_SIZE", "must be positive")
+	v.check(c.Batches.MaxServiceRecordBatchDuration > 0, "MAX_SERVICE_RECORD_BATCH_DURATION", "must be positive")
+
+	v.check(c.RateLimits.DefaultRequestsPerSecond >= 0, "DEFAULT_REQUESTS_PER_SECOND", "must not be negative")
+	v.check(c.RateLimits.DefaultItemsPerSecond >= 0, "DEFAULT_ITEMS_PER_SECOND", "must not be negative")
+	v.check(c.RateLimits.DefaultRequestsBurst >= 0, "DEFAULT_REQUESTS_BURST", "must not be negative")
+	v.check(c.RateLimits.DefaultItemsBurst >= 0, "DEFAULT_ITEMS_BURST", "must not be negative")
+	for label, limit := range c.RateLimits.PerKeyLabel {
+		v.check(limit.RequestsPerSecond >= 0 && limit.ItemsPerSecond >= 0 && limit.RequestsBurst >= 0 && limit.ItemsBurst >= 0,
+			"API_KEY_RATE_LIMITS", "must not have negative limits, got some for %q", label)
+	}
+
+	v.check(c.Usage.FlushInterval > 0, "USAGE_FLUSH_INTERVAL", "must be positive")
+	v.check(c.Usage.RetentionDays > 0, "USAGE_RETENTION_DAYS", "must be positive")
+
+	v.check(c.Readiness.DBTimeout > 0, "READINESS_DB_TIMEOUT", "must be positive")
+	v.check(c.Readiness.MaxBacklogRatio > 0 && c.Readiness.MaxBacklogRatio <= 1, "READINESS_MAX_BACKLOG_RATIO",
+		"must be greater than 0 and at most 1, got %v", c.Readiness.MaxBacklogRatio)
+	v.check(c.Readiness.MaxSaveAge >= 0, "READINESS_MAX_SAVE_AGE", "must not be negative")
+	v.check(c.Readiness.DrainDelay >= 0, "READINESS_DRAIN_DELAY", "must not be negative")
+
+	v.check(c.Server.ReadHeaderTimeout >= 0, "READ_HEADER_TIMEOUT", "must not be negative")
+	v.check(c.Server.ReadTimeout >= 0, "READ_TIMEOUT", "must not be negative")
+	v.check(c.Server.WriteTimeout >= 0, "WRITE_TIMEOUT", "must not be negative")
+	v.check(c.Server.IdleTimeout >= 0, "IDLE_TIMEOUT", "must not be negative")
+	v.check(c.Server.MaxHeaderBytes >= 0, "MAX_HEADER_BYTES", "must not be negative")
+	v.check(c.Server.MaxBodyBytes >= 0, "MAX_BODY_BYTES", "must not be negative")
+	v.check(c.Server.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT", "must be positive")
+
+	v.check(c.Sinks.PrimaryMaxRetries >= 0, "PRIMARY_SINK_MAX_RETRIES", "must not be negative")
+	v.check(c.Sinks.Timeout >= 0, "SINK_TIMEOUT", "must not be negative")
+	v.check(c.Sinks.MaxRetries >= 0, "SINK_MAX_RETRIES", "must not be negative")
+	v.check(c.Sinks.RetryBackoff >= 0, "SINK_RETRY_BACKOFF", "must not be negative")
+	v.check(c.Sinks.QueueSize >= 0, "SINK_QUEUE_SIZE", "must not be negative")
+
+	if c.Archive.Dir != "" {
+		v.checkErr(c.ArchiveConfig().Validate(), "archive")
+	}
+
+	// The writers of both would share their manifests
+	v.check(c.Sinks.DeadLetterDir == "" || filepath.Clean(c.Sinks.DeadLetterDir) != filepath.Clean(c.Archive.Dir),
+		"DEAD_LETTER_DIR", "must not be the ARCHIVE_DIR")
+
+	if len(c.Kafka.Brokers) > 0 {
+		relays, serviceRecords := c.KafkaConfigs()
+		v.checkErr(relays.Validate(), "kafka relays")
+		v.checkErr(serviceRecords.Validate(), "kafka service records")
+	}
+
+	v.check(c.Cache.Size >= 0, "CACHE_SIZE", "must not be negative")
+	v.check(c.Cache.TTL >= 0, "CACHE_TTL", "must not be negative")
+
+	v.checkErr(c.TracingConfig("").Validate(), "tracing")
+
+	if c.Diagnostics.Addr != "" {
+		_, port, err := net.SplitHostPort(c.Diagnostics.Addr)
+		v.check(err == nil && validPort(port), "DIAGNOSTICS_ADDR", "must be host:port such as localhost:6060, got %q", c.Diagnostics.Addr)
+		v.check(port != c.Port, "DIAGNOSTICS_ADDR", "must not use the port of PORT")
+	}
+
+	return errors.Join(v.errors...)
+}
+
+// ValidateStorage reports every invalid setting of the storage backend, all the commands
+// that only read and write the storage, such as export, need
+func (c Config) ValidateStorage() error {
+	v := &validator{names: envNames()}
+
+	v.check(c.DBTimeout > 0, "DB_TIMEOUT", "must be positive")
+	c.validateStorage(v)
+
+	return errors.Join(v.errors...)
+}
+
+// ValidateReplay reports every invalid setting the replay command reads, which are those
+// of the storage backend and the batch sizes it writes the items in
+func (c Config) ValidateReplay() error {
+	v := &validator{names: envNames()}
+
+	v.check(c.DBTimeout > 0, "DB_TIMEOUT", "must be positive")
+	c.validateStorage(v)
+
+	v.check(c.Batches.MaxRelayBatchSize > 0, "MAX_RELAY_BATCH_SIZE", "must be positive")
+	v.check(c.Batches.MaxServiceRecordBatchSize > 0, "MAX_SERVICE_RECORD_BATCH_SIZE", "must be positive")
+
+	return errors.Join(v.errors...)
+}
+
+// validateKeyLabels checks each label names configured keys, and that no key could be
+// mistaken for the label:key format of API_KEY_LABELS
+func (c Config) validateKeyLabels(v *validator) {
+	keys := keySet(append(append([]string{}, c.APIKeys...), c.AdminAPIKeys...))
+	labeled := make(map[string]string)
+
+	for label, labelKeys := range c.APIKeyLabels {
+		v.check(label != "" && !strings.ContainsAny(label, ":,"), "API_KEY_LABELS",
+			"must have labels without colons or commas, got %q", label)
+
+		for _, key := range labelKeys {
+			v.check(keys[key], "API_KEY_LABELS", "must only label keys of API_KEYS or ADMIN_API_KEYS, got an unknown key for %q", label)
+
+			if other, ok := labeled[key]; ok && other != label {
+				v.check(false, "API_KEY_LABELS", "must give each key a single label, got %q and %q for the same key", other, label)
+			}
+
+			labeled[key] = label
+		}
+	}
+
+	// A key such as "gateway:secret" reads as the label:key format when gateway is a label
+	for key := range keys {
+		label, _, found := strings.Cut(key, ":")
+		if _, isLabel := c.APIKeyLabels[label]; found && isLabel {
+			v.errors = append(v.errors, fmt.Errorf("API key starting with %q is ambiguous with the label %q of API_KEY_LABELS (api_key_labels)",
+				label+":", label))
+		}
+	}
+}
+
+func (c Config) validateStorage(v *validator) {
+	backends := storage.Backends()
+
+	known := false
+	for _, backend := range backends {
+		known = known || backend == c.Storage.Backend
+	}
+
+	v.check(known, "STORAGE_BACKEND", "must be one of %s, got %q", strings.Join(backends, ", "), c.Storage.Backend)
+
+	switch c.Storage.Backend {
+	case BackendPostgres:
+		pg := c.Storage.Postgres
+
+		v.check(pg.User != "", "PG_USER", "must be set")
+		v.check(pg.Password != "", "PG_PASSWORD", "must be set")
+		v.check(pg.Database != "", "PG_DATABASE", "must be set")
+
+		if pg.InstanceConnectionName == "" {
+			v.check(pg.Host != "", "PG_HOST", "must be set when DB_INSTANCE_CONNECTION_NAME is not")
+			v.check(validPort(pg.Port), "PG_PORT", "must be a port number when DB_INSTANCE_CONNECTION_NAME is not set, got %q", pg.Port)
+		}
+
+		if pg.ReplicaInstanceConnectionName == "" && (pg.ReplicaHost != "" || pg.ReplicaPort != "") {
+			v.check(pg.ReplicaHost != "", "PG_REPLICA_HOST", "must be set with PG_REPLICA_PORT")
+			v.check(validPort(pg.ReplicaPort), "PG_REPLICA_PORT", "must be a port number with PG_REPLICA_HOST, got %q", pg.ReplicaPort)
+		}
+
+		v.check(pg.ReplicaMaxStaleness >= 0, "REPLICA_MAX_STALENESS", "must not be negative")
+		v.check(pg.MaxConns >= 0, "PG_MAX_CONNS", "must not be negative")
+		v.check(pg.MinConns >= 0, "PG_MIN_CONNS", "must not be negative")
+		v.check(pg.MaxConns == 0 || pg.MinConns <= pg.MaxConns, "PG_MIN_CONNS", "must not be greater than PG_MAX_CONNS")
+		v.check(pg.MaxConnLifetime >= 0, "PG_MAX_CONN_LIFETIME", "must not be negative")
+		v.check(pg.MaxConnIdleTime >= 0, "PG_MAX_CONN_IDLE_TIME", "must not be negative")
+		v.check(pg.HealthCheckPeriod >= 0, "PG_HEALTH_CHECK_PERIOD", "must not be negative")
+
+	case BackendSQLite:
+		v.check(c.Storage.SQLite.Path != "", "SQLITE_PATH", "must be set")
+	}
+}
+
+func validPort(rawPort string) bool {
+	port, err := strconv.Atoi(rawPort)
+	return err == nil && port > 0 && port <= 65535
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"testing"
+
+	// The backends registered by the binary, listed in the unknown backend message
+	_ "github.com/pokt-foundation/transaction-http-db/storage/postgres"
+	_ "github.com/pokt-foundation/transaction-http-db/storage/sqlite"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	c := require.New(t)
+
+	validConfig := Default()
+	validConfig.APIKeys = []string{"key"}
+	validConfig.Storage.Postgres = Postgres{
+		User:     "user",
+		Password: "password",
+		Database: "database",
+		Host:     "localhost",
+		Port:     "5432",
+	}
+
+	tests := []struct {
+		name             string
+		modify           func(config *Config)
+		expectedMessages []string
+	}{
+		{
+			name:   "Valid config",
+			modify: func(config *Config) {},
+		},
+		{
+			name: "CloudSQL config",
+			modify: func(config *Config) {
+				config.Storage.Postgres.Host = ""
+				config.Storage.Postgres.Port = ""
+				config.Storage.Postgres.InstanceConnectionName = "project:region:instance"
+			},
+		},
+		{
+			name: "Neither CloudSQL nor local DB",
+			modify: func(config *Config) {
+				config.Storage.Postgres.Host = ""
+				config.Storage.Postgres.Port = ""
+			},
+			expectedMessages: []string{
+				"PG_HOST (storage.postgres.host) must be set when DB_INSTANCE_CONNECTION_NAME is not",
+				`PG_PORT (storage.postgres.port) must be a port number when DB_INSTANCE_CONNECTION_NAME is not set, got ""`,
+			},
+		},
+		{
+			name: "Replica without port",
+			modify: func(config *Config) {
+				config.Storage.Postgres.ReplicaHost = "replica"
+			},
+			expectedMessages: []string{"PG_REPLICA_PORT (storage.postgres.replica_port) must be a port number"},
+		},
+		{
+			name: "SQLite without path",
+			modify: func(config *Config) {
+				config.Storage.Backend = BackendSQLite
+			},
+			expectedMessages: []string{"SQLITE_PATH (storage.sqlite.path) must be set"},
+		},
+		{
+			name: "Unknown backend",
+			modify: func(config *Config) {
+				config.Storage.Backend = "mongo"
+			},
+			expectedMessages: []string{`STORAGE_BACKEND (storage.backend) must be one of postgres, sqlite, got "mongo"`},
+		},
+		{
+			name: "Every problem at once",
+			modify: func(config *Config) {
+				config.APIKeys = nil
+				config.Port = "http"
+				config.Batches.MaxRelayBatchSize = 0
+				config.Readiness.MaxBacklogRatio = 2
+				config.SchemaCheck = "warn"
+				config.Storage.Postgres.MinConns = 10
+				config.Storage.Postgres.MaxConns = 5
+			},
+			expectedMessages: []string{
+				"API_KEYS (api_keys) must have at least one key",
+				`PORT (port) must be a port number, got "http"`,
+				"MAX_RELAY_BATCH_SIZE (batches.max_relay_batch_size) must be positive",
+				"READINESS_MAX_BACKLOG_RATIO (readiness.max_backlog_ratio) must be greater than 0 and at most 1, got 2",
+				`SCHEMA_CHECK (schema_check) must be fail, readiness or off, got "warn"`,
+				"PG_MIN_CONNS (storage.postgres.min_conns) must not be greater than PG_MAX_CONNS",
+			},
+		},
+		{
+			name: "Negative log sampling",
+			modify: func(config *Config) {
+				config.Log.SamplingThereafter = -1
+			},
+			expectedMessages: []string{"LOG_SAMPLING_THEREAFTER (log.sampling_thereafter) must not be negative"},
+		},
+		{
+			name: "Key with a colon",
+			modify: func(config *Config) {
+				config.APIKeys = []string{"key", "team:key"}
+				config.APIKeyLabels = KeyLabels{"gateway": {"team:key"}}
+			},
+		},
+		{
+			name: "Label of an unknown key",
+			modify: func(config *Config) {
+				config.APIKeyLabels = KeyLabels{"gateway": {"key"}, "ops": {"admin"}}
+			},
+			expectedMessages: []string{`API_KEY_LABELS (api_key_labels) must only label keys of API_KEYS or ADMIN_API_KEYS, got an unknown key for "ops"`},
+		},
+		{
+			name: "Key with two labels",
+			modify: func(config *Config) {
+				config.APIKeyLabels = KeyLabels{"gateway": {"key"}, "ops": {"key"}}
+			},
+			expectedMessages: []string{"API_KEY_LABELS (api_key_labels) must give each key a single label"},
+		},
+		{
+			name: "Label with a colon",
+			modify: func(config *Config) {
+				config.APIKeyLabels = KeyLabels{"gateway:main": {"key"}}
+			},
+			expectedMessages: []string{`API_KEY_LABELS (api_key_labels) must have labels without colons or commas, got "gateway:main"`},
+		},
+		{
+			name: "Key ambiguous with a label",
+			modify: func(config *Config) {
+				config.AdminAPIKeys = []string{"ops:admin"}
+				config.APIKeyLabels = KeyLabels{"ops": {"ops:admin"}}
+			},
+			expectedMessages: []string{`API key starting with "ops:" is ambiguous with the label "ops" of API_KEY_LABELS (api_key_labels)`},
+		},
+		{
+			name: "Negative burst",
+			modify: func(config *Config) {
+				config.RateLimits.PerKeyLabel = KeyRateLimits{"gateway": {ItemsPerSecond: 10, ItemsBurst: -1}}
+			},
+			expectedMessages: []string{`API_KEY_RATE_LIMITS (rate_limits.per_key_label) must not have negative limits, got some for "gateway"`},
+		},
+		{
+			name: "Invalid archive",
+			modify: func(config *Config) {
+				config.Archive.Dir = "/tmp/archive"
+				config.Archive.Format = "csv"
+			},
+			expectedMessages: []string{"archive: "},
+		},
+		{
+			name: "Dead letter spool in the archive",
+			modify: func(config *Config) {
+				config.Archive.Dir = "/tmp/archive"
+				config.Sinks.DeadLetterDir = "/tmp/archive/"
+			},
+			expectedMessages: []string{"DEAD_LETTER_DIR (sinks.dead_letter_dir) must not be the ARCHIVE_DIR"},
+		},
+		{
+			name: "Invalid tracing",
+			modify: func(config *Config) {
+				config.Tracing.Endpoint = "localhost:4318"
+				config.Tracing.SampleRatio = 2
+			},
+			expectedMessages: []string{"tracing: ", "endpoint", "sample ratio"},
+		},
+		{
+			name: "Invalid diagnostics address",
+			modify: func(config *Config) {
+				config.Diagnostics.Enabled = true
+				config.Diagnostics.Addr = "localhost"
+			},
+			expectedMessages: []string{`DIAGNOSTICS_ADDR (diagnostics.addr) must be host:port such as localhost:6060, got "localhost"`},
+		},
+		{
+			name: "Invalid kafka",
+			modify: func(config *Config) {
+				config.Kafka.Brokers = []string{"localhost:9092"}
+				config.Kafka.ServiceRecordTopic = ""
+			},
+			expectedMessages: []string{"kafka service records: "},
+		},
+	}
+
+	for _, tt := range tests {
+		config := validConfig
+		tt.modify(&config)
+
+		err := config.Validate()
+		if len(tt.expectedMessages) == 0 {
+			c.NoError(err, tt.name)
+			continue
+		}
+
+		c.Error(err, tt.name)
+		for _, message := range tt.expectedMessages {
+			c.Contains(err.Error(), message, tt.name)
+		}
+	}
+}
+
+func TestConfig_ValidateCommands(t *testing.T) {
+	c := require.New(t)
+
+	// The commands don't need the settings of the server, such as the API keys
+	cfg := Default()
+	cfg.Storage.Backend = BackendSQLite
+	cfg.Storage.SQLite.Path = "transactions.db"
+
+	c.ErrorContains(cfg.Validate(), "API_KEYS")
+	c.NoError(cfg.ValidateStorage())
+	c.NoError(cfg.ValidateReplay())
+
+	// Replay writes the items in batches of the max sizes
+	cfg.Batches.MaxRelayBatchSize = 0
+	c.NoError(cfg.ValidateStorage())
+	c.ErrorContains(cfg.ValidateReplay(), "MAX_RELAY_BATCH_SIZE (batches.max_relay_batch_size) must be positive")
+
+	// Both need a valid storage backend
+	cfg.Storage.SQLite.Path = ""
+	c.ErrorContains(cfg.ValidateStorage(), "SQLITE_PATH (storage.sqlite.path) must be set")
+	c.ErrorContains(cfg.ValidateReplay(), "SQLITE_PATH (storage.sqlite.path) must be set")
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/pokt-foundation/transaction-http-db/config"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+)
+
+// stdout is the -out value writing the export to the standard output
+const stdout = "-"
+
+// exportFile is where the relays are written, gzip compressed when its name ends in .gz
+type exportFile struct {
+	buffered   *bufio.Writer
+	gzipWriter *gzip.Writer
+	file       *os.File
+}
+
+// createExportFile creates the file at path or returns the standard output, which
+// is not compressed
+func createExportFile(path string) (*exportFile, error) {
+	if path == stdout {
+		return &exportFile{buffered: bufio.NewWriter(os.Stdout)}, nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &exportFile{file: file}
+
+	var w io.Writer = file
+	if strings.HasSuffix(path, ".gz") {
+		export.gzipWriter = gzip.NewWriter(file)
+		w = export.gzipWriter
+	}
+
+	export.buffered = bufio.NewWriter(w)
+
+	return export, nil
+}
+
+func (f *exportFile) Write(p []byte) (int, error) {
+	return f.buffered.Write(p)
+}
+
+func (f *exportFile) Close() error {
+	err := f.buffered.Flush()
+
+	if f.gzipWriter != nil {
+		err = errors.Join(err, f.gzipWriter.Close())
+	}
+
+	if f.file != nil {
+		err = errors.Join(err, f.file.Close())
+	}
+
+	return err
+}
+
+func runExport(args []string) error {
+	flags, configPath := newFlagSet("export", "Write the relays started in a time range to an NDJSON file, one relay per line.\n"+
+		"The storage backend must support listing relays, which the postgres and sqlite ones do.")
+	rawFrom := flags.String("from", "", "first start time of the relays, a date or RFC3339 (required)")
+	rawTo := flags.String("to", "", "start time the relays are before, a date or RFC3339 (required)")
+	out := flags.String("out", stdout, "file the relays are written to, gzip compressed if it ends in .gz, - being the standard output")
+	_ = flags.Parse(args)
+
+	cfg := loadConfig(*configPath, config.Config.ValidateStorage)
+
+	if *rawFrom == "" || *rawTo == "" {
+		return errors.New("both -from and -to must be set")
+	}
+
+	from, err := parseTime(*rawFrom)
+	if err != nil {
+		return err
+	}
+
+	to, err := parseTime(*rawTo)
+	if err != nil {
+		return err
+	}
+
+	if !from.Before(to) {
+		return errors.New("-from must be before -to")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	driver, cleanup, err := openStorage(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	file, err := createExportFile(*out)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(file)
+	exported := 0
+
+	err = storage.ExportRelays(ctx, driver, from, to, func(relay types.Relay) error {
+		if err := encoder.Encode(relay); err != nil {
+			return err
+		}
+
+		exported++
+
+		return nil
+	})
+
+	// The relays written so far are kept, so an interrupted export can still be looked at
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %d relays\n", exported)
+
+	return err
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pokt-foundation/transaction-http-db/loadgen"
+)
+
+// parseRange parses a range such as "100-5000"
+func parseRange(raw string) (int, int, error) {
+	rawMin, rawMax, found := strings.Cut(raw, "-")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid range %q, must be such as 100-5000", raw)
+	}
+
+	min, err := strconv.Atoi(strings.TrimSpace(rawMin))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid range %q, must be such as 100-5000", raw)
+	}
+
+	max, err := strconv.Atoi(strings.TrimSpace(rawMax))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid range %q, must be such as 100-5000", raw)
+	}
+
+	return min, max, nil
+}
+
+func runLoadgen(args []string) error {
+	profile := loadgen.DefaultProfile()
+
+	flags := commandFlagSet("loadgen", "Send synthetic sessions, relays and service records to a running instance, then report\n"+
+		"the throughput, latency percentiles and rejections by kind of request.\n"+
+		"Without -rate, each of the -concurrency workers sends its next request once the last one is answered.")
+	target := flags.String("target", "http://localhost:8080", "base URL of the instance")
+	apiKey := flags.String("api-key", "", "API key sent in the Authorization header")
+	rate := flags.Float64("rate", 0, "requests sent per second, the requests due while -concurrency are in flight being skipped")
+	concurrency := flags.Int("concurrency", 10, "requests in flight at most")
+	duration := flags.Duration("duration", 30*time.Second, "how long requests are sent for, no limit if zero")
+	requests := flags.Int("requests", 0, "requests sent at most, no limit if zero")
+	batchSize := flags.Int("batch-size", 50, "items of each relays and service-records request")
+	timeout := flags.Duration("timeout", 10*time.Second, "timeout of each request")
+	rawMix := flags.String("mix", loadgen.DefaultMix().String(), "kinds of requests by weight, among session, relay, relays, service-record and service-records")
+	rawChains := flags.String("chains", profile.Chains.String(), "chain IDs of the items by weight")
+	regions := flags.String("regions", strings.Join(profile.Regions, ","), "comma separated portal regions of the items, created before the load")
+	flags.Float64Var(&profile.ErrorRatio, "error-ratio", profile.ErrorRatio, "fraction of relays that failed")
+	flags.Float64Var(&profile.InvalidRatio, "invalid-ratio", profile.InvalidRatio, "fraction of items missing a required field, which are rejected")
+	dataSize := flags.String("data-size", fmt.Sprintf("%d-%d", profile.MinDataSize, profile.MaxDataSize), "range of the relay data sizes")
+	flags.IntVar(&profile.Sessions, "sessions", profile.Sessions, "sessions the relays and service records are spread over")
+	seed := flags.Int64("seed", time.Now().UnixNano(), "seed of the generated items, the same seed giving the same items but for the session keys and times")
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	_ = flags.Parse(args)
+
+	mix, err := loadgen.ParseWeights(*rawMix)
+	if err != nil {
+		return fmt.Errorf("invalid -mix: %w", err)
+	}
+
+	if profile.Chains, err = loadgen.ParseWeights(*rawChains); err != nil {
+		return fmt.Errorf("invalid -chains: %w", err)
+	}
+
+	if profile.MinDataSize, profile.MaxDataSize, err = parseRange(*dataSize); err != nil {
+		return err
+	}
+
+	profile.Regions = nil
+	for _, region := range strings.Split(*regions, ",") {
+		if region = strings.TrimSpace(region); region != "" {
+			profile.Regions = append(profile.Regions, region)
+		}
+	}
+
+	if err := profile.Validate(); err != nil {
+		return err
+	}
+
+	runner, err := loadgen.NewRunner(loadgen.Config{
+		Target:      *target,
+		APIKey:      *apiKey,
+		Mix:         mix,
+		Rate:        *rate,
+		Concurrency: *concurrency,
+		Duration:    *duration,
+		MaxRequests: *requests,
+		BatchSize:   *batchSize,
+		Timeout:     *timeout,
+	}, loadgen.NewGenerator(profile, *seed), nil)
+	if err != nil {
+		return err
+	}
+
+	// An interrupt ends the run early, still reporting the requests sent so far
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "Sending load to %s\n", *target)
+
+	report, err := runner.Run(ctx)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(report)
+	}
+
+	return report.Write(os.Stdout)
+}
@@ -0,0 +1,330 @@
+package loadgen
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+)
+
+var errInvalidWeights = errors.New("weights must be a comma separated list of value=weight with positive weights")
+
+// Choice is a value picked with a probability proportional to its weight
+type Choice struct {
+	Value  string
+	Weight float64
+}
+
+// Weights is a distribution of values
+type Weights []Choice
+
+// ParseWeights parses a list such as "0021=5,0040=3,0001", a value without a weight having a weight of 1
+func ParseWeights(raw string) (Weights, error) {
+	var weights Weights
+
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		value, rawWeight, found := strings.Cut(part, "=")
+		weight := 1.0
+
+		if found {
+			var err error
+			if weight, err = strconv.ParseFloat(rawWeight, 64); err != nil {
+				return nil, fmt.Errorf("%w, got %q", errInvalidWeights, part)
+			}
+		}
+
+		if value == "" || weight <= 0 {
+			return nil, fmt.Errorf("%w, got %q", errInvalidWeights, part)
+		}
+
+		weights = append(weights, Choice{Value: value, Weight: weight})
+	}
+
+	if len(weights) == 0 {
+		return nil, errInvalidWeights
+	}
+
+	return weights, nil
+}
+
+func (w Weights) String() string {
+	parts := make([]string, 0, len(w))
+	for _, choice := range w {
+		parts = append(parts, fmt.Sprintf("%s=%v", choice.Value, choice.Weight))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func (w Weights) pick(r *rand.Rand) string {
+	total := 0.0
+	for _, choice := range w {
+		total += choice.Weight
+	}
+
+	n := r.Float64() * total
+	for _, choice := range w {
+		if n < choice.Weight {
+			return choice.Value
+		}
+
+		n -= choice.Weight
+	}
+
+	return w[len(w)-1].Value
+}
+
+// Profile describes the items to generate
+type Profile struct {
+	// Chains are the chain IDs of the items, by how often they show up
+	Chains Weights
+	// Regions are the portal regions the items come from, picked evenly
+	Regions []string
+	// ErrorRatio is the fraction of relays that failed
+	ErrorRatio float64
+	// InvalidRatio is the fraction of items missing required fields, which the service rejects
+	InvalidRatio float64
+	// MinDataSize and MaxDataSize bound the relay data sizes, picked evenly between them
+	MinDataSize, MaxDataSize int
+	// MaxMethods is the max number of chain methods of a relay, at least one being set
+	MaxMethods int
+	// Sessions is how many sessions the relays and service records are spread over
+	// before new sessions are needed
+	Sessions int
+}
+
+// DefaultProfile returns a profile with a few chains and regions, a 5% error ratio and no invalid items
+func DefaultProfile() Profile {
+	return Profile{
+		Chains:      Weights{{Value: "0021", Weight: 6}, {Value: "0040", Weight: 2}, {Value: "0001", Weight: 1}, {Value: "0009", Weight: 1}},
+		Regions:     []string{"us-east4", "europe-west3", "asia-southeast1"},
+		ErrorRatio:  0.05,
+		MinDataSize: 100,
+		MaxDataSize: 5000,
+		MaxMethods:  3,
+		Sessions:    100,
+	}
+}
+
+// Validate checks the profile can be used to generate items
+func (p Profile) Validate() error {
+	var errs []error
+
+	if len(p.Chains) == 0 {
+		errs = append(errs, errors.New("at least one chain must be set"))
+	}
+	if len(p.Regions) == 0 {
+		errs = append(errs, errors.New("at least one region must be set"))
+	}
+	if p.ErrorRatio < 0 || p.ErrorRatio > 1 {
+		errs = append(errs, fmt.Errorf("the error ratio must be between 0 and 1, got %v", p.ErrorRatio))
+	}
+	if p.InvalidRatio < 0 || p.InvalidRatio > 1 {
+		errs = append(errs, fmt.Errorf("the invalid ratio must be between 0 and 1, got %v", p.InvalidRatio))
+	}
+	if p.MinDataSize < 0 || p.MaxDataSize < p.MinDataSize {
+		errs = append(errs, fmt.Errorf("the data sizes must be positive and the min one not greater than the max one, got %d-%d", p.MinDataSize, p.MaxDataSize))
+	}
+	if p.MaxMethods < 1 {
+		errs = append(errs, errors.New("the max methods must be at least 1"))
+	}
+	if p.Sessions < 1 {
+		errs = append(errs, errors.New("the sessions must be at least 1"))
+	}
+
+	return errors.Join(errs...)
+}
+
+var methods = []string{"eth_blockNumber", "eth_chainId", "eth_call", "eth_getBalance", "eth_getLogs", "eth_getTransactionReceipt"}
+
+var relayErrors = []struct {
+	code int
+	name string
+}{
+	{code: 502, name: "bad gateway"},
+	{code: 504, name: "timeout"},
+	{code: 429, name: "rate limited"},
+}
+
+// Generator generates random valid items following a profile. Session keys are prefixed with
+// the creation time of the generator, so runs don't collide on them.
+type Generator struct {
+	profile  Profile
+	mu       sync.Mutex
+	rand     *rand.Rand
+	prefix   string
+	sessions []types.PocketSession
+	next     int
+	height   int
+	now      func() time.Time
+}
+
+// NewGenerator returns a Generator of the profile, the same seed giving the same items but for
+// the session keys and times
+func NewGenerator(profile Profile, seed int64) *Generator {
+	return &Generator{
+		profile: profile,
+		rand:    rand.New(rand.NewSource(seed)),
+		prefix:  "loadgen-" + strconv.FormatInt(time.Now().UnixNano(), 36),
+		height:  1,
+		now:     time.Now,
+	}
+}
+
+// Session returns a new session, which the relays and service records generated after it can use
+func (g *Generator) Session() types.PocketSession {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.newSession()
+}
+
+func (g *Generator) newSession() types.PocketSession {
+	g.next++
+
+	// Sessions last a few blocks, like on chain
+	if g.next%g.profile.Sessions == 0 {
+		g.height++
+	}
+
+	session := types.PocketSession{
+		SessionKey:       fmt.Sprintf("%s-%d", g.prefix, g.next),
+		SessionHeight:    g.height,
+		PortalRegionName: g.region(),
+	}
+
+	g.sessions = append(g.sessions, session)
+	if len(g.sessions) > g.profile.Sessions {
+		g.sessions = g.sessions[1:]
+	}
+
+	return session
+}
+
+// session returns one of the last sessions, creating one when there is none
+func (g *Generator) session() types.PocketSession {
+	if len(g.sessions) == 0 {
+		return g.newSession()
+	}
+
+	return g.sessions[g.rand.Intn(len(g.sessions))]
+}
+
+func (g *Generator) region() string {
+	return g.profile.Regions[g.rand.Intn(len(g.profile.Regions))]
+}
+
+func (g *Generator) hex(n int) string {
+	const digits = "0123456789abcdef"
+
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = digits[g.rand.Intn(len(digits))]
+	}
+
+	return string(b)
+}
+
+func (g *Generator) invalid() bool {
+	return g.profile.InvalidRatio > 0 && g.rand.Float64() < g.profile.InvalidRatio
+}
+
+// Relay returns a new relay of one of the last sessions
+func (g *Generator) Relay() types.Relay {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	session := g.session()
+	nodeTripTime := 20 + g.rand.Float64()*400
+	portalTripTime := nodeTripTime + g.rand.Float64()*30
+	start := g.now().UTC()
+
+	methodIDs := make([]string, 1+g.rand.Intn(g.profile.MaxMethods))
+	for i := range methodIDs {
+		methodIDs[i] = methods[g.rand.Intn(len(methods))]
+	}
+
+	relay := types.Relay{
+		PoktChainID:          g.profile.Chains.pick(g.rand),
+		EndpointID:           g.hex(24),
+		SessionKey:           session.SessionKey,
+		ProtocolAppPublicKey: g.hex(64),
+		RelaySourceURL:       "https://" + g.hex(8) + ".example.com",
+		PoktNodeAddress:      g.hex(40),
+		PoktNodeDomain:       g.hex(8) + ".example.com",
+		PoktNodePublicKey:    g.hex(64),
+		RelayStartDatetime:   start,
+		RelayReturnDatetime:  start.Add(time.Duration(portalTripTime * float64(time.Millisecond))),
+		RelayRoundtripTime:   portalTripTime,
+		RelayChainMethodIDs:  methodIDs,
+		RelayDataSize:        g.profile.MinDataSize + g.rand.Intn(g.profile.MaxDataSize-g.profile.MinDataSize+1),
+		RelayPortalTripTime:  portalTripTime,
+		RelayNodeTripTime:    nodeTripTime,
+		PortalRegionName:     session.PortalRegionName,
+		IsUserRelay:          true,
+		RequestID:            g.hex(32),
+		PoktTxID:             g.hex(64),
+	}
+
+	if g.profile.ErrorRatio > 0 && g.rand.Float64() < g.profile.ErrorRatio {
+		relayErr := relayErrors[g.rand.Intn(len(relayErrors))]
+
+		relay.IsError = true
+		relay.ErrorCode = relayErr.code
+		relay.ErrorName = relayErr.name
+		relay.ErrorMessage = "node responded with " + relayErr.name
+		relay.ErrorSource = types.ErrorSourceExternal
+		relay.ErrorType = "node"
+	}
+
+	if g.invalid() {
+		relay.PoktNodeAddress = ""
+	}
+
+	return relay
+}
+
+// ServiceRecord returns a new service record of one of the last sessions
+func (g *Generator) ServiceRecord() types.ServiceRecord {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	session := g.session()
+	successes := g.rand.Intn(100)
+	failures := g.rand.Intn(10)
+	latency := 20 + g.rand.Float64()*400
+
+	serviceRecord := types.ServiceRecord{
+		NodePublicKey:          g.hex(64),
+		PoktChainID:            g.profile.Chains.pick(g.rand),
+		SessionKey:             session.SessionKey,
+		RequestID:              g.hex(32),
+		PortalRegionName:       session.PortalRegionName,
+		Latency:                latency,
+		Tickets:                g.rand.Intn(5),
+		Result:                 "success",
+		Available:              true,
+		Successes:              successes,
+		Failures:               failures,
+		P90SuccessLatency:      latency * 1.5,
+		MedianSuccessLatency:   latency,
+		WeightedSuccessLatency: latency * 1.1,
+		SuccessRate:            float64(successes) / float64(successes+failures+1),
+	}
+
+	if g.invalid() {
+		serviceRecord.NodePublicKey = ""
+	}
+
+	return serviceRecord
+}
@@ -0,0 +1,152 @@
+package loadgen
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWeights(t *testing.T) {
+	c := require.New(t)
+
+	tests := []struct {
+		name            string
+		raw             string
+		expectedWeights Weights
+		expectErr       bool
+	}{
+		{
+			name:            "Weighted values",
+			raw:             "0021=5, 0040=2.5",
+			expectedWeights: Weights{{Value: "0021", Weight: 5}, {Value: "0040", Weight: 2.5}},
+		},
+		{
+			name:            "Values without weight",
+			raw:             "0021,0040=2",
+			expectedWeights: Weights{{Value: "0021", Weight: 1}, {Value: "0040", Weight: 2}},
+		},
+		{
+			name:      "Empty",
+			raw:       " , ",
+			expectErr: true,
+		},
+		{
+			name:      "Invalid weight",
+			raw:       "0021=five",
+			expectErr: true,
+		},
+		{
+			name:      "Zero weight",
+			raw:       "0021=0",
+			expectErr: true,
+		},
+		{
+			name:      "Missing value",
+			raw:       "=1",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		weights, err := ParseWeights(tt.raw)
+		if tt.expectErr {
+			c.ErrorIs(err, errInvalidWeights, tt.name)
+			continue
+		}
+
+		c.NoError(err, tt.name)
+		c.Equal(tt.expectedWeights, weights, tt.name)
+	}
+}
+
+func TestWeights_pick(t *testing.T) {
+	c := require.New(t)
+
+	weights := Weights{{Value: "a", Weight: 3}, {Value: "b", Weight: 1}}
+	r := rand.New(rand.NewSource(21))
+
+	picks := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		picks[weights.pick(r)]++
+	}
+
+	c.InDelta(7500, picks["a"], 300)
+	c.InDelta(2500, picks["b"], 300)
+}
+
+func TestProfile_Validate(t *testing.T) {
+	c := require.New(t)
+
+	c.NoError(DefaultProfile().Validate())
+
+	profile := DefaultProfile()
+	profile.Chains = nil
+	profile.ErrorRatio = 2
+	profile.MinDataSize = 10
+	profile.MaxDataSize = 5
+
+	err := profile.Validate()
+	c.ErrorContains(err, "at least one chain")
+	c.ErrorContains(err, "error ratio")
+	c.ErrorContains(err, "data sizes")
+}
+
+func TestGenerator(t *testing.T) {
+	c := require.New(t)
+
+	profile := DefaultProfile()
+	profile.Chains = Weights{{Value: "0021", Weight: 1}}
+	profile.ErrorRatio = 0.5
+	profile.MinDataSize = 10
+	profile.MaxDataSize = 20
+	profile.Sessions = 2
+
+	generator := NewGenerator(profile, 21)
+
+	first, second := generator.Session(), generator.Session()
+	c.NoError(first.Validate())
+	c.NotEqual(first.SessionKey, second.SessionKey)
+
+	// The oldest session is dropped once there are more than the profile sessions
+	third := generator.Session()
+
+	errors := 0
+	for i := 0; i < 1000; i++ {
+		relay := generator.Relay()
+		c.NoError(relay.Validate())
+		c.Equal("0021", relay.PoktChainID)
+		c.Contains([]string{second.SessionKey, third.SessionKey}, relay.SessionKey)
+		c.GreaterOrEqual(relay.RelayDataSize, 10)
+		c.LessOrEqual(relay.RelayDataSize, 20)
+		c.NotEmpty(relay.RelayChainMethodIDs)
+
+		if relay.IsError {
+			errors++
+			c.NotZero(relay.ErrorCode)
+		}
+
+		serviceRecord := generator.ServiceRecord()
+		c.NoError(serviceRecord.Validate())
+	}
+
+	c.InDelta(500, errors, 60)
+
+	// The same seed gives the same items
+	c.Equal(NewGenerator(profile, 21).Relay().RequestID, NewGenerator(profile, 21).Relay().RequestID)
+}
+
+func TestGenerator_InvalidRatio(t *testing.T) {
+	c := require.New(t)
+
+	profile := DefaultProfile()
+	profile.InvalidRatio = 1
+
+	generator := NewGenerator(profile, 21)
+
+	relay := generator.Relay()
+	c.Error(relay.Validate())
+
+	serviceRecord := generator.ServiceRecord()
+	c.Error(serviceRecord.Validate())
+}
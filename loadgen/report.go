@@ -0,0 +1,229 @@
+package loadgen
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// errorStatus is the status the requests that got no response are counted under
+const errorStatus = "error"
+
+// Latency summarizes the latencies of a set of requests
+type Latency struct {
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P99  time.Duration `json:"p99"`
+	Max  time.Duration `json:"max"`
+}
+
+// Stats are the outcome of a set of requests
+type Stats struct {
+	Requests int `json:"requests"`
+	// Succeeded are the requests answered with a 2xx status
+	Succeeded int `json:"succeeded"`
+	// Rejected counts the other requests by status, the ones that got no response being
+	// counted as "error"
+	Rejected map[string]int `json:"rejected,omitempty"`
+	// ItemsSent counts the items of all the requests, and ItemsAccepted the ones of the
+	// requests that succeeded
+	ItemsSent     int     `json:"itemsSent"`
+	ItemsAccepted int     `json:"itemsAccepted"`
+	Latency       Latency `json:"latency"`
+}
+
+// Report is the outcome of a run
+type Report struct {
+	Duration time.Duration `json:"duration"`
+	// Skipped are the requests that were due at the rate while the concurrency was reached
+	Skipped int `json:"skipped"`
+	// RequestsPerSecond and ItemsPerSecond are the throughput of the succeeded requests
+	RequestsPerSecond float64          `json:"requestsPerSecond"`
+	ItemsPerSecond    float64          `json:"itemsPerSecond"`
+	Total             Stats            `json:"total"`
+	Kinds             map[string]Stats `json:"kinds"`
+}
+
+// collector accumulates the results of a run, from a single goroutine
+type collector struct {
+	total *stats
+	kinds map[string]*stats
+}
+
+type stats struct {
+	Stats
+	latencies []time.Duration
+}
+
+func newCollector() *collector {
+	return &collector{
+		total: &stats{},
+		kinds: make(map[string]*stats),
+	}
+}
+
+func (c *collector) add(res result) {
+	kind, ok := c.kinds[res.kind]
+	if !ok {
+		kind = &stats{}
+		c.kinds[res.kind] = kind
+	}
+
+	for _, s := range []*stats{c.total, kind} {
+		s.add(res)
+	}
+}
+
+func (s *stats) add(res result) {
+	s.Requests++
+	s.ItemsSent += res.items
+
+	switch {
+	case res.err != nil:
+		s.reject(errorStatus)
+	case res.status >= http.StatusOK && res.status < http.StatusMultipleChoices:
+		s.Succeeded++
+		s.ItemsAccepted += res.items
+	default:
+		s.reject(strconv.Itoa(res.status))
+	}
+
+	// Requests that could not be built never reached the service, so they have no latency
+	if res.latency > 0 {
+		s.latencies = append(s.latencies, res.latency)
+	}
+}
+
+func (s *stats) reject(status string) {
+	if s.Rejected == nil {
+		s.Rejected = make(map[string]int)
+	}
+
+	s.Rejected[status]++
+}
+
+func (s *stats) summary() Stats {
+	summary := s.Stats
+	summary.Latency = summarize(s.latencies)
+
+	return summary
+}
+
+func (c *collector) report(duration time.Duration, skipped int) Report {
+	report := Report{
+		Duration: duration,
+		Skipped:  skipped,
+		Total:    c.total.summary(),
+		Kinds:    make(map[string]Stats, len(c.kinds)),
+	}
+
+	for name, kind := range c.kinds {
+		report.Kinds[name] = kind.summary()
+	}
+
+	if seconds := duration.Seconds(); seconds > 0 {
+		report.RequestsPerSecond = float64(report.Total.Succeeded) / seconds
+		report.ItemsPerSecond = float64(report.Total.ItemsAccepted) / seconds
+	}
+
+	return report
+}
+
+// summarize returns the mean, max and nearest rank percentiles of the latencies
+func summarize(latencies []time.Duration) Latency {
+	if len(latencies) == 0 {
+		return Latency{}
+	}
+
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum time.Duration
+	for _, latency := range sorted {
+		sum += latency
+	}
+
+	percentile := func(p int) time.Duration {
+		rank := (p*len(sorted) + 99) / 100
+		if rank < 1 {
+			rank = 1
+		}
+
+		return sorted[rank-1]
+	}
+
+	return Latency{
+		Mean: sum / time.Duration(len(sorted)),
+		P50:  percentile(50),
+		P90:  percentile(90),
+		P99:  percentile(99),
+		Max:  sorted[len(sorted)-1],
+	}
+}
+
+// Write writes the throughput of the report followed by a table with a row per kind of request and a total row
+func (r Report) Write(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "Duration: %s, throughput: %.1f requests/s and %.1f items/s, skipped: %d\n\n",
+		r.Duration.Round(time.Millisecond), r.RequestsPerSecond, r.ItemsPerSecond, r.Skipped)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "kind\trequests\tsucceeded\titems accepted\tmean\tp50\tp90\tp99\tmax\trejected\t")
+
+	names := make([]string, 0, len(r.Kinds))
+	for name := range r.Kinds {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	row := func(name string, s Stats) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d/%d\t%s\t%s\t%s\t%s\t%s\t%s\t\n", name, s.Requests, s.Succeeded,
+			s.ItemsAccepted, s.ItemsSent, round(s.Latency.Mean), round(s.Latency.P50), round(s.Latency.P90),
+			round(s.Latency.P99), round(s.Latency.Max), formatRejected(s.Rejected))
+	}
+
+	for _, name := range names {
+		row(name, r.Kinds[name])
+	}
+
+	row("total", r.Total)
+
+	return tw.Flush()
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(100 * time.Microsecond)
+}
+
+// formatRejected lists the rejected requests by status, such as "429: 10, 500: 2"
+func formatRejected(rejected map[string]int) string {
+	if len(rejected) == 0 {
+		return "-"
+	}
+
+	statuses := make([]string, 0, len(rejected))
+	for status := range rejected {
+		statuses = append(statuses, status)
+	}
+
+	sort.Strings(statuses)
+
+	formatted := ""
+	for i, status := range statuses {
+		if i > 0 {
+			formatted += ", "
+		}
+
+		formatted += fmt.Sprintf("%s: %d", status, rejected[status])
+	}
+
+	return formatted
+}
@@ -0,0 +1,66 @@
+package loadgen
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize(t *testing.T) {
+	c := require.New(t)
+
+	c.Equal(Latency{}, summarize(nil))
+
+	var latencies []time.Duration
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	c.Equal(Latency{
+		Mean: 50500 * time.Microsecond,
+		P50:  50 * time.Millisecond,
+		P90:  90 * time.Millisecond,
+		P99:  99 * time.Millisecond,
+		Max:  100 * time.Millisecond,
+	}, summarize(latencies))
+
+	// The input order is kept
+	c.Equal(100*time.Millisecond, latencies[0])
+
+	c.Equal(Latency{Mean: time.Second, P50: time.Second, P90: time.Second, P99: time.Second, Max: time.Second},
+		summarize([]time.Duration{time.Second}))
+}
+
+func TestCollector(t *testing.T) {
+	c := require.New(t)
+
+	collector := newCollector()
+	collector.add(result{kind: KindRelays, items: 10, status: 200, latency: time.Millisecond})
+	collector.add(result{kind: KindRelays, items: 10, status: 429, latency: time.Millisecond})
+	collector.add(result{kind: KindSession, items: 1, err: errors.New("connection refused"), latency: time.Millisecond})
+	collector.add(result{kind: KindSession, items: 1, status: 201, latency: 3 * time.Millisecond})
+
+	report := collector.report(2*time.Second, 3)
+
+	c.Equal(4, report.Total.Requests)
+	c.Equal(2, report.Total.Succeeded)
+	c.Equal(22, report.Total.ItemsSent)
+	c.Equal(11, report.Total.ItemsAccepted)
+	c.Equal(map[string]int{"429": 1, "error": 1}, report.Total.Rejected)
+	c.Equal(1.0, report.RequestsPerSecond)
+	c.Equal(5.5, report.ItemsPerSecond)
+	c.Equal(3, report.Skipped)
+
+	c.Equal(2, report.Kinds[KindRelays].Requests)
+	c.Equal(map[string]int{"429": 1}, report.Kinds[KindRelays].Rejected)
+	c.Equal(3*time.Millisecond, report.Kinds[KindSession].Latency.Max)
+
+	var out strings.Builder
+	c.NoError(report.Write(&out))
+	c.Contains(out.String(), "1.0 requests/s and 5.5 items/s, skipped: 3")
+	c.Contains(out.String(), "429: 1, error: 1")
+	c.Contains(out.String(), "total")
+}
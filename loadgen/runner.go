@@ -0,0 +1,366 @@
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+)
+
+// Kinds of requests sent to the service, named after their path
+const (
+	KindSession        = "session"
+	KindRelay          = "relay"
+	KindRelays         = "relays"
+	KindServiceRecord  = "service-record"
+	KindServiceRecords = "service-records"
+)
+
+var kinds = map[string]bool{
+	KindSession:        true,
+	KindRelay:          true,
+	KindRelays:         true,
+	KindServiceRecord:  true,
+	KindServiceRecords: true,
+}
+
+const (
+	defaultConcurrency = 10
+	defaultBatchSize   = 50
+	defaultTimeout     = 10 * time.Second
+)
+
+// Config holds the settings of a run
+type Config struct {
+	// Target is the base URL of the service
+	Target string
+	APIKey string
+	// Mix is how often each kind of request is sent
+	Mix Weights
+	// Rate is the requests sent per second. When zero, each of the Concurrency workers sends
+	// its next request as soon as the last one is answered.
+	Rate float64
+	// Concurrency is the number of requests in flight at most, defaulting to 10. With a rate,
+	// the requests due while it is reached are skipped and reported.
+	Concurrency int
+	// Duration is how long requests are sent for
+	Duration time.Duration
+	// MaxRequests stops the run once sent, no limit if zero
+	MaxRequests int
+	// BatchSize is the number of items of the relays and service records requests, defaulting to 50
+	BatchSize int
+	// Timeout bounds each request, defaulting to 10 seconds
+	Timeout time.Duration
+}
+
+// DefaultMix sends mostly relays in bulk, then service records in bulk and a new session every now and then
+func DefaultMix() Weights {
+	return Weights{{Value: KindRelays, Weight: 6}, {Value: KindServiceRecords, Weight: 3}, {Value: KindSession, Weight: 1}}
+}
+
+// Validate checks the config can be used for a run
+func (c Config) Validate() error {
+	var errs []error
+
+	if !strings.HasPrefix(c.Target, "http://") && !strings.HasPrefix(c.Target, "https://") {
+		errs = append(errs, fmt.Errorf("the target must be an http or https URL, got %q", c.Target))
+	}
+	if len(c.Mix) == 0 {
+		errs = append(errs, errors.New("the mix must have at least one kind of request"))
+	}
+	for _, choice := range c.Mix {
+		if !kinds[choice.Value] {
+			errs = append(errs, fmt.Errorf("unknown kind of request %q in the mix", choice.Value))
+		}
+	}
+	if c.Rate < 0 {
+		errs = append(errs, errors.New("the rate must not be negative"))
+	}
+	if c.Concurrency < 0 || c.BatchSize < 0 || c.Timeout < 0 || c.MaxRequests < 0 {
+		errs = append(errs, errors.New("the concurrency, batch size, timeout and max requests must not be negative"))
+	}
+	if c.Duration <= 0 && c.MaxRequests == 0 {
+		errs = append(errs, errors.New("either the duration or the max requests must be set"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// result is the outcome of a single request
+type result struct {
+	kind    string
+	items   int
+	status  int
+	err     error
+	latency time.Duration
+}
+
+// Runner sends generated items to a service following a config
+type Runner struct {
+	config    Config
+	generator *Generator
+	client    *http.Client
+}
+
+// NewRunner returns a Runner of the config sending the items of generator with client,
+// which defaults to one with the config timeout
+func NewRunner(config Config, generator *Generator, client *http.Client) (*Runner, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	if config.Concurrency == 0 {
+		config.Concurrency = defaultConcurrency
+	}
+	if config.BatchSize == 0 {
+		config.BatchSize = defaultBatchSize
+	}
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+
+	if client == nil {
+		client = &http.Client{
+			Timeout: config.Timeout,
+			Transport: &http.Transport{
+				MaxIdleConnsPerHost: config.Concurrency,
+			},
+		}
+	}
+
+	return &Runner{
+		config:    config,
+		generator: generator,
+		client:    client,
+	}, nil
+}
+
+// Run creates the regions of the profile, which the items refer to, then sends requests until
+// the duration is over, the max requests are sent or ctx is done. It waits for the requests in
+// flight and reports the outcome of all of them.
+func (r *Runner) Run(ctx context.Context) (Report, error) {
+	if err := r.createRegions(ctx); err != nil {
+		return Report{}, err
+	}
+
+	if r.config.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.config.Duration)
+		defer cancel()
+	}
+
+	results := make(chan result, r.config.Concurrency)
+	collector := newCollector()
+
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for res := range results {
+			collector.add(res)
+		}
+	}()
+
+	start := time.Now()
+
+	var skipped int
+	if r.config.Rate > 0 {
+		skipped = r.runAtRate(ctx, results)
+	} else {
+		r.runWorkers(ctx, results)
+	}
+
+	close(results)
+	<-collected
+
+	return collector.report(time.Since(start), skipped), nil
+}
+
+func (r *Runner) createRegions(ctx context.Context) error {
+	for _, region := range r.generator.profile.Regions {
+		body, err := json.Marshal(types.PortalRegion{PortalRegionName: region})
+		if err != nil {
+			return err
+		}
+
+		status, err := r.post(ctx, "region", body)
+		if err != nil {
+			return fmt.Errorf("error creating region %s: %w", region, err)
+		}
+
+		if status != http.StatusOK {
+			return fmt.Errorf("error creating region %s: got status %d", region, status)
+		}
+	}
+
+	return nil
+}
+
+// post sends body to the path under /v0 of the target, returning the response status
+func (r *Runner) post(ctx context.Context, path string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(r.config.Target, "/")+"/v0/"+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", r.config.APIKey)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// The body is read so the connection can be reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return resp.StatusCode, nil
+}
+
+// runWorkers sends requests back to back from each worker
+func (r *Runner) runWorkers(ctx context.Context, results chan<- result) {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		started int
+	)
+
+	// next reserves a request, reporting false once the max requests are reached
+	next := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.config.MaxRequests > 0 && started >= r.config.MaxRequests {
+			return false
+		}
+
+		started++
+
+		return true
+	}
+
+	for i := 0; i < r.config.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for ctx.Err() == nil && next() {
+				results <- r.send()
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// runAtRate starts requests at the configured rate whether the previous ones were answered
+// or not, so a slow service doesn't slow down the load. It returns the requests skipped
+// because the concurrency was reached.
+func (r *Runner) runAtRate(ctx context.Context, results chan<- result) int {
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / r.config.Rate))
+	defer ticker.Stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	inFlight := make(chan struct{}, r.config.Concurrency)
+	started, skipped := 0, 0
+
+	for r.config.MaxRequests == 0 || started+skipped < r.config.MaxRequests {
+		select {
+		case <-ctx.Done():
+			return skipped
+		case <-ticker.C:
+		}
+
+		select {
+		case inFlight <- struct{}{}:
+		default:
+			skipped++
+			continue
+		}
+
+		started++
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-inFlight }()
+
+			results <- r.send()
+		}()
+	}
+
+	return skipped
+}
+
+// send sends a request of a kind picked from the mix, not tied to the run context so the
+// requests in flight when the run ends are still answered
+func (r *Runner) send() result {
+	kind := r.generator.pickKind(r.config.Mix)
+
+	body, items, err := r.body(kind)
+	if err != nil {
+		return result{kind: kind, err: err}
+	}
+
+	start := time.Now()
+	status, err := r.post(context.Background(), kind, body)
+
+	return result{kind: kind, items: items, status: status, err: err, latency: time.Since(start)}
+}
+
+// body returns the JSON body of a request of the kind and the number of items it holds
+func (r *Runner) body(kind string) ([]byte, int, error) {
+	var (
+		payload any
+		items   = 1
+	)
+
+	switch kind {
+	case KindSession:
+		payload = r.generator.Session()
+
+	case KindRelay:
+		payload = r.generator.Relay()
+
+	case KindServiceRecord:
+		payload = r.generator.ServiceRecord()
+
+	case KindRelays:
+		relays := make([]any, r.config.BatchSize)
+		for i := range relays {
+			relays[i] = r.generator.Relay()
+		}
+
+		payload, items = relays, len(relays)
+
+	case KindServiceRecords:
+		serviceRecords := make([]any, r.config.BatchSize)
+		for i := range serviceRecords {
+			serviceRecords[i] = r.generator.ServiceRecord()
+		}
+
+		payload, items = serviceRecords, len(serviceRecords)
+	}
+
+	body, err := json.Marshal(payload)
+
+	return body, items, err
+}
+
+// pickKind picks the kind of the next request from the mix
+func (g *Generator) pickKind(mix Weights) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return mix.pick(g.rand)
+}
@@ -0,0 +1,219 @@
+package loadgen
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeService accepts valid relays and sessions, rejecting every other service records request
+// as rate limited
+type fakeService struct {
+	mu       sync.Mutex
+	paths    map[string]int
+	inFlight atomic.Int32
+	maxSeen  atomic.Int32
+	delay    time.Duration
+}
+
+func newFakeService(t *testing.T, delay time.Duration) (*fakeService, *httptest.Server) {
+	t.Helper()
+
+	service := &fakeService{paths: make(map[string]int), delay: delay}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlight := service.inFlight.Add(1)
+		defer service.inFlight.Add(-1)
+
+		for {
+			seen := service.maxSeen.Load()
+			if inFlight <= seen || service.maxSeen.CompareAndSwap(seen, inFlight) {
+				break
+			}
+		}
+
+		time.Sleep(service.delay)
+
+		service.mu.Lock()
+		service.paths[r.URL.Path]++
+		count := service.paths[r.URL.Path]
+		service.mu.Unlock()
+
+		if r.Header.Get("Authorization") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v0/relays":
+			var relays []types.Relay
+			if err := json.NewDecoder(r.Body).Decode(&relays); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			for _, relay := range relays {
+				if relay.Validate() != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+			}
+
+		case "/v0/service-records":
+			if count%2 == 0 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(server.Close)
+
+	return service, server
+}
+
+func TestConfig_Validate(t *testing.T) {
+	c := require.New(t)
+
+	valid := Config{Target: "http://localhost:8080", Mix: DefaultMix(), Duration: time.Second}
+	c.NoError(valid.Validate())
+
+	invalid := Config{Target: "localhost:8080", Mix: Weights{{Value: "relay", Weight: 1}, {Value: "regions", Weight: 1}}, Rate: -1}
+
+	err := invalid.Validate()
+	c.ErrorContains(err, "target")
+	c.ErrorContains(err, `unknown kind of request "regions"`)
+	c.ErrorContains(err, "rate")
+	c.ErrorContains(err, "duration or the max requests")
+}
+
+func TestRunner_Concurrency(t *testing.T) {
+	c := require.New(t)
+
+	service, server := newFakeService(t, 5*time.Millisecond)
+
+	runner, err := NewRunner(Config{
+		Target:      server.URL,
+		APIKey:      "key",
+		Mix:         Weights{{Value: KindRelays, Weight: 1}, {Value: KindServiceRecords, Weight: 1}},
+		Concurrency: 4,
+		MaxRequests: 40,
+		BatchSize:   5,
+	}, NewGenerator(DefaultProfile(), 21), nil)
+	c.NoError(err)
+
+	report, err := runner.Run(context.Background())
+	c.NoError(err)
+
+	c.Equal(40, report.Total.Requests)
+	c.Equal(200, report.Total.ItemsSent)
+	c.Equal(report.Kinds[KindRelays].Requests, report.Kinds[KindRelays].Succeeded)
+	c.Equal(report.Kinds[KindRelays].Requests*5, report.Kinds[KindRelays].ItemsAccepted)
+
+	serviceRecords := report.Kinds[KindServiceRecords]
+	c.Equal(serviceRecords.Requests, serviceRecords.Succeeded+serviceRecords.Rejected["429"])
+	c.NotZero(serviceRecords.Rejected["429"])
+	c.Equal(report.Total.Succeeded, report.Kinds[KindRelays].Succeeded+serviceRecords.Succeeded)
+
+	c.GreaterOrEqual(report.Total.Latency.P50, 5*time.Millisecond)
+	c.GreaterOrEqual(report.Total.Latency.Max, report.Total.Latency.P99)
+	c.Positive(report.RequestsPerSecond)
+	c.LessOrEqual(service.maxSeen.Load(), int32(4))
+
+	// The regions are created before the load
+	c.Equal(len(DefaultProfile().Regions), service.paths["/v0/region"])
+}
+
+func TestRunner_Rate(t *testing.T) {
+	c := require.New(t)
+
+	_, server := newFakeService(t, 0)
+
+	runner, err := NewRunner(Config{
+		Target:   server.URL,
+		APIKey:   "key",
+		Mix:      Weights{{Value: KindRelay, Weight: 1}},
+		Rate:     100,
+		Duration: 500 * time.Millisecond,
+	}, NewGenerator(DefaultProfile(), 21), nil)
+	c.NoError(err)
+
+	report, err := runner.Run(context.Background())
+	c.NoError(err)
+
+	c.InDelta(50, report.Total.Requests, 15)
+	c.Equal(report.Total.Requests, report.Total.Succeeded)
+	c.Zero(report.Skipped)
+}
+
+func TestRunner_RateSkipped(t *testing.T) {
+	c := require.New(t)
+
+	// A service slower than the rate allows for with a single request in flight
+	_, server := newFakeService(t, 50*time.Millisecond)
+
+	runner, err := NewRunner(Config{
+		Target:      server.URL,
+		APIKey:      "key",
+		Mix:         Weights{{Value: KindSession, Weight: 1}},
+		Rate:        100,
+		Concurrency: 1,
+		MaxRequests: 20,
+	}, NewGenerator(DefaultProfile(), 21), nil)
+	c.NoError(err)
+
+	report, err := runner.Run(context.Background())
+	c.NoError(err)
+
+	c.Equal(20, report.Total.Requests+report.Skipped)
+	c.Positive(report.Skipped)
+}
+
+func TestRunner_Rejections(t *testing.T) {
+	c := require.New(t)
+
+	_, server := newFakeService(t, 0)
+
+	profile := DefaultProfile()
+	profile.InvalidRatio = 1
+
+	runner, err := NewRunner(Config{
+		Target:      server.URL,
+		APIKey:      "key",
+		Mix:         Weights{{Value: KindRelays, Weight: 1}},
+		MaxRequests: 5,
+	}, NewGenerator(profile, 21), nil)
+	c.NoError(err)
+
+	report, err := runner.Run(context.Background())
+	c.NoError(err)
+	c.Equal(map[string]int{"400": 5}, report.Total.Rejected)
+	c.Zero(report.Total.ItemsAccepted)
+
+	// The regions can't be created without a valid key
+	runner, err = NewRunner(Config{Target: server.URL, APIKey: "wrong", Mix: DefaultMix(), MaxRequests: 1},
+		NewGenerator(profile, 21), nil)
+	c.NoError(err)
+
+	_, err = runner.Run(context.Background())
+	c.ErrorContains(err, "got status 401")
+
+	// A target that can't be reached fails the run before any load is sent
+	server.Close()
+
+	runner, err = NewRunner(Config{Target: server.URL, Mix: DefaultMix(), MaxRequests: 1}, NewGenerator(profile, 21), nil)
+	c.NoError(err)
+
+	_, err = runner.Run(context.Background())
+	c.Error(err)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRange(t *testing.T) {
+	c := require.New(t)
+
+	tests := []struct {
+		name        string
+		raw         string
+		expectedMin int
+		expectedMax int
+		expectErr   bool
+	}{
+		{
+			name:        "Range",
+			raw:         "100-5000",
+			expectedMin: 100,
+			expectedMax: 5000,
+		},
+		{
+			name:        "Spaces",
+			raw:         "10 - 20",
+			expectedMin: 10,
+			expectedMax: 20,
+		},
+		{
+			name:      "Single value",
+			raw:       "100",
+			expectErr: true,
+		},
+		{
+			name:      "Not a number",
+			raw:       "100-max",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		min, max, err := parseRange(tt.raw)
+		if tt.expectErr {
+			c.Error(err, tt.name)
+			continue
+		}
+
+		c.NoError(err, tt.name)
+		c.Equal(tt.expectedMin, min, tt.name)
+		c.Equal(tt.expectedMax, max, tt.name)
+	}
+}
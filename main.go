@@ -2,199 +2,247 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"os"
-	"os/signal"
-	"syscall"
+	"runtime"
+	"runtime/debug"
+	"strings"
 	"time"
 
-	postgresdriver "github.com/pokt-foundation/transaction-db/postgres-driver"
-	"github.com/pokt-foundation/transaction-http-db/batch"
-	"github.com/pokt-foundation/transaction-http-db/router"
-	"github.com/pokt-foundation/utils-go/environment"
+	"github.com/pokt-foundation/transaction-http-db/config"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	// The storage backends register themselves on import
+	_ "github.com/pokt-foundation/transaction-http-db/storage/memory"
+	_ "github.com/pokt-foundation/transaction-http-db/storage/postgres"
+	_ "github.com/pokt-foundation/transaction-http-db/storage/sqlite"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 const (
-	// Postgres DB vars - Required for all Envs.
-	pgUser     = "PG_USER"
-	pgPassword = "PG_PASSWORD"
-	pgDatabase = "PG_DATABASE"
-	// Local DB vars - Required for development/test Env.
-	pgHost = "PG_HOST"
-	pgPort = "PG_PORT"
-	// CloudSQL DB vars - Required for production Env.
-	dbInstanceConnectionName = "DB_INSTANCE_CONNECTION_NAME"
-	privateIP                = "PRIVATE_IP"
-
-	chanSize                      = "CHAN_SIZE"
-	apiKeys                       = "API_KEYS"
-	port                          = "PORT"
-	maxRelayBatchSize             = "MAX_RELAY_BATCH_SIZE"
-	maxRelayBatchDuration         = "MAX_RELAY_BATCH_DURATION"
-	maxServiceRecordBatchSize     = "MAX_SERVICE_RECORD_BATCH_SIZE"
-	maxServiceRecordBatchDuration = "MAX_SERVICE_RECORD_BATCH_DURATION"
-	dbTimeout                     = "DB_TIMEOUT"
-	debug                         = "DEBUG"
-
-	defaultPort          = "8080"
-	defaultBatchSize     = 1000
-	defaultBatchDuration = 60
-	defaultDBTimeout     = 60
-	defaultDebug         = false
-	defaultUseSSH        = false
-	defaultChanSize      = 10000
+	binaryName = "transaction-http-db"
+
+	// configFile is the env var of the optional YAML config file, also settable with the -config flag
+	configFile = "CONFIG_FILE"
+
+	dateLayout = "2006-01-02"
 )
 
-type (
-	options struct {
-		// Required vars
-		apiKeys                        map[string]bool
-		pgUser, pgPassword, pgDatabase string
-		// Local DB vars - Required for development/test Env.
-		pgHost, pgPort string
-		// CloudSQL DB vars - Required for production Env.
-		dbInstanceConnectionName string
-		privateIP                bool
-		// Optional vars
-		port                          string
-		maxRelayBatchSize             int
-		maxRelayBatchDuration         time.Duration
-		maxServiceRecordBatchSize     int
-		maxServiceRecordBatchDuration time.Duration
-		dbTimeout                     time.Duration
-		debug                         bool
-		chanSize                      int
-	}
+// version is set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
+// command is a subcommand of the binary, run with the arguments following its name
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{name: "serve", summary: "Run the HTTP server, the default when no command is given", run: runServe},
+	{name: "validate-config", summary: "Check the settings and exit, listing every problem found", run: runValidateConfig},
+	{name: "replay", summary: "Write the batches the storage backend failed to save to it again", run: runReplay},
+	{name: "export", summary: "Write the relays of a time range to an NDJSON file", run: runExport},
+	{name: "loadgen", summary: "Send synthetic traffic to a running instance and report how it coped", run: runLoadgen},
+	{name: "version", summary: "Print the version of the binary", run: runVersion},
+}
 
-	// DB config structs
-	DBConfig interface {
-		GetDriver(ctx context.Context) (driver *postgresdriver.PostgresDriver, cleanup func() error, err error)
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [command] [flags]\n\nCommands:\n", binaryName)
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", cmd.name, cmd.summary)
 	}
-	cloudSQLConfig struct {
-		options
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> -h' for the flags of a command.\n", binaryName)
+}
+
+func main() {
+	// Serving is the default so the binary keeps working when run without a command
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
-	testDBConfig struct {
-		options
+
+	if name == "help" {
+		printUsage()
+		return
 	}
-)
 
-func gatherOptions() options {
-	return options{
-		// Required vars
-		apiKeys:    environment.MustGetStringMap(apiKeys, ","),
-		pgUser:     environment.MustGetString(pgUser),
-		pgPassword: environment.MustGetString(pgPassword),
-		pgDatabase: environment.MustGetString(pgDatabase),
-		// CloudSQL DB Config var
-		dbInstanceConnectionName: environment.GetString(dbInstanceConnectionName, ""),
-		privateIP:                environment.GetBool(privateIP, false),
-		// Local DB Config vars
-		pgHost: environment.GetString(pgHost, ""),
-		pgPort: environment.GetString(pgPort, ""),
-		// Optional vars
-		port:                          environment.GetString(port, defaultPort),
-		maxRelayBatchSize:             int(environment.GetInt64(maxRelayBatchSize, defaultBatchSize)),
-		maxRelayBatchDuration:         time.Duration(environment.GetInt64(maxRelayBatchDuration, defaultBatchDuration)) * time.Second,
-		maxServiceRecordBatchSize:     int(environment.GetInt64(maxServiceRecordBatchSize, defaultBatchSize)),
-		maxServiceRecordBatchDuration: time.Duration(environment.GetInt64(maxServiceRecordBatchDuration, defaultBatchDuration)) * time.Second,
-		dbTimeout:                     time.Duration(environment.GetInt64(dbTimeout, defaultDBTimeout)) * time.Second,
-		debug:                         environment.GetBool(debug, defaultDebug),
-		chanSize:                      int(environment.GetInt64(chanSize, defaultChanSize)),
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+
+		if err := cmd.run(args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		return
 	}
+
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+	printUsage()
+	os.Exit(2)
 }
 
-// CloudSQLConfig.GetDriver connects to a GCP CloudSQL instance using the cloudsqlconn lib.
-// Intended for production use. Will be used if APP_ENV is 'production'.
-func (c *cloudSQLConfig) GetDriver(ctx context.Context) (driver *postgresdriver.PostgresDriver, cleanup func() error, err error) {
-	driverConfig := postgresdriver.CloudSQLConfig{
-		DBUser:                 c.options.pgUser,
-		DBPassword:             c.options.pgPassword,
-		DBName:                 c.options.pgDatabase,
-		InstanceConnectionName: c.options.dbInstanceConnectionName,
-	}
-	if c.options.privateIP {
-		driverConfig.PrivateIP = "true"
+// commandFlagSet returns the flag set of a command, its usage starting with the summary
+func commandFlagSet(name, summary string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s %s [flags]\n\n%s\n\nFlags:\n", binaryName, name, summary)
+		flags.PrintDefaults()
 	}
 
-	driver, cleanup, err = postgresdriver.NewCloudSQLPostgresDriver(driverConfig)
-	if err != nil {
-		return nil, nil, err
-	}
+	return flags
+}
 
-	return driver, cleanup, nil
+// newFlagSet returns the flag set of a command, with the -config flag the commands reading the settings share
+func newFlagSet(name, summary string) (*flag.FlagSet, *string) {
+	flags := commandFlagSet(name, summary)
+
+	path := flags.String("config", os.Getenv(configFile), "path of the YAML config file, the env vars taking precedence over it")
+
+	return flags, path
 }
 
-// testDBConfig.GetDriver connects to a Postgres database using standard connection string and user/PW.
-// Intended to be used for running tests on a local Docker container. Will be used if APP_ENV is 'test' or 'development'.
-func (c *testDBConfig) GetDriver(ctx context.Context) (driver *postgresdriver.PostgresDriver, cleanup func() error, err error) {
-	connectionString := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		c.options.pgHost,
-		c.options.pgPort,
-		c.options.pgUser,
-		c.options.pgPassword,
-		c.options.pgDatabase,
-	)
+// loadConfig reads the settings of the config file at path and the env vars, checking them
+// with validate, which covers the settings the command reads, and exiting with every problem
+// found when they are invalid
+func loadConfig(path string, validate func(config.Config) error) config.Config {
+	cfg, err := config.Load(path)
+	if err == nil {
+		err = validate(cfg)
+	}
 
-	driver, cleanup, err = postgresdriver.NewPostgresDriver(connectionString)
 	if err != nil {
-		return nil, nil, err
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%s\n", err)
+		os.Exit(1)
 	}
 
-	return driver, cleanup, nil
+	return cfg
 }
 
-func main() {
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
+// openStorage opens the storage backend of the settings
+func openStorage(ctx context.Context, cfg config.Config) (storage.Driver, func() error, error) {
+	return storage.Open(ctx, cfg.Storage.Backend, cfg.StorageConfig())
+}
+
+func logLevel(debug bool) zapcore.Level {
+	if debug {
+		return zapcore.DebugLevel
+	}
 
-	options := gatherOptions()
+	return zapcore.InfoLevel
+}
 
+// newLogger returns the JSON logger of the service and the level it logs at, which can be changed
+func newLogger(cfg config.Config) (*zap.Logger, zap.AtomicLevel) {
 	logConfig := zap.NewProductionConfig()
 	logConfig.DisableStacktrace = true
 	logConfig.DisableCaller = true
 	logConfig.EncoderConfig.TimeKey = ""
+	logConfig.Level.SetLevel(logLevel(cfg.Debug))
+
+	logConfig.Sampling = nil
+
+	var opts []zap.Option
+	if cfg.Log.SamplingInitial > 0 {
+		opts = append(opts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return newDebugSamplingCore(core, cfg.Log.SamplingInitial, cfg.Log.SamplingThereafter)
+		}))
+	}
+
+	return zap.Must(logConfig.Build(opts...)), logConfig.Level
+}
+
+// debugSamplingCore samples the debug lines only, so that the access log and the lines
+// that report a problem are always written
+type debugSamplingCore struct {
+	zapcore.Core
+	sampled zapcore.Core
+}
 
-	if options.debug {
-		logConfig.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+// newDebugSamplingCore returns core with its debug lines with the same message sampled each
+// second: the first initial are written, then every thereafter one
+func newDebugSamplingCore(core zapcore.Core, initial, thereafter int) zapcore.Core {
+	return &debugSamplingCore{
+		Core:    core,
+		sampled: zapcore.NewSamplerWithOptions(core, time.Second, initial, thereafter),
 	}
+}
 
-	log := zap.Must(logConfig.Build())
+func (c *debugSamplingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &debugSamplingCore{Core: c.Core.With(fields), sampled: c.sampled.With(fields)}
+}
 
-	// Choose DB configuration based on DB config vars
-	var dbConfig DBConfig
-	switch {
-	// For CloudSQL DB
-	case options.dbInstanceConnectionName != "":
-		dbConfig = &cloudSQLConfig{options: options}
+func (c *debugSamplingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if entry.Level == zapcore.DebugLevel {
+		return c.sampled.Check(entry, checked)
+	}
 
-	// For local DB
-	case options.pgHost != "" && options.pgPort != "":
-		dbConfig = &testDBConfig{options: options}
+	return c.Core.Check(entry, checked)
+}
 
-	default:
-		panic("invalid DB configuration")
+// parseTime parses a time given either in RFC3339 or as a date, which is midnight UTC
+func parseTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(dateLayout, raw); err == nil {
+		return t, nil
 	}
 
-	driver, cleanup, err := dbConfig.GetDriver(context.Background())
+	t, err := time.Parse(time.RFC3339, raw)
 	if err != nil {
-		panic(err)
+		return time.Time{}, fmt.Errorf("invalid time %q, must be a date such as 2023-10-21 or in RFC3339", raw)
 	}
 
-	defer func() {
-		if err := cleanup(); err != nil {
-			log.Error(fmt.Sprintf("Failed to clean up: %v", err))
+	return t, nil
+}
+
+func runValidateConfig(args []string) error {
+	flags, path := newFlagSet("validate-config", "Check the settings and exit, listing every problem found.")
+	connect := flags.Bool("connect", false, "also connect to the storage backend and check its schema")
+	_ = flags.Parse(args)
+
+	cfg := loadConfig(*path, config.Config.Validate)
+
+	if *connect {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.DBTimeout.Duration())
+		defer cancel()
+
+		driver, cleanup, err := openStorage(ctx, cfg)
+		if err != nil {
+			return err
 		}
-	}()
+		defer cleanup()
 
-	relayBatch := batch.NewBatch(options.maxRelayBatchSize, options.chanSize, "relay", options.maxRelayBatchDuration, options.dbTimeout, driver.WriteRelays, log)
-	serviceRecordBatch := batch.NewBatch(options.maxServiceRecordBatchSize, options.chanSize, "service_record", options.maxServiceRecordBatchDuration, options.dbTimeout, driver.WriteServiceRecords, log)
+		if err := driver.Ping(ctx); err != nil {
+			return fmt.Errorf("error reaching %s storage backend: %w", cfg.Storage.Backend, err)
+		}
 
-	router, err := router.NewRouter(driver, options.apiKeys, options.port, relayBatch, serviceRecordBatch, log)
-	if err != nil {
-		panic(err)
+		if err := storage.CheckSchema(ctx, driver); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println("Configuration is valid")
+
+	return nil
+}
+
+func runVersion(args []string) error {
+	flags := flag.NewFlagSet("version", flag.ExitOnError)
+	_ = flags.Parse(args)
+
+	revision := "unknown"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				revision = setting.Value
+			}
+		}
 	}
 
-	router.RunServer(ctx)
+	fmt.Printf("%s %s (revision %s, %s)\n", binaryName, version, revision, runtime.Version())
+
+	return nil
 }
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestDebugSamplingCore(t *testing.T) {
+	c := require.New(t)
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := zap.New(newDebugSamplingCore(core, 2, 0)).With(zap.String("key", "value"))
+
+	for i := 0; i < 5; i++ {
+		log.Debug("item added")
+		log.Info("request served")
+		log.Warn("save slow")
+		log.Error("save failed")
+	}
+
+	c.Equal(2, logs.FilterMessage("item added").Len())
+	c.Equal(5, logs.FilterMessage("request served").Len())
+	c.Equal(5, logs.FilterMessage("save slow").Len())
+	c.Equal(5, logs.FilterMessage("save failed").Len())
+	c.Equal("value", logs.All()[0].ContextMap()["key"])
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/pokt-foundation/transaction-http-db/config"
+	"github.com/pokt-foundation/transaction-http-db/sink/archive"
+)
+
+const (
+	replayTypeAll           = "all"
+	replayTypeRelay         = "relay"
+	replayTypeServiceRecord = "service_record"
+)
+
+// replayFilter returns whether an archive file is replayed, by whether its date overlaps the
+// time range and by its chain
+func replayFilter(from, to time.Time, chain string) func(entry archive.ManifestEntry) bool {
+	return func(entry archive.ManifestEntry) bool {
+		date, err := time.Parse(dateLayout, entry.Date)
+		if err != nil {
+			return false
+		}
+
+		if !from.IsZero() && !date.Add(24*time.Hour).After(from) {
+			return false
+		}
+
+		if !to.IsZero() && !date.Before(to) {
+			return false
+		}
+
+		return chain == "" || entry.Chain == chain
+	}
+}
+
+// replayWriter returns write bounded by the DB timeout, or nil when write is nil on a dry run
+func replayWriter[T any](timeout time.Duration, write func(context.Context, []*T) error) func(context.Context, []*T) error {
+	if write == nil {
+		return nil
+	}
+
+	return func(ctx context.Context, items []*T) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		return write(ctx, items)
+	}
+}
+
+func runReplay(args []string) error {
+	flags, configPath := newFlagSet("replay", "Write the batches the storage backend failed to save, kept in the dead letter spool, to the\n"+
+		"storage backend. Replays pick up where the last one stopped, so no item is written twice.")
+	dir := flags.String("dir", "", "dead letter directory, or archive directory, defaulting to the DEAD_LETTER_DIR setting")
+	itemType := flags.String("type", replayTypeAll, "items to replay: relay, service_record or all")
+	rawFrom := flags.String("from", "", "only replay the files of dates from this time, a date or RFC3339")
+	rawTo := flags.String("to", "", "only replay the files of dates before this time, a date or RFC3339")
+	chain := flags.String("chain", "", "only replay the files of this chain")
+	batchSize := flags.Int("batch-size", 0, "items written at once, defaulting to the max batch size settings")
+	dryRun := flags.Bool("dry-run", false, "read the files without writing anything")
+	_ = flags.Parse(args)
+
+	cfg := loadConfig(*configPath, config.Config.ValidateReplay)
+
+	if *dir == "" {
+		*dir = cfg.Sinks.DeadLetterDir
+	}
+	if *dir == "" {
+		return errors.New("the dead letter directory must be set with -dir or DEAD_LETTER_DIR")
+	}
+
+	if *itemType != replayTypeAll && *itemType != replayTypeRelay && *itemType != replayTypeServiceRecord {
+		return fmt.Errorf("invalid type %q, must be relay, service_record or all", *itemType)
+	}
+
+	var from, to time.Time
+	var err error
+
+	if *rawFrom != "" {
+		if from, err = parseTime(*rawFrom); err != nil {
+			return err
+		}
+	}
+	if *rawTo != "" {
+		if to, err = parseTime(*rawTo); err != nil {
+			return err
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var (
+		writeRelays         func(context.Context, []*types.Relay) error
+		writeServiceRecords func(context.Context, []*types.ServiceRecord) error
+	)
+
+	if !*dryRun {
+		driver, cleanup, err := openStorage(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		writeRelays, writeServiceRecords = driver.WriteRelays, driver.WriteServiceRecords
+	}
+
+	filter := replayFilter(from, to, *chain)
+	timeout := cfg.DBTimeout.Duration()
+
+	verb := "Replayed"
+	if *dryRun {
+		verb = "Would replay"
+	}
+
+	if *itemType != replayTypeServiceRecord {
+		size := *batchSize
+		if size <= 0 {
+			size = cfg.Batches.MaxRelayBatchSize
+		}
+
+		stats, err := archive.Replay(ctx, filepath.Join(*dir, replayTypeRelay), filter, size,
+			replayWriter(timeout, writeRelays))
+		fmt.Fprintf(os.Stderr, "%s %d relays from %d files, %d files replayed before\n", verb, stats.Records, stats.Files, stats.Skipped)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if *itemType != replayTypeRelay {
+		size := *batchSize
+		if size <= 0 {
+			size = cfg.Batches.MaxServiceRecordBatchSize
+		}
+
+		stats, err := archive.Replay(ctx, filepath.Join(*dir, replayTypeServiceRecord), filter, size,
+			replayWriter(timeout, writeServiceRecords))
+		fmt.Fprintf(os.Stderr, "%s %d service records from %d files, %d files replayed before\n", verb, stats.Records, stats.Files, stats.Skipped)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-http-db/sink/archive"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayFilter(t *testing.T) {
+	c := require.New(t)
+
+	october21 := time.Date(2023, time.October, 21, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		chain    string
+		entry    archive.ManifestEntry
+		expected bool
+	}{
+		{
+			name:     "No filter",
+			entry:    archive.ManifestEntry{Date: "2023-10-21", Chain: "0021"},
+			expected: true,
+		},
+		{
+			name:     "Date in range",
+			from:     october21,
+			to:       october21.Add(24 * time.Hour),
+			entry:    archive.ManifestEntry{Date: "2023-10-21", Chain: "0021"},
+			expected: true,
+		},
+		{
+			name:     "Date overlapping the start of the range",
+			from:     october21.Add(12 * time.Hour),
+			entry:    archive.ManifestEntry{Date: "2023-10-21", Chain: "0021"},
+			expected: true,
+		},
+		{
+			name:  "Date before the range",
+			from:  october21,
+			entry: archive.ManifestEntry{Date: "2023-10-20", Chain: "0021"},
+		},
+		{
+			name:  "Date at the end of the range",
+			to:    october21,
+			entry: archive.ManifestEntry{Date: "2023-10-21", Chain: "0021"},
+		},
+		{
+			name:  "Other chain",
+			chain: "0040",
+			entry: archive.ManifestEntry{Date: "2023-10-21", Chain: "0021"},
+		},
+		{
+			name:  "Invalid date",
+			entry: archive.ManifestEntry{Date: "21/10/2023", Chain: "0021"},
+		},
+	}
+
+	for _, tt := range tests {
+		c.Equal(tt.expected, replayFilter(tt.from, tt.to, tt.chain)(tt.entry), tt.name)
+	}
+}
@@ -0,0 +1,114 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pokt-foundation/transaction-http-db/batch"
+	jsonresponse "github.com/pokt-foundation/utils-go/json-response"
+)
+
+// batchController is the part of a batch the admin routes operate
+type batchController interface {
+	Name() string
+	Status() batch.Status
+	Flush() (int, error)
+	SaveTimeout() time.Duration
+	Pause()
+	Resume()
+}
+
+// FlushResult is the response of the flush endpoint
+type FlushResult struct {
+	Saved int `json:"saved"`
+}
+
+func (rt *Router) batches() []batchController {
+	return []batchController{rt.relayBatch, rt.serviceRecordBatch}
+}
+
+// batchFromRequest returns the batch named in the path, responding with 404 when there is none
+func (rt *Router) batchFromRequest(w http.ResponseWriter, r *http.Request) (batchController, bool) {
+	name := mux.Vars(r)["name"]
+
+	for _, b := range rt.batches() {
+		if b.Name() == name {
+			return b, true
+		}
+	}
+
+	jsonresponse.RespondWithError(w, http.StatusNotFound, fmt.Sprintf("unknown batch %q", name))
+
+	return nil, false
+}
+
+// GetBatches returns the state and settings of each batch, keyed by batch name
+func (rt *Router) GetBatches(w http.ResponseWriter, r *http.Request) {
+	batches := make(map[string]batch.Status)
+	for _, b := range rt.batches() {
+		batches[b.Name()] = b.Status()
+	}
+
+	jsonresponse.RespondWithJSON(w, http.StatusOK, batches)
+}
+
+// FlushBatch saves the items of a batch right away, paused or not, responding with the
+// number of items saved or with why the save failed and what became of the items
+func (rt *Router) FlushBatch(w http.ResponseWriter, r *http.Request) {
+	b, ok := rt.batchFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	// The save can take longer than the write timeout, which then starts once it is over
+	if err := rt.delayWriteDeadline(w, b.SaveTimeout()); err != nil {
+		rt.logError(r.Context(), fmt.Errorf("FlushBatch in extending the write deadline failed: %w", err))
+	}
+
+	saved, err := b.Flush()
+	if err != nil {
+		rt.logError(r.Context(), fmt.Errorf("FlushBatch in saving %s batch failed: %w", b.Name(), err))
+
+		outcome := "they were dropped"
+		if errors.Is(err, batch.ErrKeptForReplay) {
+			outcome = "they were kept for a replay"
+		}
+
+		jsonresponse.RespondWithError(w, http.StatusInternalServerError,
+			fmt.Sprintf("saving the %d items of the %s batch failed, %s: %s", saved, b.Name(), outcome, err))
+		return
+	}
+
+	rt.logger(r.Context()).Info(fmt.Sprintf("%s batch flushed with %d items", b.Name(), saved))
+
+	jsonresponse.RespondWithJSON(w, http.StatusOK, FlushResult{Saved: saved})
+}
+
+// PauseBatch stops saving a batch until it is resumed, responding with its state
+func (rt *Router) PauseBatch(w http.ResponseWriter, r *http.Request) {
+	b, ok := rt.batchFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	b.Pause()
+	rt.logger(r.Context()).Warn(fmt.Sprintf("%s batch paused", b.Name()))
+
+	jsonresponse.RespondWithJSON(w, http.StatusOK, b.Status())
+}
+
+// ResumeBatch saves a paused batch again, responding with its state
+func (rt *Router) ResumeBatch(w http.ResponseWriter, r *http.Request) {
+	b, ok := rt.batchFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	b.Resume()
+	rt.logger(r.Context()).Info(fmt.Sprintf("%s batch resumed", b.Name()))
+
+	jsonresponse.RespondWithJSON(w, http.StatusOK, b.Status())
+}
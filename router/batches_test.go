@@ -0,0 +1,325 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/pokt-foundation/transaction-http-db/batch"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRouter_Batches(t *testing.T) {
+	c := require.New(t)
+
+	relayMock := &batch.MockRelayWriter{}
+	relayBatch := batch.NewBatch(21, 21, "relay", time.Hour, time.Hour, relayMock.WriteRelays, zap.NewNop())
+
+	serviceRecordMock := &batch.MockServiceRecordWriter{}
+	serviceRecordBatch := batch.NewBatch(21, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
+
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"gateway-key": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop(),
+		WithAdminKeys(map[string]bool{"admin-key": true}),
+	)
+	c.NoError(err)
+
+	relay := types.Relay{
+		PoktChainID:          "21",
+		EndpointID:           "21",
+		SessionKey:           "21",
+		ProtocolAppPublicKey: "21",
+		RelaySourceURL:       "pablo.com",
+		PoktNodeAddress:      "21",
+		PoktNodeDomain:       "pablos.com",
+		PoktNodePublicKey:    "aaa",
+		RelayStartDatetime:   time.Now(),
+		RelayReturnDatetime:  time.Now(),
+		RelayRoundtripTime:   1,
+		RelayChainMethodIDs:  []string{"get_height"},
+		RelayDataSize:        21,
+		RelayPortalTripTime:  21,
+		RelayNodeTripTime:    21,
+		PortalRegionName:     "La Colombia",
+		RequestID:            "21",
+		PoktTxID:             "21",
+	}
+
+	for i := 0; i < 3; i++ {
+		c.NoError(relayBatch.Add(context.Background(), &relay))
+	}
+
+	c.Eventually(func() bool { return relayBatch.Size() == 3 }, time.Second, 10*time.Millisecond)
+
+	relayMock.On("WriteRelays", mock.Anything, mock.Anything).Return(nil).Once()
+	serviceRecordMock.On("WriteServiceRecords", mock.Anything, mock.Anything).Return(errors.New("dummy")).Once()
+	c.NoError(serviceRecordBatch.Add(context.Background(), &types.ServiceRecord{
+		SessionKey:             "21",
+		NodePublicKey:          "21",
+		PoktChainID:            "21",
+		RequestID:              "21",
+		PortalRegionName:       "La Colombia",
+		Latency:                21.07,
+		Tickets:                2,
+		Result:                 "a",
+		Available:              true,
+		Successes:              21,
+		Failures:               7,
+		P90SuccessLatency:      21.07,
+		MedianSuccessLatency:   21.07,
+		WeightedSuccessLatency: 21.07,
+		SuccessRate:            21,
+	}))
+	c.Eventually(func() bool { return serviceRecordBatch.Size() == 1 }, time.Second, 10*time.Millisecond)
+
+	tests := []struct {
+		name               string
+		method             string
+		path               string
+		apiKey             string
+		expectedStatusCode int
+		expectedBody       string
+		expectedMessage    string
+	}{
+		{
+			name:               "Non admin key",
+			method:             http.MethodPost,
+			path:               "/v0/admin/batches/relay/flush",
+			apiKey:             "gateway-key",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Pause",
+			method:             http.MethodPost,
+			path:               "/v0/admin/batches/relay/pause",
+			apiKey:             "admin-key",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Flush",
+			method:             http.MethodPost,
+			path:               "/v0/admin/batches/relay/flush",
+			apiKey:             "admin-key",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"saved":3}`,
+		},
+		{
+			name:               "Failed flush",
+			method:             http.MethodPost,
+			path:               "/v0/admin/batches/service_record/flush",
+			apiKey:             "admin-key",
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedMessage:    "saving the 1 items of the service_record batch failed, they were dropped: dummy",
+		},
+		{
+			name:               "Unknown batch",
+			method:             http.MethodPost,
+			path:               "/v0/admin/batches/session/flush",
+			apiKey:             "admin-key",
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, tt.path, nil)
+		c.NoError(err)
+
+		req.Header.Set("Authorization", tt.apiKey)
+		rr := httptest.NewRecorder()
+
+		router.router.ServeHTTP(rr, req)
+		c.Equal(tt.expectedStatusCode, rr.Code, tt.name)
+
+		if tt.expectedBody != "" {
+			c.JSONEq(tt.expectedBody, rr.Body.String(), tt.name)
+		}
+
+		c.Contains(rr.Body.String(), tt.expectedMessage, tt.name)
+	}
+
+	relayMock.AssertExpectations(t)
+	serviceRecordMock.AssertExpectations(t)
+
+	req, err := http.NewRequest(http.MethodGet, "/v0/admin/batches", nil)
+	c.NoError(err)
+
+	req.Header.Set("Authorization", "admin-key")
+	rr := httptest.NewRecorder()
+
+	router.router.ServeHTTP(rr, req)
+	c.Equal(http.StatusOK, rr.Code)
+
+	var batches map[string]batch.Status
+	c.NoError(json.Unmarshal(rr.Body.Bytes(), &batches))
+	c.True(batches["relay"].Paused)
+	c.Equal(0, batches["relay"].Size)
+	c.Equal(21, batches["relay"].MaxSize)
+	c.Equal(21, batches["relay"].ChanCapacity)
+	c.Equal("1h0m0s", batches["relay"].MaxDuration)
+	c.False(batches["service_record"].Paused)
+
+	// Resuming is reflected in the state
+	req, err = http.NewRequest(http.MethodPost, "/v0/admin/batches/relay/resume", nil)
+	c.NoError(err)
+
+	req.Header.Set("Authorization", "admin-key")
+	rr = httptest.NewRecorder()
+
+	router.router.ServeHTTP(rr, req)
+	c.Equal(http.StatusOK, rr.Code)
+
+	var status batch.Status
+	c.NoError(json.Unmarshal(rr.Body.Bytes(), &status))
+	c.False(status.Paused)
+}
+
+func TestRouter_FlushBatch_keptForReplay(t *testing.T) {
+	c := require.New(t)
+
+	relayBatch := batch.NewBatch(21, 21, "relay", time.Hour, time.Hour, (&batch.MockRelayWriter{}).WriteRelays, zap.NewNop())
+
+	serviceRecordMock := &batch.MockServiceRecordWriter{}
+	serviceRecordBatch := batch.NewBatch(21, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
+
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"gateway-key": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop(),
+		WithAdminKeys(map[string]bool{"admin-key": true}),
+		WithServerConfig(ServerConfig{WriteTimeout: 50 * time.Millisecond}),
+	)
+	c.NoError(err)
+
+	server := httptest.NewUnstartedServer(router.router)
+	server.Config.WriteTimeout = 50 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	// The fan out keeps the batch the primary sink failed to write in its dead letter
+	serviceRecordMock.On("WriteServiceRecords", mock.Anything, mock.Anything).Return(fmt.Errorf("%w, %w", errors.New("dummy"), batch.ErrKeptForReplay)).
+		After(100 * time.Millisecond).Once()
+	c.NoError(serviceRecordBatch.Add(context.Background(), &types.ServiceRecord{
+		SessionKey:             "21",
+		NodePublicKey:          "21",
+		PoktChainID:            "21",
+		RequestID:              "21",
+		PortalRegionName:       "La Colombia",
+		Latency:                21.07,
+		Tickets:                2,
+		Result:                 "a",
+		Available:              true,
+		Successes:              21,
+		Failures:               7,
+		P90SuccessLatency:      21.07,
+		MedianSuccessLatency:   21.07,
+		WeightedSuccessLatency: 21.07,
+		SuccessRate:            21,
+	}))
+	c.Eventually(func() bool { return serviceRecordBatch.Size() == 1 }, time.Second, 10*time.Millisecond)
+
+	// The save takes longer than the write timeout, which only starts once it is over
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/v0/admin/batches/service_record/flush", nil)
+	c.NoError(err)
+
+	req.Header.Set("Authorization", "admin-key")
+
+	resp, err := http.DefaultClient.Do(req)
+	c.NoError(err)
+	defer resp.Body.Close()
+	c.Equal(http.StatusInternalServerError, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	c.NoError(err)
+	c.Contains(string(body), "saving the 1 items of the service_record batch failed, they were kept for a replay")
+
+	serviceRecordMock.AssertExpectations(t)
+}
+
+func TestRouter_CreateRelay_pausedFull(t *testing.T) {
+	c := require.New(t)
+
+	relayMock := &batch.MockRelayWriter{}
+	relayBatch := batch.NewBatch(1, 1, "relay", time.Hour, time.Hour, relayMock.WriteRelays, zap.NewNop())
+
+	serviceRecordMock := &batch.MockServiceRecordWriter{}
+	serviceRecordBatch := batch.NewBatch(1, 1, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
+
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop())
+	c.NoError(err)
+
+	relay := types.Relay{
+		PoktChainID:          "21",
+		EndpointID:           "21",
+		SessionKey:           "21",
+		ProtocolAppPublicKey: "21",
+		RelaySourceURL:       "pablo.com",
+		PoktNodeAddress:      "21",
+		PoktNodeDomain:       "pablos.com",
+		PoktNodePublicKey:    "aaa",
+		RelayStartDatetime:   time.Now(),
+		RelayReturnDatetime:  time.Now(),
+		RelayRoundtripTime:   1,
+		RelayChainMethodIDs:  []string{"get_height"},
+		RelayDataSize:        21,
+		RelayPortalTripTime:  21,
+		RelayNodeTripTime:    21,
+		PortalRegionName:     "La Colombia",
+		RequestID:            "21",
+		PoktTxID:             "21",
+	}
+
+	// The paused batch takes one relay and its channel another
+	relayBatch.Pause()
+	c.NoError(relayBatch.Add(context.Background(), &relay))
+	c.Eventually(func() bool { return relayBatch.Size() == 1 }, time.Second, 10*time.Millisecond)
+	c.NoError(relayBatch.Add(context.Background(), &relay))
+
+	relayToSend, err := json.Marshal(relay)
+	c.NoError(err)
+
+	relaysToSend, err := json.Marshal([]types.Relay{relay, relay})
+	c.NoError(err)
+
+	tests := []struct {
+		name            string
+		path            string
+		reqInput        []byte
+		expectedMessage string
+	}{
+		{
+			name:            "Relay",
+			path:            "/v0/relay",
+			reqInput:        relayToSend,
+			expectedMessage: batch.ErrFull.Error(),
+		},
+		{
+			name:            "Relays",
+			path:            "/v0/relays",
+			reqInput:        relaysToSend,
+			expectedMessage: batch.ErrFull.Error() + ", 0 of 2 relays were queued",
+		},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodPost, tt.path, bytes.NewBuffer(tt.reqInput))
+		c.NoError(err)
+
+		rr := httptest.NewRecorder()
+
+		router.router.ServeHTTP(rr, req)
+		c.Equal(http.StatusServiceUnavailable, rr.Code, tt.name)
+		c.Equal("30", rr.Header().Get("Retry-After"), tt.name)
+		c.Contains(rr.Body.String(), tt.expectedMessage, tt.name)
+	}
+
+	c.Equal(1, relayBatch.Size())
+	c.Equal(1, relayBatch.ChanSize())
+	relayMock.AssertNotCalled(t, "WriteRelays", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,179 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	jsonresponse "github.com/pokt-foundation/utils-go/json-response"
+	"go.uber.org/zap"
+)
+
+const (
+	pprofPath        = "/debug/pprof/"
+	runtimeStatsPath = "/debug/runtime"
+)
+
+// DiagnosticsConfig enables the pprof and runtime stats endpoints, which are disabled by default
+type DiagnosticsConfig struct {
+	Enabled bool
+	// Addr serves the endpoints on a listener of their own without API keys, such as
+	// localhost:6060. When empty they are served under the admin routes instead.
+	Addr string
+}
+
+// RuntimeStats is the response of the runtime stats endpoint
+type RuntimeStats struct {
+	Goroutines int     `json:"goroutines"`
+	GoMaxProcs int     `json:"goMaxProcs"`
+	Heap       Heap    `json:"heap"`
+	GC         GCStats `json:"gc"`
+}
+
+// Heap holds the size of the heap in bytes, and its number of objects
+type Heap struct {
+	Alloc       uint64 `json:"alloc"`
+	InUse       uint64 `json:"inUse"`
+	Idle        uint64 `json:"idle"`
+	Released    uint64 `json:"released"`
+	Sys         uint64 `json:"sys"`
+	Objects     uint64 `json:"objects"`
+	TotalAlloc  uint64 `json:"totalAlloc"`
+	NextGCAlloc uint64 `json:"nextGCAlloc"`
+}
+
+// GCStats holds the activity of the garbage collector
+type GCStats struct {
+	Runs        uint32     `json:"runs"`
+	LastRun     *time.Time `json:"lastRun,omitempty"`
+	LastPause   string     `json:"lastPause"`
+	TotalPause  string     `json:"totalPause"`
+	CPUFraction float64    `json:"cpuFraction"`
+}
+
+// WithDiagnostics enables the pprof and runtime stats endpoints
+func WithDiagnostics(config DiagnosticsConfig) Option {
+	return func(rt *Router) {
+		rt.diagnosticsConfig = config
+	}
+}
+
+// diagnosticsHandler serves the pprof endpoints under /debug/pprof/ and the runtime stats at /debug/runtime
+func diagnosticsHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(pprofPath, pprof.Index)
+	mux.HandleFunc(pprofPath+"cmdline", pprof.Cmdline)
+	mux.HandleFunc(pprofPath+"profile", pprof.Profile)
+	mux.HandleFunc(pprofPath+"symbol", pprof.Symbol)
+	mux.HandleFunc(pprofPath+"trace", pprof.Trace)
+	mux.HandleFunc(runtimeStatsPath, GetRuntimeStats)
+
+	return mux
+}
+
+// handleAdminDiagnostics serves the diagnostics endpoints under the admin routes,
+// /v0/admin/debug/pprof/ and /v0/admin/debug/runtime
+func (rt *Router) handleAdminDiagnostics() {
+	// pprof only serves the profiles under /debug/pprof/
+	handler := http.StripPrefix(strings.TrimSuffix(adminPathPrefix, "/"), diagnosticsHandler())
+
+	pprofRoute := adminPathPrefix + strings.TrimPrefix(pprofPath, "/")
+
+	rt.router.Handle(pprofRoute, handler).Methods(http.MethodGet)
+	rt.router.Handle(pprofRoute+"{profile}", rt.extendWriteDeadline(handler)).Methods(http.MethodGet)
+	rt.router.Handle(pprofRoute+"symbol", handler).Methods(http.MethodPost)
+	rt.router.Handle(adminPathPrefix+strings.TrimPrefix(runtimeStatsPath, "/"), handler).Methods(http.MethodGet)
+}
+
+// profileSeconds returns how long the profile of the request runs, pprof collecting CPU
+// profiles for 30 seconds and traces for 1 second by default
+func profileSeconds(r *http.Request) float64 {
+	if seconds, err := strconv.ParseFloat(r.FormValue("seconds"), 64); err == nil && seconds > 0 {
+		return seconds
+	}
+
+	switch mux.Vars(r)["profile"] {
+	case "profile":
+		return 30
+	case "trace":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// extendWriteDeadline lets the CPU profiles, traces and delta profiles run for as long as
+// asked on the main port, moving the write deadline of the request past their duration
+func (rt *Router) extendWriteDeadline(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seconds := profileSeconds(r)
+		if seconds == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if err := rt.delayWriteDeadline(w, time.Duration(seconds*float64(time.Second))); err != nil {
+			rt.logError(r.Context(), fmt.Errorf("extending the write deadline of the profile failed: %w", err))
+			jsonresponse.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		// pprof refuses durations over the write timeout of the server, which no longer applies
+		ctx := context.WithValue(r.Context(), http.ServerContextKey, nil)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// newDiagnosticsServer returns the server of the diagnostics endpoints on their own listener.
+// It has no write timeout so CPU profiles and traces can run for as long as asked.
+func (rt *Router) newDiagnosticsServer() *http.Server {
+	return &http.Server{
+		Addr:              rt.diagnosticsConfig.Addr,
+		Handler:           diagnosticsHandler(),
+		ReadHeaderTimeout: rt.serverConfig.ReadHeaderTimeout,
+		ErrorLog:          zap.NewStdLog(rt.log),
+	}
+}
+
+// GetRuntimeStats returns the number of goroutines and the state of the heap and garbage collector
+func GetRuntimeStats(w http.ResponseWriter, r *http.Request) {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	stats := RuntimeStats{
+		Goroutines: runtime.NumGoroutine(),
+		GoMaxProcs: runtime.GOMAXPROCS(0),
+		Heap: Heap{
+			Alloc:       memStats.HeapAlloc,
+			InUse:       memStats.HeapInuse,
+			Idle:        memStats.HeapIdle,
+			Released:    memStats.HeapReleased,
+			Sys:         memStats.HeapSys,
+			Objects:     memStats.HeapObjects,
+			TotalAlloc:  memStats.TotalAlloc,
+			NextGCAlloc: memStats.NextGC,
+		},
+		GC: GCStats{
+			Runs:        memStats.NumGC,
+			TotalPause:  time.Duration(memStats.PauseTotalNs).String(),
+			CPUFraction: memStats.GCCPUFraction,
+			LastPause:   time.Duration(0).String(),
+		},
+	}
+
+	if memStats.NumGC > 0 {
+		lastRun := time.Unix(0, int64(memStats.LastGC))
+		stats.GC.LastRun = &lastRun
+		stats.GC.LastPause = time.Duration(memStats.PauseNs[(memStats.NumGC+255)%256]).String()
+	}
+
+	jsonresponse.RespondWithJSON(w, http.StatusOK, stats)
+}
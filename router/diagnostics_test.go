@@ -0,0 +1,187 @@
+package router
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRouter_Diagnostics(t *testing.T) {
+	c := require.New(t)
+
+	newRouter := func(config DiagnosticsConfig) *Router {
+		router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"gateway-key": true}, "8080", nil, nil, zap.NewNop(),
+			WithAdminKeys(map[string]bool{"admin-key": true}),
+			WithDiagnostics(config),
+		)
+		c.NoError(err)
+
+		return router
+	}
+
+	tests := []struct {
+		name               string
+		config             DiagnosticsConfig
+		path               string
+		apiKey             string
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:               "Disabled",
+			path:               "/v0/admin/debug/pprof/",
+			apiKey:             "admin-key",
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "Non admin key",
+			config:             DiagnosticsConfig{Enabled: true},
+			path:               "/v0/admin/debug/pprof/",
+			apiKey:             "gateway-key",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Profiles index",
+			config:             DiagnosticsConfig{Enabled: true},
+			path:               "/v0/admin/debug/pprof/",
+			apiKey:             "admin-key",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "goroutine",
+		},
+		{
+			name:               "Goroutine profile",
+			config:             DiagnosticsConfig{Enabled: true},
+			path:               "/v0/admin/debug/pprof/goroutine?debug=1",
+			apiKey:             "admin-key",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "goroutine profile",
+		},
+		{
+			name:               "Runtime stats",
+			config:             DiagnosticsConfig{Enabled: true},
+			path:               "/v0/admin/debug/runtime",
+			apiKey:             "admin-key",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `"goroutines"`,
+		},
+		{
+			name:               "Served on their own address",
+			config:             DiagnosticsConfig{Enabled: true, Addr: "localhost:6060"},
+			path:               "/v0/admin/debug/runtime",
+			apiKey:             "admin-key",
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		router := newRouter(tt.config)
+
+		req, err := http.NewRequest(http.MethodGet, tt.path, nil)
+		c.NoError(err)
+
+		req.Header.Set("Authorization", tt.apiKey)
+		rr := httptest.NewRecorder()
+
+		router.router.ServeHTTP(rr, req)
+		c.Equal(tt.expectedStatusCode, rr.Code, tt.name)
+		c.Contains(rr.Body.String(), tt.expectedBody, tt.name)
+	}
+}
+
+func TestDiagnosticsHandler(t *testing.T) {
+	c := require.New(t)
+
+	server := httptest.NewServer(diagnosticsHandler())
+	defer server.Close()
+
+	// No API key is needed on the address of their own
+	resp, err := http.Get(server.URL + "/debug/pprof/cmdline")
+	c.NoError(err)
+	resp.Body.Close()
+	c.Equal(http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/debug/runtime")
+	c.NoError(err)
+	defer resp.Body.Close()
+	c.Equal(http.StatusOK, resp.StatusCode)
+
+	var stats RuntimeStats
+	c.NoError(json.NewDecoder(resp.Body).Decode(&stats))
+	c.Positive(stats.Goroutines)
+	c.Positive(stats.GoMaxProcs)
+	c.Positive(stats.Heap.Alloc)
+	c.Positive(stats.Heap.Sys)
+}
+
+func TestRouter_Diagnostics_writeTimeout(t *testing.T) {
+	c := require.New(t)
+
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"gateway-key": true}, "8080", nil, nil, zap.NewNop(),
+		WithAdminKeys(map[string]bool{"admin-key": true}),
+		WithDiagnostics(DiagnosticsConfig{Enabled: true}),
+		WithServerConfig(ServerConfig{WriteTimeout: time.Second}),
+	)
+	c.NoError(err)
+
+	server := httptest.NewUnstartedServer(router.router)
+	server.Config.WriteTimeout = time.Second
+	server.Start()
+	defer server.Close()
+
+	// The CPU profile runs for as long as the write timeout of the server
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/v0/admin/debug/pprof/profile?seconds=1", nil)
+	c.NoError(err)
+
+	req.Header.Set("Authorization", "admin-key")
+
+	resp, err := http.DefaultClient.Do(req)
+	c.NoError(err)
+	defer resp.Body.Close()
+	c.Equal(http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	c.NoError(err)
+	c.NotEmpty(body)
+}
+
+func TestRouter_extendWriteDeadline(t *testing.T) {
+	c := require.New(t)
+
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"gateway-key": true}, "8080", nil, nil, zap.NewNop(),
+		WithServerConfig(ServerConfig{WriteTimeout: 100 * time.Millisecond}),
+	)
+	c.NoError(err)
+
+	// The profile writes once it is over, past the write timeout of the server
+	servers := make(chan any, 1)
+	profile := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		servers <- r.Context().Value(http.ServerContextKey)
+
+		time.Sleep(300 * time.Millisecond)
+		_, _ = w.Write([]byte("profile"))
+	})
+
+	server := httptest.NewUnstartedServer(router.extendWriteDeadline(profile))
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/debug/pprof/profile?seconds=0.3")
+	c.NoError(err)
+	defer resp.Body.Close()
+	c.Equal(http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	c.NoError(err)
+	c.Equal("profile", string(body))
+
+	// pprof no longer compares the duration with the write timeout of the server
+	c.Nil(<-servers)
+}
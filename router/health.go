@@ -0,0 +1,190 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	jsonresponse "github.com/pokt-foundation/utils-go/json-response"
+)
+
+const (
+	checkStatusOK     = "ok"
+	checkStatusFailed = "failed"
+
+	readinessStatusReady    = "ready"
+	readinessStatusNotReady = "not_ready"
+
+	defaultDBPingTimeout   = 2 * time.Second
+	defaultMaxBacklogRatio = 0.9
+)
+
+// Pinger checks the connection to a dependency
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// ReadinessConfig holds the thresholds of the readiness checks, a zero value
+// falling back to its default
+type ReadinessConfig struct {
+	// DBPingTimeout is how long the database has to answer a ping
+	DBPingTimeout time.Duration
+	// MaxBacklogRatio is the fraction of a batch channel capacity that can be in use
+	MaxBacklogRatio float64
+	// MaxSaveAge is how long a batch can go without a successful save, disabled if zero
+	MaxSaveAge time.Duration
+	// DrainDelay is how long the server keeps serving once shutting down, with the readiness
+	// check failing, so load balancers stop routing to it before its listener closes
+	DrainDelay time.Duration
+}
+
+// CheckResult is the outcome of a single readiness check
+type CheckResult struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// ReadinessReport is the response of the readiness endpoint
+type ReadinessReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// batchStatus is the part of a batch the readiness checks look at
+type batchStatus interface {
+	Name() string
+	ChanSize() int
+	ChanCapacity() int
+	LastSave() time.Time
+	Paused() bool
+}
+
+// WithReadiness enables the database readiness check and sets the readiness thresholds
+func WithReadiness(pinger Pinger, config ReadinessConfig) Option {
+	return func(rt *Router) {
+		rt.pinger = pinger
+
+		if config.DBPingTimeout > 0 {
+			rt.readinessConfig.DBPingTimeout = config.DBPingTimeout
+		}
+		if config.MaxBacklogRatio > 0 {
+			rt.readinessConfig.MaxBacklogRatio = config.MaxBacklogRatio
+		}
+
+		rt.readinessConfig.MaxSaveAge = config.MaxSaveAge
+		rt.readinessConfig.DrainDelay = config.DrainDelay
+	}
+}
+
+// WithSchemaCheck adds the outcome of the startup schema check to the readiness checks,
+// keeping the service from taking traffic when the database schema is not supported
+func WithSchemaCheck(err error) Option {
+	return func(rt *Router) {
+		rt.SetSchemaCheck(err)
+	}
+}
+
+// SetSchemaCheck replaces the outcome of the schema check, for a check retried after startup
+func (rt *Router) SetSchemaCheck(err error) {
+	result := checkOK("")
+	if err != nil {
+		result = checkFailed(err.Error())
+	}
+
+	rt.schemaCheck.Store(&result)
+}
+
+func checkOK(message string) CheckResult {
+	return CheckResult{Status: checkStatusOK, Message: message}
+}
+
+func checkFailed(message string) CheckResult {
+	return CheckResult{Status: checkStatusFailed, Message: message}
+}
+
+func (rt *Router) checkDB(ctx context.Context) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, rt.readinessConfig.DBPingTimeout)
+	defer cancel()
+
+	start := time.Now()
+	if err := rt.pinger.Ping(ctx); err != nil {
+		return checkFailed(fmt.Sprintf("ping failed: %s", err))
+	}
+
+	return checkOK(fmt.Sprintf("ping took %s", time.Since(start).Round(time.Millisecond)))
+}
+
+func (rt *Router) checkBacklog(b batchStatus) CheckResult {
+	size, capacity := b.ChanSize(), b.ChanCapacity()
+	message := fmt.Sprintf("%d/%d items waiting in channel", size, capacity)
+
+	if capacity > 0 && float64(size)/float64(capacity) > rt.readinessConfig.MaxBacklogRatio {
+		return checkFailed(message)
+	}
+
+	return checkOK(message)
+}
+
+func (rt *Router) checkSaveAge(b batchStatus) CheckResult {
+	age := time.Since(b.LastSave())
+	message := fmt.Sprintf("last successful save %s ago", age.Round(time.Second))
+
+	// A paused batch is not saved on purpose, its backlog check still failing once it is full
+	if b.Paused() {
+		return checkOK("paused, " + message)
+	}
+
+	if age > rt.readinessConfig.MaxSaveAge {
+		return checkFailed(message)
+	}
+
+	return checkOK(message)
+}
+
+// Livez reports the process is running and able to serve requests
+func (rt *Router) Livez(w http.ResponseWriter, r *http.Request) {
+	jsonresponse.RespondWithJSON(w, http.StatusOK, map[string]string{"status": checkStatusOK})
+}
+
+// Readyz reports whether the service can take traffic, responding with 503
+// and the breakdown of the checks when any of them fails
+func (rt *Router) Readyz(w http.ResponseWriter, r *http.Request) {
+	checks := make(map[string]CheckResult)
+
+	if rt.shuttingDown.Load() {
+		checks["shutdown"] = checkFailed("server is shutting down")
+	} else {
+		checks["shutdown"] = checkOK("")
+	}
+
+	if rt.pinger != nil {
+		checks["database"] = rt.checkDB(r.Context())
+	}
+
+	if schemaCheck := rt.schemaCheck.Load(); schemaCheck != nil {
+		checks["schema"] = *schemaCheck
+	}
+
+	for _, b := range []batchStatus{rt.relayBatch, rt.serviceRecordBatch} {
+		checks[b.Name()+"_batch_backlog"] = rt.checkBacklog(b)
+
+		if rt.readinessConfig.MaxSaveAge > 0 {
+			checks[b.Name()+"_batch_save"] = rt.checkSaveAge(b)
+		}
+	}
+
+	report := ReadinessReport{Status: readinessStatusReady, Checks: checks}
+	statusCode := http.StatusOK
+
+	for _, check := range checks {
+		if check.Status != checkStatusOK {
+			report.Status = readinessStatusNotReady
+			statusCode = http.StatusServiceUnavailable
+
+			break
+		}
+	}
+
+	jsonresponse.RespondWithJSON(w, statusCode, report)
+}
@@ -0,0 +1,281 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/pokt-foundation/transaction-http-db/batch"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	mock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type pingerFunc func(ctx context.Context) error
+
+func (f pingerFunc) Ping(ctx context.Context) error {
+	return f(ctx)
+}
+
+func TestRouter_Livez(t *testing.T) {
+	c := require.New(t)
+
+	relayWriterMock := &batch.MockRelayWriter{}
+	relayBatch := batch.NewBatch(2, 21, "relay", time.Hour, time.Hour, relayWriterMock.WriteRelays, zap.NewNop())
+
+	serviceRecordMock := &batch.MockServiceRecordWriter{}
+	serviceRecordBatch := batch.NewBatch(2, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
+
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"key": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop())
+	c.NoError(err)
+
+	req, err := http.NewRequest(http.MethodGet, "/livez", nil)
+	c.NoError(err)
+
+	rr := httptest.NewRecorder()
+	router.router.ServeHTTP(rr, req)
+	c.Equal(http.StatusOK, rr.Code)
+	c.Equal(`{"status":"ok"}`, rr.Body.String())
+}
+
+func TestRouter_Readyz(t *testing.T) {
+	c := require.New(t)
+
+	tests := []struct {
+		name               string
+		pingErr            error
+		pingDelay          time.Duration
+		fillBacklog        bool
+		maxSaveAge         time.Duration
+		pause              bool
+		shuttingDown       bool
+		schemaErr          error
+		expectedStatusCode int
+		expectedFailed     []string
+	}{
+		{
+			name:               "Ready",
+			maxSaveAge:         time.Hour,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Database down",
+			pingErr:            errors.New("dummy"),
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedFailed:     []string{"database"},
+		},
+		{
+			name:               "Database ping timeout",
+			pingDelay:          time.Second,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedFailed:     []string{"database"},
+		},
+		{
+			name:               "Backlog full",
+			fillBacklog:        true,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedFailed:     []string{"relay_batch_backlog"},
+		},
+		{
+			name:               "Save too old",
+			maxSaveAge:         time.Nanosecond,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedFailed:     []string{"relay_batch_save", "service_record_batch_save"},
+		},
+		{
+			name:               "Save too old while paused",
+			maxSaveAge:         time.Nanosecond,
+			pause:              true,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedFailed:     []string{"relay_batch_save"},
+		},
+		{
+			name:               "Incompatible schema",
+			schemaErr:          storage.ErrIncompatibleSchema,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedFailed:     []string{"schema"},
+		},
+		{
+			name:               "Shutting down",
+			shuttingDown:       true,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedFailed:     []string{"shutdown"},
+		},
+	}
+
+	for _, tt := range tests {
+		relayWriterMock := &batch.MockRelayWriter{}
+		relayBatch := batch.NewBatch(1, 2, "relay", time.Hour, time.Hour, relayWriterMock.WriteRelays, zap.NewNop())
+
+		serviceRecordMock := &batch.MockServiceRecordWriter{}
+		serviceRecordBatch := batch.NewBatch(2, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
+
+		pinger := pingerFunc(func(ctx context.Context) error {
+			select {
+			case <-time.After(tt.pingDelay):
+				return tt.pingErr
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+
+		router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"key": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop(),
+			WithReadiness(pinger, ReadinessConfig{DBPingTimeout: 50 * time.Millisecond, MaxSaveAge: tt.maxSaveAge}),
+			WithSchemaCheck(tt.schemaErr),
+		)
+		c.NoError(err)
+
+		if tt.fillBacklog {
+			// The writer blocks the batcher on the first relay so the rest pile up in the channel
+			unblock := make(chan struct{})
+			defer close(unblock)
+
+			relayWriterMock.On("WriteRelays", mock.Anything, mock.Anything).Run(func(_ mock.Arguments) { <-unblock }).Return(nil)
+			for i := 0; i < 3; i++ {
+				c.NoError(relayBatch.Add(context.Background(), &types.Relay{
+					PoktChainID:              "21",
+					EndpointID:               "21",
+					SessionKey:               "21",
+					ProtocolAppPublicKey:     "21",
+					RelaySourceURL:           "pablo.com",
+					PoktNodeAddress:          "21",
+					PoktNodeDomain:           "pablos.com",
+					PoktNodePublicKey:        "aaa",
+					RelayStartDatetime:       time.Now(),
+					RelayReturnDatetime:      time.Now(),
+					RelayRoundtripTime:       1,
+					RelayChainMethodIDs:      []string{"get_height"},
+					RelayDataSize:            21,
+					RelayPortalTripTime:      21,
+					RelayNodeTripTime:        21,
+					RelayURLIsPublicEndpoint: false,
+					PortalRegionName:         "La Colombia",
+					RequestID:                "21",
+					PoktTxID:                 "21",
+				}))
+			}
+		}
+
+		if tt.pause {
+			serviceRecordBatch.Pause()
+		}
+
+		router.shuttingDown.Store(tt.shuttingDown)
+
+		req, err := http.NewRequest(http.MethodGet, "/readyz", nil)
+		c.NoError(err)
+
+		rr := httptest.NewRecorder()
+		router.router.ServeHTTP(rr, req)
+		c.Equal(tt.expectedStatusCode, rr.Code, tt.name)
+
+		var report ReadinessReport
+		c.NoError(json.Unmarshal(rr.Body.Bytes(), &report))
+
+		var failed []string
+		for name, check := range report.Checks {
+			if check.Status == checkStatusFailed {
+				failed = append(failed, name)
+			}
+		}
+		c.ElementsMatch(tt.expectedFailed, failed, tt.name)
+	}
+}
+
+func TestRouter_SetSchemaCheck(t *testing.T) {
+	c := require.New(t)
+
+	relayBatch := batch.NewBatch(2, 21, "relay", time.Hour, time.Hour, (&batch.MockRelayWriter{}).WriteRelays, zap.NewNop())
+	serviceRecordBatch := batch.NewBatch(2, 21, "service_record", time.Hour, time.Hour, (&batch.MockServiceRecordWriter{}).WriteServiceRecords, zap.NewNop())
+
+	// The schema is not ready until a check retried after startup succeeds
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"key": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop(),
+		WithSchemaCheck(errors.New("database schema not checked yet")),
+	)
+	c.NoError(err)
+
+	readyz := func() int {
+		rr := httptest.NewRecorder()
+		router.router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return rr.Code
+	}
+
+	c.Equal(http.StatusServiceUnavailable, readyz())
+
+	router.SetSchemaCheck(nil)
+	c.Equal(http.StatusOK, readyz())
+}
+
+func TestRouter_RunServer_drainDelay(t *testing.T) {
+	c := require.New(t)
+
+	listener, err := net.Listen("tcp", "localhost:0")
+	c.NoError(err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	c.NoError(listener.Close())
+
+	relayBatch := batch.NewBatch(2, 21, "relay", time.Hour, time.Hour, (&batch.MockRelayWriter{}).WriteRelays, zap.NewNop())
+	serviceRecordBatch := batch.NewBatch(2, 21, "service_record", time.Hour, time.Hour,
+		(&batch.MockServiceRecordWriter{}).WriteServiceRecords, zap.NewNop())
+
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"key": true}, fmt.Sprint(port), relayBatch, serviceRecordBatch, zap.NewNop(),
+		WithReadiness(pingerFunc(func(ctx context.Context) error { return nil }), ReadinessConfig{DrainDelay: time.Second}),
+	)
+	c.NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		router.RunServer(ctx)
+		close(done)
+	}()
+
+	readyz := func() (int, error) {
+		resp, err := http.Get(fmt.Sprintf("http://localhost:%d/readyz", port))
+		if err != nil {
+			return 0, err
+		}
+		defer resp.Body.Close()
+
+		return resp.StatusCode, nil
+	}
+
+	c.Eventually(func() bool {
+		status, err := readyz()
+		return err == nil && status == http.StatusOK
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+
+	// The server keeps serving while draining, reporting it is not ready
+	c.Eventually(func() bool {
+		status, err := readyz()
+		return err == nil && status == http.StatusServiceUnavailable
+	}, 500*time.Millisecond, 10*time.Millisecond)
+
+	select {
+	case <-done:
+		c.Fail("server closed before the drain delay")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	c.Eventually(func() bool {
+		select {
+		case <-done:
+			return true
+		default:
+			return false
+		}
+	}, 2*time.Second, 10*time.Millisecond)
+
+	_, err = readyz()
+	c.Error(err)
+}
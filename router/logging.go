@@ -0,0 +1,157 @@
+package router
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	// maxRequestIDLength bounds the size of request IDs taken from clients
+	maxRequestIDLength = 128
+)
+
+// requestInfo is shared by the middlewares and handlers of a single request
+// so the access log can report what happened down the chain
+type requestInfo struct {
+	id    string
+	label string
+	items int
+	log   *zap.Logger
+}
+
+func requestInfoFromContext(ctx context.Context) *requestInfo {
+	info, _ := ctx.Value(requestInfoCtxKey).(*requestInfo)
+	return info
+}
+
+// logger returns the logger of the request in ctx, which carries its request ID
+func (rt *Router) logger(ctx context.Context) *zap.Logger {
+	if info := requestInfoFromContext(ctx); info != nil {
+		return info.log
+	}
+
+	return rt.log
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(id)
+}
+
+// validRequestID only accepts printable ASCII IDs so clients can't inject content in the logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if rr.status == 0 {
+		rr.status = status
+	}
+
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(p []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+
+	n, err := rr.ResponseWriter.Write(p)
+	rr.bytes += n
+
+	return n, err
+}
+
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
+
+// RequestLogHandler takes the request ID from the X-Request-ID header or generates one,
+// attaches a logger carrying it and the trace ID to the request and writes an access log
+// line once the request is served or aborted
+func (rt *Router) RequestLogHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+
+		span := trace.SpanFromContext(r.Context())
+
+		log := rt.log.With(zap.String("request_id", id))
+		if spanContext := span.SpanContext(); spanContext.IsValid() {
+			log = log.With(zap.String("trace_id", spanContext.TraceID().String()))
+		}
+
+		info := &requestInfo{
+			id:  id,
+			log: log,
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w}
+
+		// Deferred so a response aborted with http.ErrAbortHandler is logged too, the panic
+		// being passed on to the server once it is
+		defer func() {
+			recovered := recover()
+
+			if recorder.status == 0 {
+				recorder.status = http.StatusOK
+			}
+
+			setSpanAttributes(span, info)
+
+			fields := []zap.Field{
+				zap.String("method", r.Method),
+				zap.String("route", routeTemplate(r)),
+				zap.Int("status", recorder.status),
+				zap.Duration("latency", time.Since(start)),
+				zap.Int64("request_bytes", r.ContentLength),
+				zap.Int("response_bytes", recorder.bytes),
+				zap.String("key_label", info.label),
+				zap.Int("items", info.items),
+			}
+
+			if recovered != nil {
+				info.log.Warn("request aborted", append(fields, zap.String("err", fmt.Sprint(recovered)))...)
+				panic(recovered)
+			}
+
+			info.log.Info("request served", fields...)
+		}()
+
+		h.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), requestInfoCtxKey, info)))
+	})
+}
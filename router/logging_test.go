@@ -0,0 +1,125 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/pokt-foundation/transaction-http-db/batch"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRouter_RequestLogHandler(t *testing.T) {
+	c := require.New(t)
+
+	relayWriterMock := &batch.MockRelayWriter{}
+	relayBatch := batch.NewBatch(21, 21, "relay", time.Hour, time.Hour, relayWriterMock.WriteRelays, zap.NewNop())
+
+	serviceRecordMock := &batch.MockServiceRecordWriter{}
+	serviceRecordBatch := batch.NewBatch(21, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
+
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"gateway-key": true}, "8080", relayBatch, serviceRecordBatch, zap.New(core),
+		WithKeyLabels(map[string]string{"gateway-key": "gateway"}),
+	)
+	c.NoError(err)
+
+	relaysToSend, err := json.Marshal([]*types.Relay{{}, {}})
+	c.NoError(err)
+
+	tests := []struct {
+		name               string
+		requestID          string
+		expectedRequestID  string
+		expectedStatusCode int
+	}{
+		{
+			name:               "Request ID from client",
+			requestID:          "gateway-21",
+			expectedRequestID:  "gateway-21",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Generated request ID",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Invalid request ID from client",
+			requestID:          "gateway 21\n",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		logs.TakeAll()
+
+		req, err := http.NewRequest(http.MethodPost, "/v0/relays", bytes.NewBuffer(relaysToSend))
+		c.NoError(err)
+
+		req.Header.Set("Authorization", "gateway-key")
+		req.Header.Set(requestIDHeader, tt.requestID)
+		rr := httptest.NewRecorder()
+
+		router.router.ServeHTTP(rr, req)
+		c.Equal(tt.expectedStatusCode, rr.Code, tt.name)
+
+		requestID := rr.Header().Get(requestIDHeader)
+		if tt.expectedRequestID != "" {
+			c.Equal(tt.expectedRequestID, requestID, tt.name)
+		} else {
+			c.Len(requestID, 32, tt.name)
+		}
+
+		// Every line logged while serving the request carries its ID
+		entries := logs.AllUntimed()
+		c.NotEmpty(entries, tt.name)
+		for _, entry := range entries {
+			c.Equal(requestID, entry.ContextMap()["request_id"], tt.name)
+		}
+
+		accessLog := entries[len(entries)-1].ContextMap()
+		c.Equal("/v0/relays", accessLog["route"], tt.name)
+		c.Equal(int64(http.StatusBadRequest), accessLog["status"], tt.name)
+		c.Equal("gateway", accessLog["key_label"], tt.name)
+		c.Equal(int64(2), accessLog["items"], tt.name)
+		c.Equal(int64(rr.Body.Len()), accessLog["response_bytes"], tt.name)
+	}
+}
+
+func TestRouter_RequestLogHandler_aborted(t *testing.T) {
+	c := require.New(t)
+
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"": true}, "8080", nil, nil, zap.New(core))
+	c.NoError(err)
+
+	router.router.HandleFunc("/v0/panic-after-write", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		panic("dummy")
+	})
+
+	req, err := http.NewRequest(http.MethodGet, "/v0/panic-after-write", nil)
+	c.NoError(err)
+
+	rr := httptest.NewRecorder()
+	c.PanicsWithValue(http.ErrAbortHandler, func() { router.router.ServeHTTP(rr, req) })
+
+	entries := logs.FilterMessage("request aborted").AllUntimed()
+	c.Len(entries, 1)
+
+	accessLog := entries[0].ContextMap()
+	c.Equal("/v0/panic-after-write", accessLog["route"])
+	c.Equal(int64(http.StatusOK), accessLog["status"])
+	c.Equal(http.ErrAbortHandler.Error(), accessLog["err"])
+	c.Empty(logs.FilterMessage("request served").AllUntimed())
+}
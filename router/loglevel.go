@@ -0,0 +1,183 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	jsonresponse "github.com/pokt-foundation/utils-go/json-response"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LogLevel changes the level of the logs at runtime. A temporary level reverts to the base
+// level once its duration is over.
+type LogLevel struct {
+	level zap.AtomicLevel
+	log   *zap.Logger
+
+	mu   sync.Mutex
+	base zapcore.Level
+	// revertAt is the time a temporary level reverts at, zero when there is none
+	revertAt time.Time
+	timer    *time.Timer
+	// generation counts the temporary levels set, telling a timer whether its level is still the current one
+	generation uint64
+}
+
+// LogLevelStatus is the current level of the logs, and the base one it reverts to if temporary
+type LogLevelStatus struct {
+	Level     string     `json:"level"`
+	BaseLevel string     `json:"baseLevel"`
+	RevertAt  *time.Time `json:"revertAt,omitempty"`
+}
+
+// LogLevelChange is the body of the log level endpoint. Duration is a Go duration such
+// as "15m", the level being temporary when it is set.
+type LogLevelChange struct {
+	Level    string `json:"level"`
+	Duration string `json:"duration,omitempty"`
+}
+
+// NewLogLevel returns a LogLevel changing level, its current level being the base one
+func NewLogLevel(level zap.AtomicLevel, logger *zap.Logger) *LogLevel {
+	return &LogLevel{
+		level: level,
+		log:   logger,
+		base:  level.Level(),
+	}
+}
+
+// WithLogLevel enables changing the level of the logs from the admin routes
+func WithLogLevel(logLevel *LogLevel) Option {
+	return func(rt *Router) {
+		rt.logLevel = logLevel
+	}
+}
+
+// SetBase sets the base level, applied right away unless a temporary level is set
+func (l *LogLevel) SetBase(level zapcore.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.base = level
+
+	if l.timer == nil {
+		l.level.SetLevel(level)
+	}
+}
+
+// Set sets the level of the logs. When duration is positive, the level reverts to the base
+// level once it is over, otherwise it becomes the base level. Either way it replaces the
+// temporary level set before, if any.
+func (l *LogLevel) Set(level zapcore.Level, duration time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer, l.revertAt = nil, time.Time{}
+	}
+
+	l.level.SetLevel(level)
+
+	if duration <= 0 {
+		l.base = level
+		return
+	}
+
+	// The timer may fire before AfterFunc returns, revert waiting for the lock held here
+	l.generation++
+	generation := l.generation
+
+	l.timer = time.AfterFunc(duration, func() { l.revert(generation) })
+	l.revertAt = time.Now().Add(duration)
+}
+
+// revert sets the base level back if generation is the one of the current temporary level
+func (l *LogLevel) revert(generation uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.timer == nil || l.generation != generation {
+		return
+	}
+
+	l.log.Info(fmt.Sprintf("temporary log level %s reverted to %s", l.level.Level(), l.base))
+
+	l.level.SetLevel(l.base)
+	l.timer, l.revertAt = nil, time.Time{}
+}
+
+// Status returns the current level of the logs
+func (l *LogLevel) Status() LogLevelStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	status := LogLevelStatus{
+		Level:     l.level.Level().String(),
+		BaseLevel: l.base.String(),
+	}
+
+	if !l.revertAt.IsZero() {
+		revertAt := l.revertAt
+		status.RevertAt = &revertAt
+	}
+
+	return status
+}
+
+// GetLogLevel returns the current level of the logs
+func (rt *Router) GetLogLevel(w http.ResponseWriter, r *http.Request) {
+	if rt.logLevel == nil {
+		jsonresponse.RespondWithError(w, http.StatusNotImplemented, "changing the log level is not enabled")
+		return
+	}
+
+	jsonresponse.RespondWithJSON(w, http.StatusOK, rt.logLevel.Status())
+}
+
+// SetLogLevel changes the level of the logs, for a while if a duration is given,
+// responding with the new level
+func (rt *Router) SetLogLevel(w http.ResponseWriter, r *http.Request) {
+	if rt.logLevel == nil {
+		jsonresponse.RespondWithError(w, http.StatusNotImplemented, "changing the log level is not enabled")
+		return
+	}
+
+	var change LogLevelChange
+	if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+		jsonresponse.RespondWithError(w, decodeErrorStatus(err), err.Error())
+		return
+	}
+
+	defer r.Body.Close()
+
+	level, err := zapcore.ParseLevel(change.Level)
+	if err != nil {
+		jsonresponse.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var duration time.Duration
+	if change.Duration != "" {
+		if duration, err = time.ParseDuration(change.Duration); err != nil || duration <= 0 {
+			jsonresponse.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid duration %q, must be positive such as 15m", change.Duration))
+			return
+		}
+	}
+
+	// Logged before the change so it shows up whatever the new level
+	message := fmt.Sprintf("log level set to %s", level)
+	if duration > 0 {
+		message += fmt.Sprintf(" for %s", duration)
+	}
+
+	rt.logger(r.Context()).Warn(message)
+
+	rt.logLevel.Set(level, duration)
+
+	jsonresponse.RespondWithJSON(w, http.StatusOK, rt.logLevel.Status())
+}
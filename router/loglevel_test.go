@@ -0,0 +1,148 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-http-db/batch"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLogLevel(t *testing.T) {
+	c := require.New(t)
+
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	logLevel := NewLogLevel(level, zap.NewNop())
+
+	// A temporary level reverts to the base level
+	logLevel.Set(zapcore.DebugLevel, 50*time.Millisecond)
+	c.Equal(zapcore.DebugLevel, level.Level())
+	c.NotNil(logLevel.Status().RevertAt)
+
+	// The base level changes without replacing the temporary level
+	logLevel.SetBase(zapcore.WarnLevel)
+	c.Equal(zapcore.DebugLevel, level.Level())
+
+	c.Eventually(func() bool { return level.Level() == zapcore.WarnLevel }, time.Second, 10*time.Millisecond)
+	c.Equal(LogLevelStatus{Level: "warn", BaseLevel: "warn"}, logLevel.Status())
+
+	// A level set without duration becomes the base level, cancelling the temporary level
+	logLevel.Set(zapcore.DebugLevel, 50*time.Millisecond)
+	logLevel.Set(zapcore.ErrorLevel, 0)
+
+	time.Sleep(100 * time.Millisecond)
+	c.Equal(LogLevelStatus{Level: "error", BaseLevel: "error"}, logLevel.Status())
+
+	logLevel.SetBase(zapcore.InfoLevel)
+	c.Equal(zapcore.InfoLevel, level.Level())
+
+	// A level so short it is over before Set returns still reverts
+	logLevel.Set(zapcore.DebugLevel, time.Nanosecond)
+	c.Eventually(func() bool { return level.Level() == zapcore.InfoLevel }, time.Second, 10*time.Millisecond)
+	c.Equal(LogLevelStatus{Level: "info", BaseLevel: "info"}, logLevel.Status())
+}
+
+func TestRouter_LogLevel(t *testing.T) {
+	c := require.New(t)
+
+	relayWriterMock := &batch.MockRelayWriter{}
+	relayBatch := batch.NewBatch(21, 21, "relay", time.Hour, time.Hour, relayWriterMock.WriteRelays, zap.NewNop())
+
+	serviceRecordMock := &batch.MockServiceRecordWriter{}
+	serviceRecordBatch := batch.NewBatch(21, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
+
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"gateway-key": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop(),
+		WithAdminKeys(map[string]bool{"admin-key": true}),
+		WithLogLevel(NewLogLevel(level, zap.NewNop())),
+	)
+	c.NoError(err)
+
+	tests := []struct {
+		name               string
+		method             string
+		apiKey             string
+		body               string
+		expectedStatusCode int
+		expectedLevel      zapcore.Level
+		expectRevert       bool
+	}{
+		{
+			name:               "Non admin key",
+			method:             http.MethodPut,
+			apiKey:             "gateway-key",
+			body:               `{"level":"debug"}`,
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedLevel:      zapcore.InfoLevel,
+		},
+		{
+			name:               "Get",
+			method:             http.MethodGet,
+			apiKey:             "admin-key",
+			expectedStatusCode: http.StatusOK,
+			expectedLevel:      zapcore.InfoLevel,
+		},
+		{
+			name:               "Temporary debug",
+			method:             http.MethodPut,
+			apiKey:             "admin-key",
+			body:               `{"level":"debug","duration":"1h"}`,
+			expectedStatusCode: http.StatusOK,
+			expectedLevel:      zapcore.DebugLevel,
+			expectRevert:       true,
+		},
+		{
+			name:               "Unknown level",
+			method:             http.MethodPut,
+			apiKey:             "admin-key",
+			body:               `{"level":"verbose"}`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedLevel:      zapcore.DebugLevel,
+		},
+		{
+			name:               "Invalid duration",
+			method:             http.MethodPut,
+			apiKey:             "admin-key",
+			body:               `{"level":"debug","duration":"-1m"}`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedLevel:      zapcore.DebugLevel,
+		},
+		{
+			name:               "Permanent level",
+			method:             http.MethodPut,
+			apiKey:             "admin-key",
+			body:               `{"level":"warn"}`,
+			expectedStatusCode: http.StatusOK,
+			expectedLevel:      zapcore.WarnLevel,
+		},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, "/v0/admin/log-level", bytes.NewBufferString(tt.body))
+		c.NoError(err)
+
+		req.Header.Set("Authorization", tt.apiKey)
+		rr := httptest.NewRecorder()
+
+		router.router.ServeHTTP(rr, req)
+		c.Equal(tt.expectedStatusCode, rr.Code, tt.name)
+		c.Equal(tt.expectedLevel, level.Level(), tt.name)
+
+		if tt.expectedStatusCode != http.StatusOK {
+			continue
+		}
+
+		var status LogLevelStatus
+		c.NoError(json.Unmarshal(rr.Body.Bytes(), &status), tt.name)
+		c.Equal(tt.expectedLevel.String(), status.Level, tt.name)
+		c.Equal(tt.expectRevert, status.RevertAt != nil, tt.name)
+	}
+}
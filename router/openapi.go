@@ -0,0 +1,23 @@
+package router
+
+import (
+	_ "embed"
+	"fmt"
+	"net/http"
+)
+
+// openAPISpec is the OpenAPI 3 document of every route of the router, kept in line with
+// the handlers by the contract tests
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// GetOpenAPI returns the OpenAPI document of the API
+func (rt *Router) GetOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write(openAPISpec); err != nil {
+		rt.logError(r.Context(), fmt.Errorf("GetOpenAPI in response writing failed: %w", err))
+	}
+}
@@ -0,0 +1,313 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gorilla/mux"
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/pokt-foundation/transaction-http-db/batch"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	"github.com/pokt-foundation/transaction-http-db/usage"
+	mock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func loadOpenAPISpec(t *testing.T) *openapi3.T {
+	t.Helper()
+
+	doc, err := openapi3.NewLoader().LoadFromData(openAPISpec)
+	require.NoError(t, err)
+	require.NoError(t, doc.Validate(context.Background()))
+
+	return doc
+}
+
+// disallowAdditionalProperties makes the objects of the spec reject the properties it does not
+// have, so a field added to a response without updating the spec fails the contract tests
+func disallowAdditionalProperties(schema *openapi3.Schema) {
+	if schema == nil {
+		return
+	}
+
+	if len(schema.Properties) > 0 && schema.AdditionalProperties.Schema == nil {
+		has := false
+		schema.AdditionalProperties.Has = &has
+	}
+
+	for _, property := range schema.Properties {
+		disallowAdditionalProperties(property.Value)
+	}
+
+	if schema.Items != nil {
+		disallowAdditionalProperties(schema.Items.Value)
+	}
+
+	if schema.AdditionalProperties.Schema != nil {
+		disallowAdditionalProperties(schema.AdditionalProperties.Schema.Value)
+	}
+}
+
+// newContractRouter returns a router with every optional feature enabled so all its
+// routes can be called
+func newContractRouter(t *testing.T) *Router {
+	t.Helper()
+
+	relayWriterMock := &batch.MockRelayWriter{}
+	relayWriterMock.On("WriteRelays", mock.Anything, mock.Anything).Return(nil)
+	relayBatch := batch.NewBatch(21, 21, "relay", time.Hour, time.Hour, relayWriterMock.WriteRelays, zap.NewNop())
+
+	serviceRecordMock := &batch.MockServiceRecordWriter{}
+	serviceRecordMock.On("WriteServiceRecords", mock.Anything, mock.Anything).Return(errors.New("dummy"))
+	serviceRecordBatch := batch.NewBatch(21, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
+
+	relay := types.Relay{
+		RelayID:             21,
+		PoktChainID:         "21",
+		SessionKey:          "21",
+		PoktNodeAddress:     "21",
+		PortalRegionName:    "La Colombia",
+		RelayStartDatetime:  time.Now(),
+		RelayReturnDatetime: time.Now(),
+		RelayChainMethodIDs: []string{"get_height"},
+		IsError:             true,
+		ErrorSource:         types.ErrorSourceExternal,
+		Session:             types.PocketSession{SessionKey: "21", SessionHeight: 21, PortalRegionName: "La Colombia"},
+		Region:              types.PortalRegion{PortalRegionName: "La Colombia"},
+	}
+
+	serviceRecord := types.ServiceRecord{
+		ServiceRecordID: 21,
+		NodePublicKey:   "21",
+		PoktChainID:     "21",
+		SessionKey:      "21",
+		Latency:         21.07,
+	}
+
+	driver := &storage.MockDriver{}
+	driver.On("Ping", mock.Anything).Return(nil)
+	driver.On("WriteSession", mock.Anything, mock.Anything).Return(nil)
+	driver.On("WriteRegion", mock.Anything, mock.Anything).Return(nil)
+	driver.On("ReadRelay", mock.Anything, 21).Return(relay, nil)
+	driver.On("ReadServiceRecord", mock.Anything, 21).Return(serviceRecord, nil)
+
+	usageTracker, err := usage.NewTracker(nil, time.Hour, zap.NewNop())
+	require.NoError(t, err)
+
+	fanOut := batch.NewFanOut("relay", batch.Sink[*types.Relay]{
+		Name:  "postgres",
+		Write: func(ctx context.Context, relays []*types.Relay) error { return nil },
+	}, nil, zap.NewNop())
+
+	router, err := NewRouter(driver, map[string]bool{"gateway-key": true, "limited-key": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop(),
+		WithAdminKeys(map[string]bool{"admin-key": true}),
+		WithKeyLabels(map[string]string{"limited-key": "limited"}),
+		WithRateLimiter(NewRateLimiter(RateLimit{}, map[string]RateLimit{"limited": {RequestsPerSecond: 0.001, RequestsBurst: 1}})),
+		WithUsageTracker(usageTracker),
+		WithReadiness(driver, ReadinessConfig{}),
+		WithSinks(fanOut),
+		WithReloader(reloaderFunc(func() ([]string, error) { return nil, nil })),
+		WithLogLevel(NewLogLevel(zap.NewAtomicLevel(), zap.NewNop())),
+		WithDiagnostics(DiagnosticsConfig{Enabled: true}),
+	)
+	require.NoError(t, err)
+
+	return router
+}
+
+func TestOpenAPI_Routes(t *testing.T) {
+	c := require.New(t)
+
+	doc := loadOpenAPISpec(t)
+	router := newContractRouter(t)
+
+	// Every route has an operation and every operation a route
+	var routes []string
+	c.NoError(router.router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+
+		methods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+
+		for _, method := range methods {
+			routes = append(routes, method+" "+template)
+		}
+
+		return nil
+	}))
+
+	var operations []string
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			operations = append(operations, method+" "+path)
+		}
+	}
+
+	sort.Strings(routes)
+	sort.Strings(operations)
+	c.Equal(routes, operations)
+}
+
+func TestOpenAPI_Contract(t *testing.T) {
+	c := require.New(t)
+
+	doc := loadOpenAPISpec(t)
+	for _, schema := range doc.Components.Schemas {
+		disallowAdditionalProperties(schema.Value)
+	}
+
+	specRouter, err := gorillamux.NewRouter(doc)
+	c.NoError(err)
+
+	server := httptest.NewServer(newContractRouter(t).router)
+	defer server.Close()
+
+	relay := `{"chainID":"21","sessionKey":"21","poktNodeAddress":"21","portalRegionName":"La Colombia","relayStartDatetime":"2023-01-01T00:00:00Z","relayReturnDatetime":"2023-01-01T00:00:01Z","relayChainMethodIDs":["get_height"]}`
+	serviceRecord := `{"nodePublicKey":"21","chainID":"21","sessionKey":"21","latency":21.07,"available":true}`
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		apiKey string
+		// invalidRequest is set when the spec rejects the request as the handler does
+		invalidRequest     bool
+		expectedStatusCode int
+	}{
+		{name: "Health check", method: http.MethodGet, path: "/", expectedStatusCode: http.StatusOK},
+		{name: "Liveness", method: http.MethodGet, path: "/livez", expectedStatusCode: http.StatusOK},
+		{name: "Readiness", method: http.MethodGet, path: "/readyz", expectedStatusCode: http.StatusOK},
+		{name: "Metrics", method: http.MethodGet, path: "/metrics", expectedStatusCode: http.StatusOK},
+		{name: "OpenAPI document", method: http.MethodGet, path: "/v0/openapi.json", expectedStatusCode: http.StatusOK},
+		{name: "Unauthorized", method: http.MethodPost, path: "/v0/relay", body: relay, apiKey: "unknown-key", expectedStatusCode: http.StatusUnauthorized},
+		{name: "Create session", method: http.MethodPost, path: "/v0/session", body: `{"sessionKey":"21","sessionHeight":21,"portalRegionName":"La Colombia"}`, apiKey: "gateway-key", expectedStatusCode: http.StatusOK},
+		{name: "Invalid session", method: http.MethodPost, path: "/v0/session", body: `{"sessionKey":"21"}`, apiKey: "gateway-key", invalidRequest: true, expectedStatusCode: http.StatusBadRequest},
+		{name: "Create region", method: http.MethodPost, path: "/v0/region", body: `{"portalRegionName":"La Colombia"}`, apiKey: "gateway-key", expectedStatusCode: http.StatusOK},
+		{name: "Create relay", method: http.MethodPost, path: "/v0/relay", body: relay, apiKey: "gateway-key", expectedStatusCode: http.StatusOK},
+		{name: "Invalid relay", method: http.MethodPost, path: "/v0/relay", body: `{"chainID":"21"}`, apiKey: "gateway-key", invalidRequest: true, expectedStatusCode: http.StatusBadRequest},
+		{name: "Create relays", method: http.MethodPost, path: "/v0/relays", body: "[" + relay + "," + relay + "]", apiKey: "gateway-key", expectedStatusCode: http.StatusOK},
+		{name: "Some invalid relays", method: http.MethodPost, path: "/v0/relays", body: "[" + relay + `,{}]`, apiKey: "gateway-key", invalidRequest: true, expectedStatusCode: http.StatusBadRequest},
+		{name: "Get relay", method: http.MethodGet, path: "/v0/relay/21", apiKey: "gateway-key", expectedStatusCode: http.StatusOK},
+		{name: "Invalid relay ID", method: http.MethodGet, path: "/v0/relay/pablo", apiKey: "gateway-key", invalidRequest: true, expectedStatusCode: http.StatusBadRequest},
+		{name: "Create service record", method: http.MethodPost, path: "/v0/service-record", body: serviceRecord, apiKey: "gateway-key", expectedStatusCode: http.StatusOK},
+		{name: "Create service records", method: http.MethodPost, path: "/v0/service-records", body: "[" + serviceRecord + "]", apiKey: "gateway-key", expectedStatusCode: http.StatusOK},
+		{name: "Some invalid service records", method: http.MethodPost, path: "/v0/service-records", body: "[" + serviceRecord + `,{"chainID":"21"}]`, apiKey: "gateway-key", invalidRequest: true, expectedStatusCode: http.StatusBadRequest},
+		{name: "Get service record", method: http.MethodGet, path: "/v0/service-record/21", apiKey: "gateway-key", expectedStatusCode: http.StatusOK},
+		{name: "Allowed by rate limit", method: http.MethodGet, path: "/v0/relay/21", apiKey: "limited-key", expectedStatusCode: http.StatusOK},
+		{name: "Rate limited", method: http.MethodGet, path: "/v0/relay/21", apiKey: "limited-key", expectedStatusCode: http.StatusTooManyRequests},
+		{name: "Non admin key", method: http.MethodGet, path: "/v0/admin/batches", apiKey: "gateway-key", expectedStatusCode: http.StatusUnauthorized},
+		{name: "Rate limits", method: http.MethodGet, path: "/v0/admin/rate-limits", apiKey: "admin-key", expectedStatusCode: http.StatusOK},
+		{name: "Usage", method: http.MethodGet, path: "/v0/admin/usage?label=limited", apiKey: "admin-key", expectedStatusCode: http.StatusOK},
+		{name: "Usage with from after to", method: http.MethodGet, path: "/v0/admin/usage?from=2023-01-02T00:00:00Z&to=2023-01-01T00:00:00Z", apiKey: "admin-key", expectedStatusCode: http.StatusBadRequest},
+		{name: "Sinks", method: http.MethodGet, path: "/v0/admin/sinks", apiKey: "admin-key", expectedStatusCode: http.StatusOK},
+		{name: "Reload", method: http.MethodPost, path: "/v0/admin/reload", apiKey: "admin-key", expectedStatusCode: http.StatusOK},
+		{name: "Get log level", method: http.MethodGet, path: "/v0/admin/log-level", apiKey: "admin-key", expectedStatusCode: http.StatusOK},
+		{name: "Set log level", method: http.MethodPut, path: "/v0/admin/log-level", body: `{"level":"debug","duration":"15m"}`, apiKey: "admin-key", expectedStatusCode: http.StatusOK},
+		{name: "Invalid log level", method: http.MethodPut, path: "/v0/admin/log-level", body: `{"level":"verbose"}`, apiKey: "admin-key", invalidRequest: true, expectedStatusCode: http.StatusBadRequest},
+		{name: "Batches", method: http.MethodGet, path: "/v0/admin/batches", apiKey: "admin-key", expectedStatusCode: http.StatusOK},
+		{name: "Pause batch", method: http.MethodPost, path: "/v0/admin/batches/relay/pause", apiKey: "admin-key", expectedStatusCode: http.StatusOK},
+		{name: "Flush batch", method: http.MethodPost, path: "/v0/admin/batches/relay/flush", apiKey: "admin-key", expectedStatusCode: http.StatusOK},
+		{name: "Failed flush", method: http.MethodPost, path: "/v0/admin/batches/service_record/flush", apiKey: "admin-key", expectedStatusCode: http.StatusInternalServerError},
+		{name: "Resume batch", method: http.MethodPost, path: "/v0/admin/batches/relay/resume", apiKey: "admin-key", expectedStatusCode: http.StatusOK},
+		{name: "Unknown batch", method: http.MethodPost, path: "/v0/admin/batches/session/pause", apiKey: "admin-key", invalidRequest: true, expectedStatusCode: http.StatusNotFound},
+		{name: "Profiles", method: http.MethodGet, path: "/v0/admin/debug/pprof/", apiKey: "admin-key", expectedStatusCode: http.StatusOK},
+		{name: "Heap profile", method: http.MethodGet, path: "/v0/admin/debug/pprof/heap", apiKey: "admin-key", expectedStatusCode: http.StatusOK},
+		{name: "Goroutine profile as text", method: http.MethodGet, path: "/v0/admin/debug/pprof/goroutine?debug=1", apiKey: "admin-key", expectedStatusCode: http.StatusOK},
+		{name: "Unknown profile", method: http.MethodGet, path: "/v0/admin/debug/pprof/pablo", apiKey: "admin-key", expectedStatusCode: http.StatusNotFound},
+		{name: "Symbols", method: http.MethodPost, path: "/v0/admin/debug/pprof/symbol", body: "0x0", apiKey: "admin-key", expectedStatusCode: http.StatusOK},
+		{name: "Runtime stats", method: http.MethodGet, path: "/v0/admin/debug/runtime", apiKey: "admin-key", expectedStatusCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+		c.NoError(err, tt.name)
+
+		if tt.apiKey != "" {
+			req.Header.Set("Authorization", tt.apiKey)
+		}
+		if tt.body != "" {
+			req.Header.Set("Content-Type", "application/json")
+			if strings.HasSuffix(tt.path, "/symbol") {
+				req.Header.Set("Content-Type", "text/plain")
+			}
+		}
+
+		route, pathParams, err := specRouter.FindRoute(req)
+		c.NoError(err, tt.name)
+
+		requestInput := &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+				IncludeResponseStatus: true,
+			},
+		}
+
+		err = openapi3filter.ValidateRequest(context.Background(), requestInput)
+		if tt.invalidRequest {
+			c.Error(err, tt.name)
+		} else {
+			c.NoError(err, tt.name)
+		}
+
+		// The validation reads the body
+		req.Body = io.NopCloser(strings.NewReader(tt.body))
+
+		resp, err := http.DefaultClient.Do(req)
+		c.NoError(err, tt.name)
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		c.NoError(err, tt.name)
+
+		c.Equal(tt.expectedStatusCode, resp.StatusCode, "%s: %s", tt.name, body)
+
+		c.NoError(openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: requestInput,
+			Status:                 resp.StatusCode,
+			Header:                 resp.Header,
+			Body:                   io.NopCloser(bytes.NewReader(body)),
+			Options:                requestInput.Options,
+		}), tt.name)
+	}
+}
+
+func TestRouter_GetOpenAPI(t *testing.T) {
+	c := require.New(t)
+
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"gateway-key": true}, "8080", nil, nil, zap.NewNop())
+	c.NoError(err)
+
+	// The document is served without an API key
+	req, err := http.NewRequest(http.MethodGet, "/v0/openapi.json", nil)
+	c.NoError(err)
+
+	rr := httptest.NewRecorder()
+	router.router.ServeHTTP(rr, req)
+	c.Equal(http.StatusOK, rr.Code)
+	c.Equal("application/json", rr.Header().Get("Content-Type"))
+
+	doc, err := openapi3.NewLoader().LoadFromData(rr.Body.Bytes())
+	c.NoError(err)
+	c.Equal("Transaction HTTP DB", doc.Info.Title)
+}
@@ -0,0 +1,301 @@
+package router
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	jsonresponse "github.com/pokt-foundation/utils-go/json-response"
+	"golang.org/x/time/rate"
+)
+
+// RateLimit holds the token bucket settings of a single API key.
+// A zero rate disables the corresponding limit.
+type RateLimit struct {
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	RequestsBurst     int     `json:"requestsBurst"`
+	ItemsPerSecond    float64 `json:"itemsPerSecond"`
+	ItemsBurst        int     `json:"itemsBurst"`
+}
+
+// KeyConsumption is the rate limiting state of a single API key
+type KeyConsumption struct {
+	Label                    string    `json:"label"`
+	Limit                    RateLimit `json:"limit"`
+	AllowedRequests          uint64    `json:"allowedRequests"`
+	LimitedRequests          uint64    `json:"limitedRequests"`
+	AllowedItems             uint64    `json:"allowedItems"`
+	LimitedItems             uint64    `json:"limitedItems"`
+	AvailableRequestsBalance float64   `json:"availableRequestsBalance"`
+	AvailableItemsBalance    float64   `json:"availableItemsBalance"`
+}
+
+type keyLimiter struct {
+	limit           RateLimit
+	requests        *rate.Limiter
+	items           *rate.Limiter
+	allowedRequests atomic.Uint64
+	limitedRequests atomic.Uint64
+	allowedItems    atomic.Uint64
+	limitedItems    atomic.Uint64
+}
+
+// RateLimiter keeps a pair of token buckets per API key label, one for
+// requests and one for the items sent in them
+type RateLimiter struct {
+	rwMutex   sync.RWMutex
+	defaults  RateLimit
+	overrides map[string]RateLimit
+	limiters  map[string]*keyLimiter
+}
+
+// NewRateLimiter returns a RateLimiter applying defaults to every key label
+// that has no entry in overrides
+func NewRateLimiter(defaults RateLimit, overrides map[string]RateLimit) *RateLimiter {
+	return &RateLimiter{
+		defaults:  defaults,
+		overrides: overrides,
+		limiters:  make(map[string]*keyLimiter),
+	}
+}
+
+// bucketSettings returns the token bucket rate and burst of a limit, the burst
+// defaulting to one second worth of tokens
+func bucketSettings(perSecond float64, burst int) (rate.Limit, int) {
+	if perSecond <= 0 {
+		return rate.Inf, 0
+	}
+
+	if burst <= 0 {
+		burst = int(math.Max(1, math.Ceil(perSecond)))
+	}
+
+	return rate.Limit(perSecond), burst
+}
+
+func newBucket(perSecond float64, burst int) *rate.Limiter {
+	return rate.NewLimiter(bucketSettings(perSecond, burst))
+}
+
+func setBucket(bucket *rate.Limiter, perSecond float64, burst int) {
+	limit, burst := bucketSettings(perSecond, burst)
+
+	bucket.SetLimit(limit)
+	bucket.SetBurst(burst)
+}
+
+// SetLimits replaces the default and per key label limits, applying them to the
+// key labels already seen without resetting their counters
+func (l *RateLimiter) SetLimits(defaults RateLimit, overrides map[string]RateLimit) {
+	l.rwMutex.Lock()
+	defer l.rwMutex.Unlock()
+
+	l.defaults = defaults
+	l.overrides = overrides
+
+	for label, kl := range l.limiters {
+		limit, ok := overrides[label]
+		if !ok {
+			limit = defaults
+		}
+
+		kl.limit = limit
+		setBucket(kl.requests, limit.RequestsPerSecond, limit.RequestsBurst)
+		setBucket(kl.items, limit.ItemsPerSecond, limit.ItemsBurst)
+	}
+}
+
+func (l *RateLimiter) limiter(label string) *keyLimiter {
+	l.rwMutex.RLock()
+	kl, ok := l.limiters[label]
+	l.rwMutex.RUnlock()
+
+	if ok {
+		return kl
+	}
+
+	l.rwMutex.Lock()
+	defer l.rwMutex.Unlock()
+
+	if kl, ok := l.limiters[label]; ok {
+		return kl
+	}
+
+	limit, ok := l.overrides[label]
+	if !ok {
+		limit = l.defaults
+	}
+
+	kl = &keyLimiter{
+		limit:    limit,
+		requests: newBucket(limit.RequestsPerSecond, limit.RequestsBurst),
+		items:    newBucket(limit.ItemsPerSecond, limit.ItemsBurst),
+	}
+	l.limiters[label] = kl
+
+	return kl
+}
+
+// take consumes n tokens from the bucket, returning how long the caller must
+// wait before retrying when there are not enough of them. A negative wait
+// means the request can never succeed because it exceeds the bucket burst.
+func take(bucket *rate.Limiter, n int) (bool, time.Duration) {
+	now := time.Now()
+
+	reservation := bucket.ReserveN(now, n)
+	if !reservation.OK() {
+		return false, -1
+	}
+
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+
+	return true, 0
+}
+
+// AllowRequest reports whether the key label may perform one more request
+func (l *RateLimiter) AllowRequest(label string) (bool, time.Duration) {
+	kl := l.limiter(label)
+
+	ok, retryAfter := take(kl.requests, 1)
+	if !ok {
+		kl.limitedRequests.Add(1)
+		return false, retryAfter
+	}
+
+	kl.allowedRequests.Add(1)
+
+	return true, 0
+}
+
+// AllowItems reports whether the key label may send n more items
+func (l *RateLimiter) AllowItems(label string, n int) (bool, time.Duration) {
+	kl := l.limiter(label)
+
+	ok, retryAfter := take(kl.items, n)
+	if !ok {
+		kl.limitedItems.Add(uint64(n))
+		return false, retryAfter
+	}
+
+	kl.allowedItems.Add(uint64(n))
+
+	return true, 0
+}
+
+// ItemsBurst returns the most items the key label may send at once
+func (l *RateLimiter) ItemsBurst(label string) int {
+	return l.limiter(label).items.Burst()
+}
+
+// Consumption returns the state of every key label seen so far, sorted by label
+func (l *RateLimiter) Consumption() []KeyConsumption {
+	l.rwMutex.RLock()
+	defer l.rwMutex.RUnlock()
+
+	now := time.Now()
+
+	consumption := make([]KeyConsumption, 0, len(l.limiters))
+	for label, kl := range l.limiters {
+		consumption = append(consumption, KeyConsumption{
+			Label:                    label,
+			Limit:                    kl.limit,
+			AllowedRequests:          kl.allowedRequests.Load(),
+			LimitedRequests:          kl.limitedRequests.Load(),
+			AllowedItems:             kl.allowedItems.Load(),
+			LimitedItems:             kl.limitedItems.Load(),
+			AvailableRequestsBalance: balance(kl.requests, now),
+			AvailableItemsBalance:    balance(kl.items, now),
+		})
+	}
+
+	sort.Slice(consumption, func(i, j int) bool {
+		return consumption[i].Label < consumption[j].Label
+	})
+
+	return consumption
+}
+
+// balance returns the tokens left in the bucket, -1 meaning it is unlimited
+func balance(bucket *rate.Limiter, now time.Time) float64 {
+	if bucket.Limit() == rate.Inf {
+		return -1
+	}
+
+	return bucket.TokensAt(now)
+}
+
+func respondWithTooManyRequests(w http.ResponseWriter, retryAfter time.Duration, msg string) {
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+
+	jsonresponse.RespondWithError(w, http.StatusTooManyRequests, msg)
+}
+
+// RateLimitHandler rejects requests of API keys that went over their requests per second limit
+func (rt *Router) RateLimitHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		label := keyLabelFromContext(r.Context())
+		if rt.rateLimiter == nil || label == "" {
+			h.ServeHTTP(w, r)
+
+			return
+		}
+
+		ok, retryAfter := rt.rateLimiter.AllowRequest(label)
+		if !ok {
+			recordRejectedRequest(r)
+
+			respondWithTooManyRequests(w, retryAfter, fmt.Sprintf("requests rate limit exceeded for key %s", label))
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// allowItems checks the items per second limit of the request API key, responding
+// with 429 when the items must be rejected, or 413 when they are more than the burst
+func (rt *Router) allowItems(w http.ResponseWriter, r *http.Request, n int) bool {
+	label := keyLabelFromContext(r.Context())
+	if rt.rateLimiter == nil || label == "" || n == 0 {
+		return true
+	}
+
+	ok, retryAfter := rt.rateLimiter.AllowItems(label, n)
+	if ok {
+		return true
+	}
+
+	rt.recordItems(r, 0, n)
+
+	// Retrying can't help a request with more items than the burst, which must be split instead
+	if retryAfter < 0 {
+		jsonresponse.RespondWithError(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("%d items exceed the items burst of %d allowed for key %s, send them in smaller requests",
+				n, rt.rateLimiter.ItemsBurst(label), label))
+		return false
+	}
+
+	respondWithTooManyRequests(w, retryAfter, fmt.Sprintf("items rate limit exceeded for key %s", label))
+
+	return false
+}
+
+func (rt *Router) GetRateLimits(w http.ResponseWriter, r *http.Request) {
+	if rt.rateLimiter == nil {
+		jsonresponse.RespondWithJSON(w, http.StatusOK, []KeyConsumption{})
+		return
+	}
+
+	jsonresponse.RespondWithJSON(w, http.StatusOK, rt.rateLimiter.Consumption())
+}
@@ -0,0 +1,200 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/pokt-foundation/transaction-http-db/batch"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRateLimiter_AllowRequest(t *testing.T) {
+	c := require.New(t)
+
+	limiter := NewRateLimiter(RateLimit{RequestsPerSecond: 1}, map[string]RateLimit{
+		"unlimited": {},
+	})
+
+	ok, _ := limiter.AllowRequest("gateway")
+	c.True(ok)
+
+	ok, retryAfter := limiter.AllowRequest("gateway")
+	c.False(ok)
+	c.Greater(retryAfter, time.Duration(0))
+
+	for i := 0; i < 10; i++ {
+		ok, _ = limiter.AllowRequest("unlimited")
+		c.True(ok)
+	}
+
+	consumption := limiter.Consumption()
+	c.Len(consumption, 2)
+	c.Equal("gateway", consumption[0].Label)
+	c.Equal(uint64(1), consumption[0].AllowedRequests)
+	c.Equal(uint64(1), consumption[0].LimitedRequests)
+	c.Equal("unlimited", consumption[1].Label)
+	c.Equal(uint64(10), consumption[1].AllowedRequests)
+	c.Equal(float64(-1), consumption[1].AvailableRequestsBalance)
+}
+
+func TestRateLimiter_AllowItems(t *testing.T) {
+	c := require.New(t)
+
+	limiter := NewRateLimiter(RateLimit{ItemsPerSecond: 10}, nil)
+
+	ok, _ := limiter.AllowItems("gateway", 6)
+	c.True(ok)
+
+	ok, retryAfter := limiter.AllowItems("gateway", 6)
+	c.False(ok)
+	c.Greater(retryAfter, time.Duration(0))
+
+	ok, retryAfter = limiter.AllowItems("gateway", 11)
+	c.False(ok)
+	c.Less(retryAfter, time.Duration(0))
+}
+
+func TestRateLimiter_SetLimits(t *testing.T) {
+	c := require.New(t)
+
+	limiter := NewRateLimiter(RateLimit{RequestsPerSecond: 1}, nil)
+
+	ok, _ := limiter.AllowRequest("gateway")
+	c.True(ok)
+
+	ok, _ = limiter.AllowRequest("gateway")
+	c.False(ok)
+
+	limiter.SetLimits(RateLimit{RequestsPerSecond: 1}, map[string]RateLimit{
+		"gateway": {RequestsPerSecond: 100, ItemsPerSecond: 10},
+	})
+
+	// The emptied bucket refills at the new rate
+	c.Eventually(func() bool {
+		ok, _ := limiter.AllowRequest("gateway")
+		return ok
+	}, time.Second, 5*time.Millisecond)
+
+	ok, _ = limiter.AllowItems("gateway", 11)
+	c.False(ok)
+
+	// The counters of the key label are kept
+	consumption := limiter.Consumption()
+	c.Len(consumption, 1)
+	c.Equal(RateLimit{RequestsPerSecond: 100, ItemsPerSecond: 10}, consumption[0].Limit)
+	c.Equal(uint64(2), consumption[0].AllowedRequests)
+	c.GreaterOrEqual(consumption[0].LimitedRequests, uint64(1))
+
+	limiter.SetLimits(RateLimit{}, nil)
+
+	for i := 0; i < 10; i++ {
+		ok, _ = limiter.AllowRequest("gateway")
+		c.True(ok)
+	}
+}
+
+func TestRouter_RateLimitHandler(t *testing.T) {
+	c := require.New(t)
+
+	relayWriterMock := &batch.MockRelayWriter{}
+	relayBatch := batch.NewBatch(2, 21, "relay", time.Hour, time.Hour, relayWriterMock.WriteRelays, zap.NewNop())
+
+	serviceRecordMock := &batch.MockServiceRecordWriter{}
+	serviceRecordBatch := batch.NewBatch(2, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
+
+	limiter := NewRateLimiter(RateLimit{RequestsPerSecond: 2, RequestsBurst: 2, ItemsPerSecond: 1, ItemsBurst: 2}, nil)
+
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"gateway-key": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop(),
+		WithAdminKeys(map[string]bool{"admin-key": true}),
+		WithKeyLabels(map[string]string{"gateway-key": "gateway"}),
+		WithRateLimiter(limiter),
+	)
+	c.NoError(err)
+
+	relaysToSend, err := json.Marshal([]*types.Relay{{}, {}, {}})
+	c.NoError(err)
+
+	tests := []struct {
+		name               string
+		method             string
+		path               string
+		reqInput           []byte
+		apiKey             string
+		expectedStatusCode int
+		expectRetryAfter   bool
+	}{
+		{
+			name:               "Items over burst",
+			method:             http.MethodPost,
+			path:               "/v0/relays",
+			reqInput:           relaysToSend,
+			apiKey:             "gateway-key",
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:               "Request allowed",
+			method:             http.MethodGet,
+			path:               "/v0/relay/pablo",
+			apiKey:             "gateway-key",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Requests rate limited",
+			method:             http.MethodGet,
+			path:               "/v0/relay/pablo",
+			apiKey:             "gateway-key",
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectRetryAfter:   true,
+		},
+		{
+			name:               "Admin route with regular key",
+			method:             http.MethodGet,
+			path:               "/v0/admin/rate-limits",
+			apiKey:             "gateway-key",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Admin route with admin key",
+			method:             http.MethodGet,
+			path:               "/v0/admin/rate-limits",
+			apiKey:             "admin-key",
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, tt.path, bytes.NewBuffer(tt.reqInput))
+		c.NoError(err)
+
+		req.Header.Set("Authorization", tt.apiKey)
+		rr := httptest.NewRecorder()
+
+		router.router.ServeHTTP(rr, req)
+		c.Equal(tt.expectedStatusCode, rr.Code, tt.name)
+
+		if tt.expectRetryAfter {
+			c.NotEmpty(rr.Header().Get("Retry-After"), tt.name)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "/v0/admin/rate-limits", nil)
+	c.NoError(err)
+
+	req.Header.Set("Authorization", "admin-key")
+	rr := httptest.NewRecorder()
+	router.router.ServeHTTP(rr, req)
+
+	var consumption []KeyConsumption
+	c.NoError(json.Unmarshal(rr.Body.Bytes(), &consumption))
+
+	c.Equal("gateway", consumption[0].Label)
+	c.Equal(uint64(3), consumption[0].LimitedItems)
+	c.Equal(DefaultKeyLabel("admin-key"), consumption[1].Label)
+}
@@ -0,0 +1,56 @@
+package router
+
+import (
+	"net/http"
+
+	jsonresponse "github.com/pokt-foundation/utils-go/json-response"
+)
+
+// Reloader reloads the settings that can change without a restart, returning what changed
+type Reloader interface {
+	Reload() (changes []string, err error)
+}
+
+// ReloadResult is the response of the reload endpoint
+type ReloadResult struct {
+	Changes []string `json:"changes"`
+}
+
+// WithReloader enables reloading the settings from the admin routes
+func WithReloader(reloader Reloader) Option {
+	return func(rt *Router) {
+		rt.reloader = reloader
+	}
+}
+
+// SetKeys replaces the API keys, the admin API keys and their labels, taking effect
+// from the next request
+func (rt *Router) SetKeys(apiKeys, adminKeys map[string]bool, keyLabels map[string]string) {
+	rt.keysMutex.Lock()
+	defer rt.keysMutex.Unlock()
+
+	rt.apiKeys = apiKeys
+	rt.adminKeys = adminKeys
+	rt.keyLabels = keyLabels
+}
+
+// Reload reloads the settings, responding with what changed or with why the
+// new settings were rejected, in which case nothing is applied
+func (rt *Router) Reload(w http.ResponseWriter, r *http.Request) {
+	if rt.reloader == nil {
+		jsonresponse.RespondWithError(w, http.StatusNotImplemented, "reloading is not enabled")
+		return
+	}
+
+	changes, err := rt.reloader.Reload()
+	if err != nil {
+		jsonresponse.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if changes == nil {
+		changes = []string{}
+	}
+
+	jsonresponse.RespondWithJSON(w, http.StatusOK, ReloadResult{Changes: changes})
+}
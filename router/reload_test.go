@@ -0,0 +1,111 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-http-db/batch"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type reloaderFunc func() ([]string, error)
+
+func (f reloaderFunc) Reload() ([]string, error) {
+	return f()
+}
+
+func TestRouter_Reload(t *testing.T) {
+	c := require.New(t)
+
+	relayWriterMock := &batch.MockRelayWriter{}
+	relayBatch := batch.NewBatch(21, 21, "relay", time.Hour, time.Hour, relayWriterMock.WriteRelays, zap.NewNop())
+
+	serviceRecordMock := &batch.MockServiceRecordWriter{}
+	serviceRecordBatch := batch.NewBatch(21, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
+
+	var (
+		router    *Router
+		reloadErr error
+	)
+
+	// The reload rotates the admin key
+	reloader := reloaderFunc(func() ([]string, error) {
+		if reloadErr != nil {
+			return nil, reloadErr
+		}
+
+		router.SetKeys(map[string]bool{"gateway-key": true}, map[string]bool{"new-admin-key": true}, nil)
+
+		return []string{"ADMIN_API_KEYS changed"}, nil
+	})
+
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"gateway-key": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop(),
+		WithAdminKeys(map[string]bool{"admin-key": true}),
+		WithReloader(reloader),
+	)
+	c.NoError(err)
+
+	tests := []struct {
+		name               string
+		apiKey             string
+		reloadErr          error
+		expectedStatusCode int
+		expectedChanges    []string
+	}{
+		{
+			name:               "Non admin key",
+			apiKey:             "gateway-key",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Invalid settings",
+			apiKey:             "admin-key",
+			reloadErr:          errors.New("CHAN_SIZE (chan_size) must be positive"),
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Reloaded",
+			apiKey:             "admin-key",
+			expectedStatusCode: http.StatusOK,
+			expectedChanges:    []string{"ADMIN_API_KEYS changed"},
+		},
+		{
+			name:               "Rotated key",
+			apiKey:             "admin-key",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "New key",
+			apiKey:             "new-admin-key",
+			expectedStatusCode: http.StatusOK,
+			expectedChanges:    []string{"ADMIN_API_KEYS changed"},
+		},
+	}
+
+	for _, tt := range tests {
+		reloadErr = tt.reloadErr
+
+		req, err := http.NewRequest(http.MethodPost, "/v0/admin/reload", nil)
+		c.NoError(err)
+
+		req.Header.Set("Authorization", tt.apiKey)
+		rr := httptest.NewRecorder()
+
+		router.router.ServeHTTP(rr, req)
+		c.Equal(tt.expectedStatusCode, rr.Code, tt.name)
+
+		if tt.expectedStatusCode != http.StatusOK {
+			continue
+		}
+
+		var result ReloadResult
+		c.NoError(json.Unmarshal(rr.Body.Bytes(), &result), tt.name)
+		c.Equal(tt.expectedChanges, result.Changes, tt.name)
+	}
+}
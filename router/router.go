@@ -2,50 +2,127 @@ package router
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/pokt-foundation/transaction-db/types"
 	"github.com/pokt-foundation/transaction-http-db/batch"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	"github.com/pokt-foundation/transaction-http-db/usage"
 	jsonresponse "github.com/pokt-foundation/utils-go/json-response"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
 
-type Driver interface {
-	WriteSession(ctx context.Context, session types.PocketSession) error
-	WriteRegion(ctx context.Context, region types.PortalRegion) error
-	WriteRelay(ctx context.Context, relay types.Relay) error
-	ReadRelay(ctx context.Context, relayID int) (types.Relay, error)
-	WriteServiceRecord(ctx context.Context, serviceRecord types.ServiceRecord) error
-	ReadServiceRecord(ctx context.Context, serviceRecordID int) (types.ServiceRecord, error)
-}
-
 type Router struct {
 	router             *mux.Router
-	driver             Driver
+	driver             storage.Driver
+	keysMutex          sync.RWMutex
 	apiKeys            map[string]bool
+	adminKeys          map[string]bool
+	keyLabels          map[string]string
+	rateLimiter        *RateLimiter
+	reloader           Reloader
+	logLevel           *LogLevel
+	usageTracker       *usage.Tracker
+	pinger             Pinger
+	readinessConfig    ReadinessConfig
+	schemaCheck        atomic.Pointer[CheckResult]
+	shuttingDown       atomic.Bool
+	serverConfig       ServerConfig
+	diagnosticsConfig  DiagnosticsConfig
+	sinks              []SinkReporter
 	relayBatch         *batch.Batch[*types.Relay]
 	serviceRecordBatch *batch.Batch[*types.ServiceRecord]
 	port               string
 	log                *zap.Logger
 }
 
-func (rt *Router) logError(err error) {
-	rt.log.Error(err.Error(), zap.String("err", err.Error()))
+// Option configures optional features of the Router
+type Option func(*Router)
+
+// WithAdminKeys sets the API keys allowed to call the /v0/admin routes
+func WithAdminKeys(adminKeys map[string]bool) Option {
+	return func(rt *Router) {
+		rt.adminKeys = adminKeys
+	}
+}
+
+// WithKeyLabels sets the human readable labels used to identify API keys in limits and reports
+func WithKeyLabels(keyLabels map[string]string) Option {
+	return func(rt *Router) {
+		rt.keyLabels = keyLabels
+	}
+}
+
+// WithUsageTracker enables the per API key usage accounting
+func WithUsageTracker(usageTracker *usage.Tracker) Option {
+	return func(rt *Router) {
+		rt.usageTracker = usageTracker
+	}
+}
+
+// WithRateLimiter enables per API key rate limiting
+func WithRateLimiter(rateLimiter *RateLimiter) Option {
+	return func(rt *Router) {
+		rt.rateLimiter = rateLimiter
+	}
+}
+
+type contextKey int
+
+const (
+	keyLabelCtxKey contextKey = iota
+	requestInfoCtxKey
+	usageRequestCtxKey
+)
+
+const adminPathPrefix = "/v0/admin/"
+
+// publicPaths can be called without an API key
+var publicPaths = map[string]bool{
+	"/":                true,
+	"/livez":           true,
+	"/readyz":          true,
+	"/metrics":         true,
+	"/v0/openapi.json": true,
+}
+
+func (rt *Router) logError(ctx context.Context, err error) {
+	rt.logger(ctx).Error(err.Error(), zap.String("err", err.Error()))
 }
 
 func respondWithResultOK(w http.ResponseWriter) {
 	jsonresponse.RespondWithJSON(w, http.StatusOK, map[string]string{"result": "ok"})
 }
 
+// batchFullRetryAfter is the wait suggested to the clients of a paused batch that is full
+const batchFullRetryAfter = 30 * time.Second
+
+// batchUnavailable reports whether an item was not added to a batch because the batch is
+// paused and full or the request was cancelled while waiting for it, rather than being invalid
+func batchUnavailable(err error) bool {
+	return errors.Is(err, batch.ErrFull) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func respondBatchUnavailable(w http.ResponseWriter, message string) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(batchFullRetryAfter.Seconds())))
+	jsonresponse.RespondWithError(w, http.StatusServiceUnavailable, message)
+}
+
 // NewRouter returns router instance
-func NewRouter(driver Driver, apiKeys map[string]bool, port string, relayBatch *batch.Batch[*types.Relay], serviceRecordBatch *batch.Batch[*types.ServiceRecord], logger *zap.Logger) (*Router, error) {
+func NewRouter(driver storage.Driver, apiKeys map[string]bool, port string, relayBatch *batch.Batch[*types.Relay], serviceRecordBatch *batch.Batch[*types.ServiceRecord], logger *zap.Logger, opts ...Option) (*Router, error) {
 	rt := &Router{
 		driver:             driver,
 		router:             mux.NewRouter(),
@@ -54,9 +131,22 @@ func NewRouter(driver Driver, apiKeys map[string]bool, port string, relayBatch *
 		serviceRecordBatch: serviceRecordBatch,
 		port:               port,
 		log:                logger,
+		readinessConfig: ReadinessConfig{
+			DBPingTimeout:   defaultDBPingTimeout,
+			MaxBacklogRatio: defaultMaxBacklogRatio,
+		},
+		serverConfig: defaultServerConfig(),
+	}
+
+	for _, opt := range opts {
+		opt(rt)
 	}
 
 	rt.router.HandleFunc("/", rt.HealthCheck).Methods(http.MethodGet)
+	rt.router.HandleFunc("/livez", rt.Livez).Methods(http.MethodGet)
+	rt.router.HandleFunc("/readyz", rt.Readyz).Methods(http.MethodGet)
+	rt.router.Handle("/metrics", promhttp.Handler()).Methods(http.MethodGet)
+	rt.router.HandleFunc("/v0/openapi.json", rt.GetOpenAPI).Methods(http.MethodGet)
 
 	rt.router.HandleFunc("/v0/session", rt.CreateSession).Methods(http.MethodPost)
 	rt.router.HandleFunc("/v0/region", rt.CreateRegion).Methods(http.MethodPost)
@@ -67,16 +157,34 @@ func NewRouter(driver Driver, apiKeys map[string]bool, port string, relayBatch *
 	rt.router.HandleFunc("/v0/service-records", rt.CreateServiceRecords).Methods(http.MethodPost)
 	rt.router.HandleFunc("/v0/service-record/{id}", rt.GetServiceRecord).Methods(http.MethodGet)
 
-	rt.router.Use(rt.AuthorizationHandler)
+	rt.router.HandleFunc("/v0/admin/rate-limits", rt.GetRateLimits).Methods(http.MethodGet)
+	rt.router.HandleFunc("/v0/admin/usage", rt.GetUsage).Methods(http.MethodGet)
+	rt.router.HandleFunc("/v0/admin/sinks", rt.GetSinks).Methods(http.MethodGet)
+	rt.router.HandleFunc("/v0/admin/reload", rt.Reload).Methods(http.MethodPost)
+	rt.router.HandleFunc("/v0/admin/log-level", rt.GetLogLevel).Methods(http.MethodGet)
+	rt.router.HandleFunc("/v0/admin/log-level", rt.SetLogLevel).Methods(http.MethodPut)
+	rt.router.HandleFunc("/v0/admin/batches", rt.GetBatches).Methods(http.MethodGet)
+	rt.router.HandleFunc("/v0/admin/batches/{name}/flush", rt.FlushBatch).Methods(http.MethodPost)
+	rt.router.HandleFunc("/v0/admin/batches/{name}/pause", rt.PauseBatch).Methods(http.MethodPost)
+	rt.router.HandleFunc("/v0/admin/batches/{name}/resume", rt.ResumeBatch).Methods(http.MethodPost)
+
+	if rt.diagnosticsConfig.Enabled && rt.diagnosticsConfig.Addr == "" {
+		rt.handleAdminDiagnostics()
+	}
+
+	middlewares := []mux.MiddlewareFunc{rt.TracingHandler, rt.RequestLogHandler, rt.RecoveryHandler, rt.BodyLimitHandler,
+		rt.AuthorizationHandler, rt.UsageHandler, rt.RateLimitHandler}
+
+	rt.router.Use(middlewares...)
+	// The mux only runs its middlewares for the matched routes
+	rt.router.NotFoundHandler = withMiddlewares(http.HandlerFunc(respondNotFound), middlewares...)
+	rt.router.MethodNotAllowedHandler = withMiddlewares(http.HandlerFunc(respondMethodNotAllowed), middlewares...)
 
 	return rt, nil
 }
 
 func (rt *Router) RunServer(ctx context.Context) {
-	httpServer := &http.Server{
-		Addr:    ":" + rt.port,
-		Handler: rt.router,
-	}
+	httpServer := rt.newHTTPServer()
 
 	rt.log.Info(fmt.Sprintf("Transaction HTTP DB running in port: %s", rt.port))
 
@@ -84,25 +192,54 @@ func (rt *Router) RunServer(ctx context.Context) {
 	g.Go(func() error {
 		return httpServer.ListenAndServe()
 	})
+
+	if rt.diagnosticsConfig.Enabled && rt.diagnosticsConfig.Addr != "" {
+		diagnosticsServer := rt.newDiagnosticsServer()
+
+		rt.log.Info(fmt.Sprintf("Diagnostics endpoints running in address: %s", rt.diagnosticsConfig.Addr))
+
+		g.Go(func() error {
+			return diagnosticsServer.ListenAndServe()
+		})
+		g.Go(func() error {
+			<-gCtx.Done()
+			// Profiles being captured are cut short, they are of no use once shutting down
+			if err := diagnosticsServer.Close(); err != nil {
+				rt.logError(ctx, fmt.Errorf("Error closing diagnostics server: %s", err))
+			}
+
+			return nil
+		})
+	}
 	g.Go(func() error {
 		<-gCtx.Done()
+		rt.shuttingDown.Store(true)
 		rt.log.Info("HTTP router context finished")
-		if err := httpServer.Shutdown(context.Background()); err != nil {
-			rt.logError(fmt.Errorf("Error closing http server: %s", err))
+
+		if rt.readinessConfig.DrainDelay > 0 {
+			rt.log.Info(fmt.Sprintf("Draining for %s before closing the http server", rt.readinessConfig.DrainDelay))
+			time.Sleep(rt.readinessConfig.DrainDelay)
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), rt.serverConfig.ShutdownTimeout)
+		defer cancel()
+
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			rt.logError(ctx, fmt.Errorf("Error closing http server: %s", err))
 		}
 
 		var wg sync.WaitGroup
 		wg.Add(2)
 		go func() {
 			defer wg.Done()
-			if err := rt.relayBatch.Save(); err != nil {
-				rt.logError(fmt.Errorf("Error saving relay batch: %s", err))
+			if err := rt.relayBatch.Close(); err != nil {
+				rt.logError(ctx, fmt.Errorf("Error saving relay batch: %s", err))
 			}
 		}()
 		go func() {
 			defer wg.Done()
-			if err := rt.serviceRecordBatch.Save(); err != nil {
-				rt.logError(fmt.Errorf("Error saving service record batch: %s", err))
+			if err := rt.serviceRecordBatch.Close(); err != nil {
+				rt.logError(ctx, fmt.Errorf("Error saving service record batch: %s", err))
 			}
 		}()
 		wg.Wait()
@@ -117,32 +254,71 @@ func (rt *Router) RunServer(ctx context.Context) {
 
 func (rt *Router) AuthorizationHandler(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// This is the path of the health check endpoint
-		if r.URL.Path == "/" {
+		// These are the paths of the health check endpoints
+		if publicPaths[r.URL.Path] {
 			h.ServeHTTP(w, r)
 
 			return
 		}
 
-		if !rt.apiKeys[r.Header.Get("Authorization")] {
+		key := r.Header.Get("Authorization")
+
+		rt.keysMutex.RLock()
+		authorized := rt.apiKeys[key]
+		if strings.HasPrefix(r.URL.Path, adminPathPrefix) {
+			authorized = rt.adminKeys[key]
+		}
+		rt.keysMutex.RUnlock()
+
+		if !authorized {
 			w.WriteHeader(http.StatusUnauthorized)
 			_, err := w.Write([]byte("Unauthorized"))
 			if err != nil {
-				panic(err)
+				rt.logError(r.Context(), fmt.Errorf("AuthorizationHandler in response writing failed: %w", err))
 			}
 
 			return
 		}
 
-		h.ServeHTTP(w, r)
+		label := rt.keyLabel(key)
+		if info := requestInfoFromContext(r.Context()); info != nil {
+			info.label = label
+		}
+
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), keyLabelCtxKey, label)))
 	})
 }
 
+// keyLabel returns the configured label of an API key, or a label derived from its hash
+// so the key itself never shows up in reports
+func (rt *Router) keyLabel(key string) string {
+	rt.keysMutex.RLock()
+	label, ok := rt.keyLabels[key]
+	rt.keysMutex.RUnlock()
+
+	if ok {
+		return label
+	}
+
+	return DefaultKeyLabel(key)
+}
+
+// DefaultKeyLabel returns the label used for API keys that were not given one
+func DefaultKeyLabel(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "key-" + hex.EncodeToString(sum[:4])
+}
+
+func keyLabelFromContext(ctx context.Context) string {
+	label, _ := ctx.Value(keyLabelCtxKey).(string)
+	return label
+}
+
 func (rt *Router) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, err := w.Write([]byte("Transaction HTTP DB is up and running!"))
 	if err != nil {
-		panic(err)
+		rt.logError(r.Context(), fmt.Errorf("HealthCheck in response writing failed: %w", err))
 	}
 }
 
@@ -153,22 +329,22 @@ func (rt *Router) CreateSession(w http.ResponseWriter, r *http.Request) {
 	var session types.PocketSession
 	err := decoder.Decode(&session)
 	if err != nil {
-		rt.logError(fmt.Errorf("CreateSession in JSON decoding failed: %w", err))
-		jsonresponse.RespondWithError(w, http.StatusBadRequest, err.Error())
+		rt.logError(r.Context(), fmt.Errorf("CreateSession in JSON decoding failed: %w", err))
+		jsonresponse.RespondWithError(w, decodeErrorStatus(err), err.Error())
 		return
 	}
 
 	defer r.Body.Close()
 
 	if err := session.Validate(); err != nil {
-		rt.logError(fmt.Errorf("CreateSession in validate session failed: %w", err))
+		rt.logError(r.Context(), fmt.Errorf("CreateSession in validate session failed: %w", err))
 		jsonresponse.RespondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	err = rt.driver.WriteSession(ctx, session)
 	if err != nil {
-		rt.logError(fmt.Errorf("CreateSession in WriteSession failed: %w", err))
+		rt.logError(r.Context(), fmt.Errorf("CreateSession in WriteSession failed: %w", err))
 
 		if errors.Is(err, types.ErrRepeatedSessionKey) {
 			jsonresponse.RespondWithError(w, http.StatusBadRequest, err.Error())
@@ -189,8 +365,8 @@ func (rt *Router) CreateRegion(w http.ResponseWriter, r *http.Request) {
 	var region types.PortalRegion
 	err := decoder.Decode(&region)
 	if err != nil {
-		rt.logError(fmt.Errorf("CreateRegion in JSON decoding failed: %w", err))
-		jsonresponse.RespondWithError(w, http.StatusBadRequest, err.Error())
+		rt.logError(r.Context(), fmt.Errorf("CreateRegion in JSON decoding failed: %w", err))
+		jsonresponse.RespondWithError(w, decodeErrorStatus(err), err.Error())
 		return
 	}
 
@@ -198,7 +374,7 @@ func (rt *Router) CreateRegion(w http.ResponseWriter, r *http.Request) {
 
 	err = rt.driver.WriteRegion(ctx, region)
 	if err != nil {
-		rt.logError(fmt.Errorf("CreateRegion in WriteRegion failed: %w", err))
+		rt.logError(r.Context(), fmt.Errorf("CreateRegion in WriteRegion failed: %w", err))
 		jsonresponse.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -212,20 +388,33 @@ func (rt *Router) CreateRelay(w http.ResponseWriter, r *http.Request) {
 	var relay types.Relay
 	err := decoder.Decode(&relay)
 	if err != nil {
-		rt.logError(fmt.Errorf("CreateRelay in JSON decoding failed: %w", err))
-		jsonresponse.RespondWithError(w, http.StatusBadRequest, err.Error())
+		rt.logError(r.Context(), fmt.Errorf("CreateRelay in JSON decoding failed: %w", err))
+		jsonresponse.RespondWithError(w, decodeErrorStatus(err), err.Error())
 		return
 	}
 
 	defer r.Body.Close()
 
-	err = rt.relayBatch.Add(&relay)
+	if !rt.allowItems(w, r, 1) {
+		return
+	}
+
+	err = rt.relayBatch.Add(r.Context(), &relay)
+	if batchUnavailable(err) {
+		rt.recordItems(r, 0, 1)
+		rt.logError(r.Context(), fmt.Errorf("CreateRelay in relay queueing failed: %w", err))
+		respondBatchUnavailable(w, err.Error())
+		return
+	}
 	if err != nil {
-		rt.logError(fmt.Errorf("CreateRelay in relay validating failed: %w", err))
+		rt.recordItems(r, 0, 1)
+		rt.logError(r.Context(), fmt.Errorf("CreateRelay in relay validating failed: %w", err))
 		jsonresponse.RespondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	rt.recordItems(r, 1, 0)
+
 	respondWithResultOK(w)
 }
 
@@ -235,20 +424,40 @@ func (rt *Router) CreateRelays(w http.ResponseWriter, r *http.Request) {
 	var relays []*types.Relay
 	err := decoder.Decode(&relays)
 	if err != nil {
-		rt.logError(fmt.Errorf("CreateRelays in JSON decoding failed: %w", err))
-		jsonresponse.RespondWithError(w, http.StatusBadRequest, err.Error())
+		rt.logError(r.Context(), fmt.Errorf("CreateRelays in JSON decoding failed: %w", err))
+		jsonresponse.RespondWithError(w, decodeErrorStatus(err), err.Error())
 		return
 	}
 
 	defer r.Body.Close()
 
-	errs := 0
+	if !rt.allowItems(w, r, len(relays)) {
+		return
+	}
+
+	errs, queued := 0, 0
 	for _, relay := range relays {
-		err = rt.relayBatch.Add(relay)
+		err = rt.relayBatch.Add(r.Context(), relay)
+		if batchUnavailable(err) {
+			break
+		}
+
 		if err != nil {
-			rt.logError(fmt.Errorf("CreateRelays in relay validating failed: %w", err))
+			rt.logError(r.Context(), fmt.Errorf("CreateRelays in relay validating failed: %w", err))
 			errs++
+			continue
 		}
+
+		queued++
+	}
+
+	rt.recordItems(r, queued, len(relays)-queued)
+
+	// The relays queued before are kept, so the response tells how many to send again
+	if batchUnavailable(err) {
+		rt.logError(r.Context(), fmt.Errorf("CreateRelays in relay queueing failed: %w", err))
+		respondBatchUnavailable(w, fmt.Sprintf("%s, %d of %d relays were queued", err, queued, len(relays)))
+		return
 	}
 
 	// TODO: Return the relay errors that failed
@@ -267,14 +476,14 @@ func (rt *Router) GetRelay(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		rt.logError(fmt.Errorf("GetRelay in params parsing failed: %w", err))
+		rt.logError(r.Context(), fmt.Errorf("GetRelay in params parsing failed: %w", err))
 		jsonresponse.RespondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	relay, err := rt.driver.ReadRelay(ctx, id)
 	if err != nil {
-		rt.logError(fmt.Errorf("GetRelay in ReadRelay failed: %w", err))
+		rt.logError(r.Context(), fmt.Errorf("GetRelay in ReadRelay failed: %w", err))
 		jsonresponse.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -288,20 +497,33 @@ func (rt *Router) CreateServiceRecord(w http.ResponseWriter, r *http.Request) {
 	var serviceRecord types.ServiceRecord
 	err := decoder.Decode(&serviceRecord)
 	if err != nil {
-		rt.logError(fmt.Errorf("CreateServiceRecord in JSON decoding failed: %w", err))
-		jsonresponse.RespondWithError(w, http.StatusBadRequest, err.Error())
+		rt.logError(r.Context(), fmt.Errorf("CreateServiceRecord in JSON decoding failed: %w", err))
+		jsonresponse.RespondWithError(w, decodeErrorStatus(err), err.Error())
 		return
 	}
 
 	defer r.Body.Close()
 
-	err = rt.serviceRecordBatch.Add(&serviceRecord)
+	if !rt.allowItems(w, r, 1) {
+		return
+	}
+
+	err = rt.serviceRecordBatch.Add(r.Context(), &serviceRecord)
+	if batchUnavailable(err) {
+		rt.recordItems(r, 0, 1)
+		rt.logError(r.Context(), fmt.Errorf("CreateServiceRecord in service record queueing failed: %w", err))
+		respondBatchUnavailable(w, err.Error())
+		return
+	}
 	if err != nil {
-		rt.logError(fmt.Errorf("CreateServiceRecord in service record validating failed: %w", err))
+		rt.recordItems(r, 0, 1)
+		rt.logError(r.Context(), fmt.Errorf("CreateServiceRecord in service record validating failed: %w", err))
 		jsonresponse.RespondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	rt.recordItems(r, 1, 0)
+
 	respondWithResultOK(w)
 }
 
@@ -311,20 +533,40 @@ func (rt *Router) CreateServiceRecords(w http.ResponseWriter, r *http.Request) {
 	var serviceRecords []*types.ServiceRecord
 	err := decoder.Decode(&serviceRecords)
 	if err != nil {
-		rt.logError(fmt.Errorf("CreateServiceRecords in JSON decoding failed: %w", err))
-		jsonresponse.RespondWithError(w, http.StatusBadRequest, err.Error())
+		rt.logError(r.Context(), fmt.Errorf("CreateServiceRecords in JSON decoding failed: %w", err))
+		jsonresponse.RespondWithError(w, decodeErrorStatus(err), err.Error())
 		return
 	}
 
 	defer r.Body.Close()
 
-	errs := 0
+	if !rt.allowItems(w, r, len(serviceRecords)) {
+		return
+	}
+
+	errs, queued := 0, 0
 	for _, serviceRecord := range serviceRecords {
-		err = rt.serviceRecordBatch.Add(serviceRecord)
+		err = rt.serviceRecordBatch.Add(r.Context(), serviceRecord)
+		if batchUnavailable(err) {
+			break
+		}
+
 		if err != nil {
-			rt.logError(fmt.Errorf("CreateServiceRecords in service record validating failed: %w", err))
+			rt.logError(r.Context(), fmt.Errorf("CreateServiceRecords in service record validating failed: %w", err))
 			errs++
+			continue
 		}
+
+		queued++
+	}
+
+	rt.recordItems(r, queued, len(serviceRecords)-queued)
+
+	// The service records queued before are kept, so the response tells how many to send again
+	if batchUnavailable(err) {
+		rt.logError(r.Context(), fmt.Errorf("CreateServiceRecords in service record queueing failed: %w", err))
+		respondBatchUnavailable(w, fmt.Sprintf("%s, %d of %d service records were queued", err, queued, len(serviceRecords)))
+		return
 	}
 
 	// TODO: Return the service records errors that failed
@@ -343,14 +585,14 @@ func (rt *Router) GetServiceRecord(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		rt.logError(fmt.Errorf("GetServiceRecord in params parsing failed: %w", err))
+		rt.logError(r.Context(), fmt.Errorf("GetServiceRecord in params parsing failed: %w", err))
 		jsonresponse.RespondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	serviceRecord, err := rt.driver.ReadServiceRecord(ctx, id)
 	if err != nil {
-		rt.logError(fmt.Errorf("GetServiceRecord in ReadServiceRecord failed: %w", err))
+		rt.logError(r.Context(), fmt.Errorf("GetServiceRecord in ReadServiceRecord failed: %w", err))
 		jsonresponse.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
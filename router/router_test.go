@@ -13,6 +13,7 @@ import (
 
 	"github.com/pokt-foundation/transaction-db/types"
 	"github.com/pokt-foundation/transaction-http-db/batch"
+	"github.com/pokt-foundation/transaction-http-db/storage"
 	mock "github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
@@ -26,7 +27,7 @@ func TestRouter_HealthCheck(t *testing.T) {
 	serviceRecordMock := &batch.MockServiceRecordWriter{}
 	serviceRecordBatch := batch.NewBatch(2, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
 
-	router, err := NewRouter(&MockDriver{}, map[string]bool{"": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop())
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop())
 	c.NoError(err)
 
 	tests := []struct {
@@ -58,7 +59,7 @@ func TestRouter_CreateSession(t *testing.T) {
 	serviceRecordMock := &batch.MockServiceRecordWriter{}
 	serviceRecordBatch := batch.NewBatch(2, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
 
-	driverMock := &MockDriver{}
+	driverMock := &storage.MockDriver{}
 	router, err := NewRouter(driverMock, map[string]bool{"": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop())
 	c.NoError(err)
 
@@ -144,7 +145,7 @@ func TestRouter_CreateRegion(t *testing.T) {
 	serviceRecordMock := &batch.MockServiceRecordWriter{}
 	serviceRecordBatch := batch.NewBatch(2, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
 
-	driverMock := &MockDriver{}
+	driverMock := &storage.MockDriver{}
 	router, err := NewRouter(driverMock, map[string]bool{"": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop())
 	c.NoError(err)
 
@@ -214,7 +215,7 @@ func TestRouter_CreateRelay(t *testing.T) {
 	serviceRecordMock := &batch.MockServiceRecordWriter{}
 	serviceRecordBatch := batch.NewBatch(2, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
 
-	router, err := NewRouter(&MockDriver{}, map[string]bool{"": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop())
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop())
 	c.NoError(err)
 
 	rawRelayToSend := types.Relay{
@@ -295,7 +296,7 @@ func TestRouter_CreateServiceRecord(t *testing.T) {
 	serviceRecordBatch := batch.NewBatch(2, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
 	serviceRecordMock.On("WriteServiceRecords", mock.Anything, mock.Anything).Return(nil).Once()
 
-	router, err := NewRouter(&MockDriver{}, map[string]bool{"": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop())
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop())
 	c.NoError(err)
 
 	rawServiceRecordToSend := types.ServiceRecord{
@@ -376,7 +377,7 @@ func TestRouter_CreateRelays(t *testing.T) {
 	serviceRecordMock := &batch.MockServiceRecordWriter{}
 	serviceRecordBatch := batch.NewBatch(2, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
 
-	router, err := NewRouter(&MockDriver{}, map[string]bool{"": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop())
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop())
 	c.NoError(err)
 
 	rawRelaysToSend := []types.Relay{{
@@ -486,7 +487,7 @@ func TestRouter_CreateServiceRecords(t *testing.T) {
 	serviceRecordBatch := batch.NewBatch(2, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
 	serviceRecordMock.On("WriteServiceRecords", mock.Anything, mock.Anything).Return(nil).Once()
 
-	router, err := NewRouter(&MockDriver{}, map[string]bool{"": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop())
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop())
 	c.NoError(err)
 
 	rawServiceRecordsToSend := []types.ServiceRecord{{
@@ -583,7 +584,7 @@ func TestRouter_GetRelay(t *testing.T) {
 	serviceRecordMock := &batch.MockServiceRecordWriter{}
 	serviceRecordBatch := batch.NewBatch(2, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
 
-	driverMock := &MockDriver{}
+	driverMock := &storage.MockDriver{}
 	router, err := NewRouter(driverMock, map[string]bool{"": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop())
 	c.NoError(err)
 
@@ -663,7 +664,7 @@ func TestRouter_GetServiceRecord(t *testing.T) {
 	serviceRecordMock := &batch.MockServiceRecordWriter{}
 	serviceRecordBatch := batch.NewBatch(2, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
 
-	driverMock := &MockDriver{}
+	driverMock := &storage.MockDriver{}
 	router, err := NewRouter(driverMock, map[string]bool{"": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop())
 	c.NoError(err)
 
@@ -764,7 +765,7 @@ func TestRouter_RunServer(t *testing.T) {
 		serviceRecordMock := &batch.MockServiceRecordWriter{}
 		serviceRecordBatch := batch.NewBatch(2, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
 
-		err := relayBatch.Add(&types.Relay{
+		err := relayBatch.Add(context.Background(), &types.Relay{
 			PoktChainID:              "21",
 			EndpointID:               "21",
 			SessionKey:               "21",
@@ -795,7 +796,7 @@ func TestRouter_RunServer(t *testing.T) {
 		})
 		c.NoError(err)
 
-		err = serviceRecordBatch.Add(&types.ServiceRecord{
+		err = serviceRecordBatch.Add(context.Background(), &types.ServiceRecord{
 			SessionKey:             "21",
 			NodePublicKey:          "21",
 			PoktChainID:            "21",
@@ -818,7 +819,7 @@ func TestRouter_RunServer(t *testing.T) {
 		c.Equal(1, relayBatch.Size())
 		c.Equal(1, serviceRecordBatch.Size())
 
-		router, err := NewRouter(&MockDriver{}, map[string]bool{"": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop())
+		router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop())
 		c.NoError(err)
 
 		ctxTimeout, cancel := context.WithTimeout(context.Background(), tt.ctxTimeout)
@@ -0,0 +1,189 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gorilla/mux"
+	jsonresponse "github.com/pokt-foundation/utils-go/json-response"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultReadHeaderTimeout = 10 * time.Second
+	defaultReadTimeout       = 30 * time.Second
+	defaultWriteTimeout      = 30 * time.Second
+	defaultIdleTimeout       = 120 * time.Second
+	defaultMaxBodyBytes      = 10 << 20
+	defaultShutdownTimeout   = 30 * time.Second
+)
+
+var panicsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "transaction_http_db",
+	Name:      "panics_total",
+	Help:      "Number of panics recovered while serving requests, by route.",
+}, []string{"route"})
+
+// ServerConfig holds the limits of the HTTP server, a zero value falling back to its default
+type ServerConfig struct {
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	MaxBodyBytes      int64
+	// ShutdownTimeout bounds how long in flight requests have to finish on shutdown
+	ShutdownTimeout time.Duration
+}
+
+func defaultServerConfig() ServerConfig {
+	return ServerConfig{
+		ReadHeaderTimeout: defaultReadHeaderTimeout,
+		ReadTimeout:       defaultReadTimeout,
+		WriteTimeout:      defaultWriteTimeout,
+		IdleTimeout:       defaultIdleTimeout,
+		MaxHeaderBytes:    http.DefaultMaxHeaderBytes,
+		MaxBodyBytes:      defaultMaxBodyBytes,
+		ShutdownTimeout:   defaultShutdownTimeout,
+	}
+}
+
+// WithServerConfig sets the timeouts and size limits of the HTTP server
+func WithServerConfig(config ServerConfig) Option {
+	return func(rt *Router) {
+		if config.ReadHeaderTimeout > 0 {
+			rt.serverConfig.ReadHeaderTimeout = config.ReadHeaderTimeout
+		}
+		if config.ReadTimeout > 0 {
+			rt.serverConfig.ReadTimeout = config.ReadTimeout
+		}
+		if config.WriteTimeout > 0 {
+			rt.serverConfig.WriteTimeout = config.WriteTimeout
+		}
+		if config.IdleTimeout > 0 {
+			rt.serverConfig.IdleTimeout = config.IdleTimeout
+		}
+		if config.MaxHeaderBytes > 0 {
+			rt.serverConfig.MaxHeaderBytes = config.MaxHeaderBytes
+		}
+		if config.MaxBodyBytes > 0 {
+			rt.serverConfig.MaxBodyBytes = config.MaxBodyBytes
+		}
+		if config.ShutdownTimeout > 0 {
+			rt.serverConfig.ShutdownTimeout = config.ShutdownTimeout
+		}
+	}
+}
+
+func (rt *Router) newHTTPServer() *http.Server {
+	return &http.Server{
+		Addr:              ":" + rt.port,
+		Handler:           rt.router,
+		ReadHeaderTimeout: rt.serverConfig.ReadHeaderTimeout,
+		ReadTimeout:       rt.serverConfig.ReadTimeout,
+		WriteTimeout:      rt.serverConfig.WriteTimeout,
+		IdleTimeout:       rt.serverConfig.IdleTimeout,
+		MaxHeaderBytes:    rt.serverConfig.MaxHeaderBytes,
+		ErrorLog:          zap.NewStdLog(rt.log),
+	}
+}
+
+// unmatchedRoute names the requests matching no route, keeping arbitrary paths out of the
+// metric labels and span names
+const unmatchedRoute = "unmatched"
+
+func routeTemplate(r *http.Request) string {
+	if currentRoute := mux.CurrentRoute(r); currentRoute != nil {
+		if template, err := currentRoute.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+
+	return unmatchedRoute
+}
+
+// delayWriteDeadline gives the response the write timeout of the server once d is over, for
+// the handlers that take longer than the write timeout on purpose
+func (rt *Router) delayWriteDeadline(w http.ResponseWriter, d time.Duration) error {
+	return http.NewResponseController(w).SetWriteDeadline(time.Now().Add(d + rt.serverConfig.WriteTimeout))
+}
+
+// withMiddlewares wraps h with the middlewares, the first one being the outermost
+// like for the routes of the mux
+func withMiddlewares(h http.Handler, middlewares ...mux.MiddlewareFunc) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+
+	return h
+}
+
+func respondNotFound(w http.ResponseWriter, r *http.Request) {
+	jsonresponse.RespondWithError(w, http.StatusNotFound, "route not found")
+}
+
+func respondMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	jsonresponse.RespondWithError(w, http.StatusMethodNotAllowed, "method not allowed")
+}
+
+// RecoveryHandler turns a panic while serving a request into a logged 500, or aborts
+// the response when the handler already started writing it
+func (rt *Router) RecoveryHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &responseRecorder{ResponseWriter: w}
+
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			// The server aborts the response on purpose with this panic, let it do so
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			route := routeTemplate(r)
+			panicsTotal.WithLabelValues(route).Inc()
+
+			rt.logger(r.Context()).Error(fmt.Sprintf("panic serving %s: %v", route, recovered),
+				zap.String("err", fmt.Sprint(recovered)),
+				zap.String("stacktrace", string(debug.Stack())),
+			)
+
+			// Writing a 500 after the headers went out would corrupt the response, the
+			// connection is closed instead so the client sees it failed
+			if recorder.status != 0 {
+				panic(http.ErrAbortHandler)
+			}
+
+			jsonresponse.RespondWithError(w, http.StatusInternalServerError, "internal server error")
+		}()
+
+		h.ServeHTTP(recorder, r)
+	})
+}
+
+// BodyLimitHandler caps the size of request bodies at the configured max body bytes
+func (rt *Router) BodyLimitHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, rt.serverConfig.MaxBodyBytes)
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// decodeErrorStatus returns the status code to respond with when decoding a request body fails
+func decodeErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+
+	return http.StatusBadRequest
+}
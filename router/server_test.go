@@ -0,0 +1,177 @@
+package router
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-http-db/batch"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRouter_RecoveryHandler(t *testing.T) {
+	c := require.New(t)
+
+	relayWriterMock := &batch.MockRelayWriter{}
+	relayBatch := batch.NewBatch(2, 21, "relay", time.Hour, time.Hour, relayWriterMock.WriteRelays, zap.NewNop())
+
+	serviceRecordMock := &batch.MockServiceRecordWriter{}
+	serviceRecordBatch := batch.NewBatch(2, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
+
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop())
+	c.NoError(err)
+
+	router.router.HandleFunc("/v0/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("dummy")
+	})
+
+	panicsBefore := testutil.ToFloat64(panicsTotal.WithLabelValues("/v0/panic"))
+
+	req, err := http.NewRequest(http.MethodGet, "/v0/panic", nil)
+	c.NoError(err)
+
+	rr := httptest.NewRecorder()
+	router.router.ServeHTTP(rr, req)
+	c.Equal(http.StatusInternalServerError, rr.Code)
+	c.Equal(`{"error":"internal server error"}`, rr.Body.String())
+	c.Equal(panicsBefore+1, testutil.ToFloat64(panicsTotal.WithLabelValues("/v0/panic")))
+
+	// A response already started is aborted rather than followed by a 500
+	router.router.HandleFunc("/v0/panic-after-write", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		panic("dummy")
+	})
+
+	req, err = http.NewRequest(http.MethodGet, "/v0/panic-after-write", nil)
+	c.NoError(err)
+
+	rr = httptest.NewRecorder()
+	c.PanicsWithValue(http.ErrAbortHandler, func() { router.router.ServeHTTP(rr, req) })
+	c.Equal(http.StatusOK, rr.Code)
+	c.Empty(rr.Body.String())
+}
+
+func TestRouter_unmatchedRoutes(t *testing.T) {
+	c := require.New(t)
+
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"key": true}, "8080", nil, nil, zap.NewNop())
+	c.NoError(err)
+
+	tests := []struct {
+		name               string
+		method             string
+		path               string
+		apiKey             string
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:               "Not found",
+			method:             http.MethodGet,
+			path:               "/v0/relayz",
+			apiKey:             "key",
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       `{"error":"route not found"}`,
+		},
+		{
+			name:               "Method not allowed",
+			method:             http.MethodDelete,
+			path:               "/v0/relays",
+			apiKey:             "key",
+			expectedStatusCode: http.StatusMethodNotAllowed,
+			expectedBody:       `{"error":"method not allowed"}`,
+		},
+		{
+			name:               "Not found without API key",
+			method:             http.MethodGet,
+			path:               "/v0/relayz",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, tt.path, nil)
+		c.NoError(err)
+
+		req.Header.Set("Authorization", tt.apiKey)
+		req.Header.Set(requestIDHeader, "unmatched-request")
+		rr := httptest.NewRecorder()
+
+		router.router.ServeHTTP(rr, req)
+		c.Equal(tt.expectedStatusCode, rr.Code, tt.name)
+		c.Equal("unmatched-request", rr.Header().Get(requestIDHeader), tt.name)
+
+		if tt.expectedBody != "" {
+			c.Equal(tt.expectedBody, rr.Body.String(), tt.name)
+		}
+	}
+}
+
+func TestRouter_BodyLimitHandler(t *testing.T) {
+	c := require.New(t)
+
+	relayWriterMock := &batch.MockRelayWriter{}
+	relayBatch := batch.NewBatch(2, 21, "relay", time.Hour, time.Hour, relayWriterMock.WriteRelays, zap.NewNop())
+
+	serviceRecordMock := &batch.MockServiceRecordWriter{}
+	serviceRecordBatch := batch.NewBatch(2, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
+
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop(),
+		WithServerConfig(ServerConfig{MaxBodyBytes: 8}),
+	)
+	c.NoError(err)
+
+	tests := []struct {
+		name               string
+		reqInput           []byte
+		expectedStatusCode int
+	}{
+		{
+			name:               "Body under limit",
+			reqInput:           []byte("wrong"),
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Body over limit",
+			reqInput:           []byte(`[{"chainID":"21"}]`),
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodPost, "/v0/relays", bytes.NewBuffer(tt.reqInput))
+		c.NoError(err)
+
+		rr := httptest.NewRecorder()
+		router.router.ServeHTTP(rr, req)
+		c.Equal(tt.expectedStatusCode, rr.Code, tt.name)
+	}
+}
+
+func TestRouter_newHTTPServer(t *testing.T) {
+	c := require.New(t)
+
+	relayWriterMock := &batch.MockRelayWriter{}
+	relayBatch := batch.NewBatch(2, 21, "relay", time.Hour, time.Hour, relayWriterMock.WriteRelays, zap.NewNop())
+
+	serviceRecordMock := &batch.MockServiceRecordWriter{}
+	serviceRecordBatch := batch.NewBatch(2, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
+
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop(),
+		WithServerConfig(ServerConfig{ReadHeaderTimeout: time.Second, MaxHeaderBytes: 21}),
+	)
+	c.NoError(err)
+
+	httpServer := router.newHTTPServer()
+	c.Equal(":8080", httpServer.Addr)
+	c.Equal(time.Second, httpServer.ReadHeaderTimeout)
+	c.Equal(21, httpServer.MaxHeaderBytes)
+	c.Equal(defaultReadTimeout, httpServer.ReadTimeout)
+	c.Equal(defaultWriteTimeout, httpServer.WriteTimeout)
+	c.Equal(defaultIdleTimeout, httpServer.IdleTimeout)
+}
@@ -0,0 +1,31 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/pokt-foundation/transaction-http-db/batch"
+	jsonresponse "github.com/pokt-foundation/utils-go/json-response"
+)
+
+// SinkReporter reports the health of the sinks a batch is written to
+type SinkReporter interface {
+	Name() string
+	Health() []batch.SinkHealth
+}
+
+// WithSinks exposes the health of the sinks of each batch in the admin routes
+func WithSinks(sinks ...SinkReporter) Option {
+	return func(rt *Router) {
+		rt.sinks = sinks
+	}
+}
+
+// GetSinks returns the health of the sinks of each batch, keyed by batch name
+func (rt *Router) GetSinks(w http.ResponseWriter, r *http.Request) {
+	sinks := make(map[string][]batch.SinkHealth, len(rt.sinks))
+	for _, sink := range rt.sinks {
+		sinks[sink.Name()] = sink.Health()
+	}
+
+	jsonresponse.RespondWithJSON(w, http.StatusOK, sinks)
+}
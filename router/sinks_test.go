@@ -0,0 +1,82 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/pokt-foundation/transaction-http-db/batch"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRouter_GetSinks(t *testing.T) {
+	c := require.New(t)
+
+	relayFanOut := batch.NewFanOut("relay", batch.Sink[*types.Relay]{
+		Name:  "postgres",
+		Write: func(ctx context.Context, relays []*types.Relay) error { return nil },
+	}, []batch.Sink[*types.Relay]{{
+		Name:  "archive",
+		Write: func(ctx context.Context, relays []*types.Relay) error { return errors.New("dummy") },
+	}}, zap.NewNop())
+
+	relayBatch := batch.NewBatch(21, 21, "relay", time.Hour, time.Hour, relayFanOut.Write, zap.NewNop())
+
+	serviceRecordMock := &batch.MockServiceRecordWriter{}
+	serviceRecordBatch := batch.NewBatch(21, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
+
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"gateway-key": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop(),
+		WithAdminKeys(map[string]bool{"admin-key": true}),
+		WithSinks(relayFanOut),
+	)
+	c.NoError(err)
+
+	c.NoError(relayFanOut.Write(context.Background(), []*types.Relay{{}}))
+	c.NoError(relayFanOut.Close(context.Background()))
+
+	tests := []struct {
+		name               string
+		apiKey             string
+		expectedStatusCode int
+	}{
+		{
+			name:               "Admin key",
+			apiKey:             "admin-key",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Non admin key",
+			apiKey:             "gateway-key",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodGet, "/v0/admin/sinks", nil)
+		c.NoError(err)
+
+		req.Header.Set("Authorization", tt.apiKey)
+		rr := httptest.NewRecorder()
+
+		router.router.ServeHTTP(rr, req)
+		c.Equal(tt.expectedStatusCode, rr.Code, tt.name)
+
+		if tt.expectedStatusCode != http.StatusOK {
+			continue
+		}
+
+		var sinks map[string][]batch.SinkHealth
+		c.NoError(json.Unmarshal(rr.Body.Bytes(), &sinks), tt.name)
+		c.Len(sinks["relay"], 2, tt.name)
+		c.True(sinks["relay"][0].Healthy, tt.name)
+		c.False(sinks["relay"][1].Healthy, tt.name)
+		c.Equal("dummy", sinks["relay"][1].LastError, tt.name)
+	}
+}
@@ -0,0 +1,51 @@
+package router
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/pokt-foundation/transaction-http-db/router"
+
+// TracingHandler starts a span for each request, continuing the trace of the W3C trace
+// context headers of the caller if any
+func (rt *Router) TracingHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		route := routeTemplate(r)
+
+		ctx, span := otel.Tracer(instrumentationName).Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPMethod(r.Method), semconv.HTTPRoute(route)),
+		)
+		defer span.End()
+
+		recorder := &responseRecorder{ResponseWriter: w}
+
+		h.ServeHTTP(recorder, r.WithContext(ctx))
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+
+		span.SetAttributes(semconv.HTTPStatusCode(recorder.status))
+
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+	})
+}
+
+// setSpanAttributes adds what the request handlers found out to the span of the request
+func setSpanAttributes(span trace.Span, info *requestInfo) {
+	span.SetAttributes(
+		attribute.String("api_key.label", info.label),
+		attribute.Int("request.items", info.items),
+	)
+}
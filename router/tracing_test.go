@@ -0,0 +1,110 @@
+package router
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/pokt-foundation/transaction-http-db/batch"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+)
+
+func TestRouter_Tracing(t *testing.T) {
+	c := require.New(t)
+
+	recorder := tracetest.NewSpanRecorder()
+
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	relayWriterMock := &batch.MockRelayWriter{}
+	relayBatch := batch.NewBatch(21, 21, "relay", time.Hour, time.Hour, relayWriterMock.WriteRelays, zap.NewNop())
+
+	serviceRecordMock := &batch.MockServiceRecordWriter{}
+	serviceRecordBatch := batch.NewBatch(21, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
+
+	driverMock := &storage.MockDriver{}
+	driverMock.On("ReadRelay", mock.Anything, 21).Return(types.Relay{}, errors.New("dummy"))
+
+	router, err := NewRouter(driverMock, map[string]bool{"key": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop(),
+		WithKeyLabels(map[string]string{"key": "gateway"}),
+	)
+	c.NoError(err)
+
+	tests := []struct {
+		name               string
+		method             string
+		path               string
+		body               string
+		traceparent        string
+		expectedSpanName   string
+		expectedStatusCode int
+		expectedItems      int
+	}{
+		{
+			name:               "Continued trace",
+			method:             http.MethodPost,
+			path:               "/v0/relays",
+			body:               `[{}, {}]`,
+			traceparent:        "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+			expectedSpanName:   "POST /v0/relays",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedItems:      2,
+		},
+		{
+			name:               "New trace",
+			method:             http.MethodGet,
+			path:               "/v0/relay/21",
+			expectedSpanName:   "GET /v0/relay/{id}",
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+		c.NoError(err)
+
+		req.Header.Set("Authorization", "key")
+		if tt.traceparent != "" {
+			req.Header.Set("traceparent", tt.traceparent)
+		}
+
+		rr := httptest.NewRecorder()
+		router.router.ServeHTTP(rr, req)
+		c.Equal(tt.expectedStatusCode, rr.Code, tt.name)
+
+		spans := recorder.Ended()
+		span := spans[len(spans)-1]
+
+		c.Equal(tt.expectedSpanName, span.Name(), tt.name)
+		c.Contains(span.Attributes(), attribute.Int("http.status_code", tt.expectedStatusCode), tt.name)
+		c.Contains(span.Attributes(), attribute.Int("request.items", tt.expectedItems), tt.name)
+		c.Contains(span.Attributes(), attribute.String("api_key.label", "gateway"), tt.name)
+
+		if tt.traceparent == "" {
+			c.False(span.Parent().IsValid(), tt.name)
+			continue
+		}
+
+		c.Equal("0af7651916cd43dd8448eb211c80319c", span.SpanContext().TraceID().String(), tt.name)
+		c.Equal("b7ad6b7169203331", span.Parent().SpanID().String(), tt.name)
+		c.True(span.Parent().IsRemote(), tt.name)
+	}
+}
@@ -0,0 +1,135 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pokt-foundation/transaction-http-db/usage"
+	jsonresponse "github.com/pokt-foundation/utils-go/json-response"
+)
+
+const defaultUsageReportRange = 24 * time.Hour
+
+// UsageReport is the response of the usage endpoint
+type UsageReport struct {
+	From    time.Time      `json:"from"`
+	To      time.Time      `json:"to"`
+	Records []usage.Record `json:"records"`
+}
+
+type countingReadCloser struct {
+	io.ReadCloser
+	bytes int64
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.bytes += int64(n)
+
+	return n, err
+}
+
+// usageRequest tells the usage handler how a request was handled by the inner ones
+type usageRequest struct {
+	rejected bool
+}
+
+// UsageHandler accounts each request of an API key and the bytes of its body, or the request
+// as rejected when the requests rate limit turned it down, so it is not counted as both
+func (rt *Router) UsageHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		label := keyLabelFromContext(r.Context())
+		if rt.usageTracker == nil || label == "" {
+			h.ServeHTTP(w, r)
+
+			return
+		}
+
+		body := &countingReadCloser{ReadCloser: r.Body}
+		r.Body = body
+
+		request := &usageRequest{}
+
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), usageRequestCtxKey, request)))
+
+		if request.rejected {
+			rt.usageTracker.AddRejectedRequest(label)
+			return
+		}
+
+		rt.usageTracker.AddRequest(label, body.bytes)
+	})
+}
+
+// recordRejectedRequest accounts the request as rejected before its items were read
+func recordRejectedRequest(r *http.Request) {
+	if request, ok := r.Context().Value(usageRequestCtxKey).(*usageRequest); ok {
+		request.rejected = true
+	}
+}
+
+// recordItems accounts the items of the request API key that were accepted or rejected,
+// and the items handled by the request for its access log
+func (rt *Router) recordItems(r *http.Request, accepted, rejected int) {
+	if info := requestInfoFromContext(r.Context()); info != nil {
+		info.items += accepted + rejected
+	}
+
+	label := keyLabelFromContext(r.Context())
+	if rt.usageTracker == nil || label == "" {
+		return
+	}
+
+	rt.usageTracker.AddItems(label, accepted, rejected)
+}
+
+func parseTimeParam(r *http.Request, name string, defaultValue time.Time) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s parameter: %w", name, err)
+	}
+
+	return parsed, nil
+}
+
+func (rt *Router) GetUsage(w http.ResponseWriter, r *http.Request) {
+	if rt.usageTracker == nil {
+		jsonresponse.RespondWithError(w, http.StatusNotFound, "usage accounting is not enabled")
+		return
+	}
+
+	now := time.Now()
+
+	to, err := parseTimeParam(r, "to", now)
+	if err != nil {
+		rt.logError(r.Context(), fmt.Errorf("GetUsage in params parsing failed: %w", err))
+		jsonresponse.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	from, err := parseTimeParam(r, "from", to.Add(-defaultUsageReportRange))
+	if err != nil {
+		rt.logError(r.Context(), fmt.Errorf("GetUsage in params parsing failed: %w", err))
+		jsonresponse.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !from.Before(to) {
+		jsonresponse.RespondWithError(w, http.StatusBadRequest, "from parameter must be before to parameter")
+		return
+	}
+
+	jsonresponse.RespondWithJSON(w, http.StatusOK, UsageReport{
+		From:    from,
+		To:      to,
+		Records: rt.usageTracker.Report(from, to, r.URL.Query().Get("label")),
+	})
+}
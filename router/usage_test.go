@@ -0,0 +1,157 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/pokt-foundation/transaction-http-db/batch"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	"github.com/pokt-foundation/transaction-http-db/usage"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRouter_GetUsage(t *testing.T) {
+	c := require.New(t)
+
+	relayWriterMock := &batch.MockRelayWriter{}
+	relayBatch := batch.NewBatch(21, 21, "relay", time.Hour, time.Hour, relayWriterMock.WriteRelays, zap.NewNop())
+
+	serviceRecordMock := &batch.MockServiceRecordWriter{}
+	serviceRecordBatch := batch.NewBatch(21, 21, "service_record", time.Hour, time.Hour, serviceRecordMock.WriteServiceRecords, zap.NewNop())
+
+	tracker, err := usage.NewTracker(nil, 0, zap.NewNop())
+	c.NoError(err)
+
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"gateway-key": true}, "8080", relayBatch, serviceRecordBatch, zap.NewNop(),
+		WithAdminKeys(map[string]bool{"admin-key": true}),
+		WithKeyLabels(map[string]string{"gateway-key": "gateway", "admin-key": "admin"}),
+		WithUsageTracker(tracker),
+	)
+	c.NoError(err)
+
+	relaysToSend, err := json.Marshal([]*types.Relay{{
+		PoktChainID:              "21",
+		EndpointID:               "21",
+		SessionKey:               "21",
+		ProtocolAppPublicKey:     "21",
+		RelaySourceURL:           "pablo.com",
+		PoktNodeAddress:          "21",
+		PoktNodeDomain:           "pablos.com",
+		PoktNodePublicKey:        "aaa",
+		RelayStartDatetime:       time.Now(),
+		RelayReturnDatetime:      time.Now(),
+		RelayRoundtripTime:       1,
+		RelayChainMethodIDs:      []string{"get_height"},
+		RelayDataSize:            21,
+		RelayPortalTripTime:      21,
+		RelayNodeTripTime:        21,
+		RelayURLIsPublicEndpoint: false,
+		PortalRegionName:         "La Colombia",
+		RequestID:                "21",
+		PoktTxID:                 "21",
+	}, {}})
+	c.NoError(err)
+
+	req, err := http.NewRequest(http.MethodPost, "/v0/relays", bytes.NewBuffer(relaysToSend))
+	c.NoError(err)
+
+	req.Header.Set("Authorization", "gateway-key")
+	rr := httptest.NewRecorder()
+	router.router.ServeHTTP(rr, req)
+	c.Equal(http.StatusBadRequest, rr.Code)
+
+	tests := []struct {
+		name               string
+		query              string
+		expectedStatusCode int
+		expectedRecords    int
+	}{
+		{
+			name:               "Default range",
+			expectedStatusCode: http.StatusOK,
+			expectedRecords:    1,
+		},
+		{
+			name:               "Filtered by label",
+			query:              "?label=admin",
+			expectedStatusCode: http.StatusOK,
+			expectedRecords:    1,
+		},
+		{
+			name:               "Range without usage",
+			query:              "?from=2023-10-21T00:00:00Z&to=2023-10-22T00:00:00Z",
+			expectedStatusCode: http.StatusOK,
+			expectedRecords:    0,
+		},
+		{
+			name:               "Wrong time",
+			query:              "?from=yesterday",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "From after to",
+			query:              "?from=2023-10-22T00:00:00Z&to=2023-10-21T00:00:00Z",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodGet, "/v0/admin/usage"+tt.query, nil)
+		c.NoError(err)
+
+		req.Header.Set("Authorization", "admin-key")
+		rr := httptest.NewRecorder()
+		router.router.ServeHTTP(rr, req)
+		c.Equal(tt.expectedStatusCode, rr.Code, tt.name)
+
+		if tt.expectedStatusCode != http.StatusOK {
+			continue
+		}
+
+		var report UsageReport
+		c.NoError(json.Unmarshal(rr.Body.Bytes(), &report))
+		c.Len(report.Records, tt.expectedRecords, tt.name)
+	}
+
+	records := tracker.Report(time.Now().Add(-time.Hour), time.Now().Add(time.Hour), "gateway")
+	c.Len(records, 1)
+	c.Equal(uint64(1), records[0].Requests)
+	c.Equal(uint64(1), records[0].ItemsAccepted)
+	c.Equal(uint64(1), records[0].ItemsRejected)
+	c.Equal(uint64(len(relaysToSend)), records[0].Bytes)
+}
+
+func TestRouter_UsageHandler_rateLimited(t *testing.T) {
+	c := require.New(t)
+
+	tracker, err := usage.NewTracker(nil, 0, zap.NewNop())
+	c.NoError(err)
+
+	router, err := NewRouter(&storage.MockDriver{}, map[string]bool{"gateway-key": true}, "8080", nil, nil, zap.NewNop(),
+		WithKeyLabels(map[string]string{"gateway-key": "gateway"}),
+		WithRateLimiter(NewRateLimiter(RateLimit{RequestsPerSecond: 0.001, RequestsBurst: 1}, nil)),
+		WithUsageTracker(tracker),
+	)
+	c.NoError(err)
+
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest(http.MethodPost, "/v0/relays", bytes.NewBufferString("{"))
+		c.NoError(err)
+
+		req.Header.Set("Authorization", "gateway-key")
+		router.router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// The rejected request is only counted as such
+	records := tracker.Report(time.Now().Add(-time.Hour), time.Now().Add(time.Hour), "gateway")
+	c.Len(records, 1)
+	c.Equal(uint64(1), records[0].Requests)
+	c.Equal(uint64(1), records[0].RequestsRejected)
+	c.Equal(uint64(1), records[0].Bytes)
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/pokt-foundation/transaction-http-db/batch"
+	"github.com/pokt-foundation/transaction-http-db/config"
+	"github.com/pokt-foundation/transaction-http-db/router"
+	"github.com/pokt-foundation/transaction-http-db/sink/archive"
+	"github.com/pokt-foundation/transaction-http-db/sink/kafka"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	"github.com/pokt-foundation/transaction-http-db/storage/cache"
+	"github.com/pokt-foundation/transaction-http-db/tracing"
+	"github.com/pokt-foundation/transaction-http-db/usage"
+	"go.uber.org/zap"
+)
+
+// reloadOnSIGHUP reloads the settings every time the process gets a SIGHUP, until ctx is done
+func reloadOnSIGHUP(ctx context.Context, reloader *config.Reloader) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-hup:
+			// The reloader logs the outcome
+			_, _ = reloader.Reload()
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// schemaCheckInterval is how often the schema check is retried while the database can't be reached
+const schemaCheckInterval = 10 * time.Second
+
+var errSchemaUnchecked = errors.New("database schema not checked yet")
+
+// checkSchema checks the database schema is supported. An unreachable database is
+// reported as errSchemaUnchecked.
+func checkSchema(ctx context.Context, cfg config.Config, driver storage.Driver) error {
+	checkCtx, cancel := context.WithTimeout(ctx, cfg.DBTimeout.Duration())
+	defer cancel()
+
+	err := storage.CheckSchema(checkCtx, driver)
+	if err != nil && !errors.Is(err, storage.ErrIncompatibleSchema) {
+		return fmt.Errorf("%w: %s", errSchemaUnchecked, err)
+	}
+
+	return err
+}
+
+// retrySchemaCheck checks the schema every interval until the database can be reached,
+// reporting the outcome to setResult, or until ctx is done. An incompatible schema only
+// fails the readiness whatever the check mode, as exiting while serving would lose the
+// items of the batches.
+func retrySchemaCheck(ctx context.Context, cfg config.Config, driver storage.Driver, interval time.Duration,
+	setResult func(error), log *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		err := checkSchema(ctx, cfg, driver)
+		if errors.Is(err, errSchemaUnchecked) {
+			log.Warn("Database schema could not be checked", zap.Error(err))
+			continue
+		}
+
+		if err != nil {
+			log.Error("Database schema check failed, the service won't be ready", zap.Error(err))
+		} else {
+			log.Info("Database schema checked")
+		}
+
+		setResult(err)
+
+		return
+	}
+}
+
+// archiveRotateInterval is how often the archive files are checked for their max age
+const archiveRotateInterval = time.Minute
+
+// rotateArchives closes the archive files that reached their max age every interval, so the
+// files of a writer that stopped receiving items are not left open until its next write,
+// until ctx is done
+func rotateArchives(ctx context.Context, interval time.Duration, writers []interface{ Rotate() error }, log *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		for _, writer := range writers {
+			if err := writer.Rotate(); err != nil {
+				log.Error(fmt.Sprintf("Failed to rotate archive files: %v", err))
+			}
+		}
+	}
+}
+
+// primarySink returns the sink of the storage backend, which is the one whose
+// errors fail the batch save
+func primarySink[T batch.Validator](cfg config.Config, write func(context.Context, []T) error) batch.Sink[T] {
+	return batch.Sink[T]{
+		Name:         cfg.Storage.Backend,
+		Write:        write,
+		MaxRetries:   cfg.Sinks.PrimaryMaxRetries,
+		RetryBackoff: cfg.Sinks.RetryBackoff.Duration(),
+	}
+}
+
+// secondarySink returns a sink that is written in the background with the secondary sink settings
+func secondarySink[T batch.Validator](cfg config.Config, name string, write func(context.Context, []T) error) batch.Sink[T] {
+	return batch.Sink[T]{
+		Name:         name,
+		Write:        write,
+		Timeout:      cfg.Sinks.Timeout.Duration(),
+		MaxRetries:   cfg.Sinks.MaxRetries,
+		RetryBackoff: cfg.Sinks.RetryBackoff.Duration(),
+		QueueSize:    cfg.Sinks.QueueSize,
+	}
+}
+
+func runServe(args []string) error {
+	flags, configPath := newFlagSet("serve", "Run the HTTP server, the default when no command is given.")
+	_ = flags.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg := loadConfig(*configPath, config.Config.Validate)
+
+	log, level := newLogger(cfg)
+	runtimeLevel := router.NewLogLevel(level, log)
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.TracingConfig(version))
+	if err != nil {
+		log.Fatal("Failed to set up tracing", zap.Error(err))
+	}
+
+	reloader := config.NewReloader(cfg, *configPath, log)
+
+	driver, cleanup, err := openStorage(context.Background(), cfg)
+	if err != nil {
+		log.Fatal("Failed to open storage", zap.Error(err))
+	}
+
+	defer func() {
+		if err := cleanup(); err != nil {
+			log.Error(fmt.Sprintf("Failed to clean up: %v", err))
+		}
+	}()
+
+	var routerOptions []router.Option
+
+	// An unreachable database doesn't stop the service, the check being retried in the
+	// background while the readiness reports it as not done
+	var schemaUnchecked bool
+	schemaDriver := driver
+
+	if cfg.SchemaCheck == config.SchemaCheckOff {
+		log.Warn("Database schema check disabled")
+	} else {
+		err := checkSchema(ctx, cfg, schemaDriver)
+
+		switch {
+		case errors.Is(err, errSchemaUnchecked):
+			log.Error("Database schema could not be checked, retrying in the background", zap.Error(err))
+			schemaUnchecked = true
+
+		case err != nil && cfg.SchemaCheck == config.SchemaCheckFail:
+			log.Fatal("Database schema check failed", zap.Error(err))
+
+		case err != nil:
+			log.Error("Database schema check failed, the service won't be ready", zap.Error(err))
+		}
+
+		routerOptions = append(routerOptions, router.WithSchemaCheck(err))
+	}
+
+	if cfg.Cache.Size > 0 {
+		driver = cache.New(driver, cfg.CacheConfig())
+	}
+
+	var (
+		relaySinks         []batch.Sink[*types.Relay]
+		serviceRecordSinks []batch.Sink[*types.ServiceRecord]
+		closers            []func() error
+	)
+
+	if cfg.Archive.Dir != "" {
+		relayArchive, err := archive.NewRelayWriter(cfg.ArchiveConfig(), log)
+		if err != nil {
+			log.Fatal("Failed to create relay archive", zap.Error(err))
+		}
+
+		serviceRecordArchive, err := archive.NewServiceRecordWriter(cfg.ArchiveConfig(), log)
+		if err != nil {
+			log.Fatal("Failed to create service record archive", zap.Error(err))
+		}
+
+		relaySinks = append(relaySinks, secondarySink(cfg, "archive", relayArchive.Write))
+		serviceRecordSinks = append(serviceRecordSinks, secondarySink(cfg, "archive", serviceRecordArchive.Write))
+		closers = append(closers, relayArchive.Close, serviceRecordArchive.Close)
+
+		if maxFileAge := cfg.Archive.MaxFileAge.Duration(); maxFileAge > 0 {
+			interval := archiveRotateInterval
+			if maxFileAge < interval {
+				interval = maxFileAge
+			}
+
+			go rotateArchives(ctx, interval, []interface{ Rotate() error }{relayArchive, serviceRecordArchive}, log)
+		}
+	}
+
+	if len(cfg.Kafka.Brokers) > 0 {
+		relayConfig, serviceRecordConfig := cfg.KafkaConfigs()
+
+		relayProducer, err := kafka.NewRelayProducer(relayConfig)
+		if err != nil {
+			log.Fatal("Failed to create relay producer", zap.Error(err))
+		}
+
+		serviceRecordProducer, err := kafka.NewServiceRecordProducer(serviceRecordConfig)
+		if err != nil {
+			log.Fatal("Failed to create service record producer", zap.Error(err))
+		}
+
+		relaySinks = append(relaySinks, secondarySink(cfg, "kafka", relayProducer.Write))
+		serviceRecordSinks = append(serviceRecordSinks, secondarySink(cfg, "kafka", serviceRecordProducer.Write))
+		closers = append(closers, relayProducer.Close, serviceRecordProducer.Close)
+	}
+
+	var (
+		relayOptions         []batch.FanOutOption[*types.Relay]
+		serviceRecordOptions []batch.FanOutOption[*types.ServiceRecord]
+	)
+
+	if cfg.Sinks.DeadLetterDir != "" {
+		relayDeadLetter, err := archive.NewRelayWriter(cfg.DeadLetterConfig(), log)
+		if err != nil {
+			log.Fatal("Failed to create relay dead letter spool", zap.Error(err))
+		}
+
+		serviceRecordDeadLetter, err := archive.NewServiceRecordWriter(cfg.DeadLetterConfig(), log)
+		if err != nil {
+			log.Fatal("Failed to create service record dead letter spool", zap.Error(err))
+		}
+
+		relayOptions = append(relayOptions, batch.WithDeadLetter(relayDeadLetter.Write))
+		serviceRecordOptions = append(serviceRecordOptions, batch.WithDeadLetter(serviceRecordDeadLetter.Write))
+		closers = append(closers, relayDeadLetter.Close, serviceRecordDeadLetter.Close)
+	}
+
+	relayFanOut := batch.NewFanOut("relay", primarySink(cfg, driver.WriteRelays), relaySinks, log, relayOptions...)
+	serviceRecordFanOut := batch.NewFanOut("service_record", primarySink(cfg, driver.WriteServiceRecords), serviceRecordSinks, log, serviceRecordOptions...)
+
+	relayBatch := batch.NewBatch(cfg.Batches.MaxRelayBatchSize, cfg.ChanSize, "relay", cfg.Batches.MaxRelayBatchDuration.Duration(),
+		cfg.DBTimeout.Duration(), relayFanOut.Write, log, batch.WithItemTime(func(relay *types.Relay) time.Time { return relay.RelayReturnDatetime }))
+	serviceRecordBatch := batch.NewBatch(cfg.Batches.MaxServiceRecordBatchSize, cfg.ChanSize, "service_record", cfg.Batches.MaxServiceRecordBatchDuration.Duration(),
+		cfg.DBTimeout.Duration(), serviceRecordFanOut.Write, log)
+
+	// Usage is only kept in memory when no file is set
+	var usageStore usage.Store
+	if cfg.Usage.File != "" {
+		usageStore = usage.NewFileStore(cfg.Usage.File)
+	}
+
+	usageTracker, err := usage.NewTracker(usageStore, cfg.UsageRetention(), log)
+	if err != nil {
+		log.Fatal("Failed to load usage", zap.Error(err))
+	}
+
+	// The usage keeps being counted while the server drains, so it is only flushed for the
+	// last time once the server is shut down
+	usageCtx, stopUsage := context.WithCancel(context.Background())
+	defer stopUsage()
+
+	usageDone := make(chan struct{})
+	go func() {
+		defer close(usageDone)
+		usageTracker.Run(usageCtx, cfg.Usage.FlushInterval.Duration())
+	}()
+
+	apiKeys, adminKeys, keyLabels := cfg.Keys()
+	rateLimiter := router.NewRateLimiter(cfg.DefaultRateLimit(), cfg.LabelRateLimits())
+
+	routerOptions = append(routerOptions,
+		router.WithAdminKeys(adminKeys),
+		router.WithKeyLabels(keyLabels),
+		router.WithRateLimiter(rateLimiter),
+		router.WithUsageTracker(usageTracker),
+		router.WithReadiness(driver, cfg.ReadinessConfig()),
+		router.WithServerConfig(cfg.ServerConfig()),
+		router.WithSinks(relayFanOut, serviceRecordFanOut),
+		router.WithReloader(reloader),
+		router.WithLogLevel(runtimeLevel),
+		router.WithDiagnostics(cfg.DiagnosticsConfig()),
+	)
+
+	router, err := router.NewRouter(driver, apiKeys, cfg.Port, relayBatch, serviceRecordBatch, log, routerOptions...)
+	if err != nil {
+		log.Fatal("Failed to create router", zap.Error(err))
+	}
+
+	reloader.OnReload(func(cfg config.Config) {
+		runtimeLevel.SetBase(logLevel(cfg.Debug))
+		relayBatch.SetLimits(cfg.Batches.MaxRelayBatchSize, cfg.Batches.MaxRelayBatchDuration.Duration())
+		serviceRecordBatch.SetLimits(cfg.Batches.MaxServiceRecordBatchSize, cfg.Batches.MaxServiceRecordBatchDuration.Duration())
+		router.SetKeys(cfg.Keys())
+		rateLimiter.SetLimits(cfg.DefaultRateLimit(), cfg.LabelRateLimits())
+	})
+
+	go reloadOnSIGHUP(ctx, reloader)
+
+	if schemaUnchecked {
+		go retrySchemaCheck(ctx, cfg, schemaDriver, schemaCheckInterval, router.SetSchemaCheck, log)
+	}
+
+	router.RunServer(ctx)
+
+	stopUsage()
+	<-usageDone
+
+	// The batches were closed on shutdown, their last items saved, so what is left is for
+	// the secondary sinks to catch up
+	closeCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout.Duration())
+	defer cancel()
+
+	for _, fanOut := range []interface{ Close(context.Context) error }{relayFanOut, serviceRecordFanOut} {
+		if err := fanOut.Close(closeCtx); err != nil {
+			log.Error(fmt.Sprintf("Failed to close sinks: %v", err))
+		}
+	}
+
+	for _, closer := range closers {
+		if err := closer(); err != nil {
+			log.Error(fmt.Sprintf("Failed to close sink: %v", err))
+		}
+	}
+
+	// The spans of the last saves are exported after the sinks are closed
+	if err := shutdownTracing(closeCtx); err != nil {
+		log.Error(fmt.Sprintf("Failed to export spans: %v", err))
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-http-db/config"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// schemaDriver fails its schema checks with the errors in turn, then succeeds
+type schemaDriver struct {
+	storage.MockDriver
+	errs   []error
+	checks atomic.Int32
+}
+
+func (d *schemaDriver) CheckSchema(ctx context.Context) error {
+	check := int(d.checks.Add(1)) - 1
+	if check < len(d.errs) {
+		return d.errs[check]
+	}
+
+	return nil
+}
+
+func TestRetrySchemaCheck(t *testing.T) {
+	c := require.New(t)
+
+	errUnreachable := errors.New("connection refused")
+
+	tests := []struct {
+		name           string
+		errs           []error
+		expectedErr    error
+		expectedChecks int32
+	}{
+		{
+			name:           "Database reachable after a few retries",
+			errs:           []error{errUnreachable, errUnreachable},
+			expectedChecks: 3,
+		},
+		{
+			name:           "Incompatible schema once the database is reachable",
+			errs:           []error{errUnreachable, storage.ErrIncompatibleSchema},
+			expectedErr:    storage.ErrIncompatibleSchema,
+			expectedChecks: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		// A schema found incompatible while serving doesn't exit, even in the fail mode
+		cfg := config.Config{DBTimeout: config.Seconds(1), SchemaCheck: config.SchemaCheckFail}
+		driver := &schemaDriver{errs: tt.errs}
+
+		results := make(chan error, 1)
+		retrySchemaCheck(context.Background(), cfg, driver, time.Millisecond, func(err error) {
+			results <- err
+		}, zap.NewNop())
+
+		c.ErrorIs(<-results, tt.expectedErr, tt.name)
+		c.Equal(tt.expectedChecks, driver.checks.Load(), tt.name)
+	}
+
+	// It gives up once ctx is done, the schema staying unchecked
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	retrySchemaCheck(ctx, config.Config{DBTimeout: config.Seconds(1)}, &schemaDriver{}, time.Hour, func(err error) {
+		c.Fail("unexpected schema check result")
+	}, zap.NewNop())
+}
+
+func TestCheckSchema(t *testing.T) {
+	c := require.New(t)
+
+	cfg := config.Config{DBTimeout: config.Seconds(1)}
+
+	c.NoError(checkSchema(context.Background(), cfg, &schemaDriver{}))
+	c.ErrorIs(checkSchema(context.Background(), cfg, &schemaDriver{errs: []error{errors.New("timeout")}}), errSchemaUnchecked)
+	c.ErrorIs(checkSchema(context.Background(), cfg, &schemaDriver{errs: []error{storage.ErrIncompatibleSchema}}), storage.ErrIncompatibleSchema)
+}
+
+// rotator counts its rotations, failing them when err is set
+type rotator struct {
+	rotations atomic.Int32
+	err       error
+}
+
+func (r *rotator) Rotate() error {
+	r.rotations.Add(1)
+	return r.err
+}
+
+func TestRotateArchives(t *testing.T) {
+	c := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// A writer failing to rotate doesn't stop the others being rotated
+	failing, rotating := &rotator{err: errors.New("disk full")}, &rotator{}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rotateArchives(ctx, time.Millisecond, []interface{ Rotate() error }{failing, rotating}, zap.NewNop())
+	}()
+
+	c.Eventually(func() bool { return rotating.rotations.Load() >= 2 }, time.Second, time.Millisecond)
+	c.Positive(failing.rotations.Load())
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,449 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"go.uber.org/zap"
+)
+
+// Format is the encoding of the archive files
+type Format string
+
+const (
+	// FormatNDJSON writes one JSON document per line, gzip compressed
+	FormatNDJSON Format = "ndjson"
+	// FormatParquet writes zstd compressed Parquet files
+	FormatParquet Format = "parquet"
+)
+
+const (
+	partialSuffix  = ".partial"
+	dateLayout     = "2006-01-02"
+	fileTimeLayout = "20060102T150405Z"
+	unknownChain   = "unknown"
+)
+
+var (
+	errMissingDir    = errors.New("the archive directory must be set")
+	errInvalidFormat = errors.New("invalid archive format, must be ndjson or parquet")
+	errClosed        = errors.New("archive writer is closed")
+)
+
+// Config holds the settings of an archive writer
+type Config struct {
+	// Dir is the root directory of the archive, each writer using a subdirectory named after it
+	Dir    string
+	Format Format
+	// MaxFileBytes rotates a file once it reaches this size, 0 meaning no limit
+	MaxFileBytes int64
+	// MaxFileAge rotates a file once it has been open this long, 0 meaning no limit
+	MaxFileAge time.Duration
+	// FilePerWrite closes the files once each write is done, every batch being a file of
+	// its own that can be read right away
+	FilePerWrite bool
+}
+
+// Validate checks the config can be used to create a writer
+func (c Config) Validate() error {
+	if c.Dir == "" {
+		return errMissingDir
+	}
+
+	if c.Format != FormatNDJSON && c.Format != FormatParquet {
+		return errInvalidFormat
+	}
+
+	return nil
+}
+
+// PartitionFunc returns the date and chain an item is archived under, a zero
+// date meaning the time it is written
+type PartitionFunc[T any] func(item T) (date time.Time, chain string)
+
+// Writer archives batches of items, keeping a file open per partition until it is rotated.
+// Files are written with a .partial suffix and renamed once closed, when they are also
+// added to the manifest, so readers only need to look at the files the manifest lists.
+// A batch is written in full or not at all, so a failed write can be retried, and the
+// partial files a crash leaves behind are recovered when the next writer is created.
+type Writer[T any] struct {
+	name      string
+	dir       string
+	config    Config
+	partition PartitionFunc[T]
+	model     any
+	files     map[string]*file
+	manifest  *manifest
+	mu        sync.Mutex
+	seq       int
+	closed    bool
+	now       func() time.Time
+	log       *zap.Logger
+}
+
+// NewWriter returns a Writer archiving under the name subdirectory of the config dir, where
+// model is a value of the archived type, used to build the Parquet schema
+func NewWriter[T any](name string, config Config, model T, partition PartitionFunc[T], logger *zap.Logger) (*Writer[T], error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(config.Dir, name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	manifest, err := openManifest(filepath.Join(dir, manifestFileName))
+	if err != nil {
+		return nil, fmt.Errorf("error loading %s archive manifest: %w", name, err)
+	}
+
+	w := &Writer[T]{
+		name:      name,
+		dir:       dir,
+		config:    config,
+		partition: partition,
+		model:     model,
+		files:     make(map[string]*file),
+		manifest:  manifest,
+		now:       time.Now,
+		log:       logger,
+	}
+
+	if err := w.recoverPartials(); err != nil {
+		return nil, fmt.Errorf("error recovering %s archive partial files: %w", name, err)
+	}
+
+	return w, nil
+}
+
+// NewRelayWriter returns a Writer of relays partitioned by their start date and chain
+func NewRelayWriter(config Config, logger *zap.Logger) (*Writer[*types.Relay], error) {
+	return NewWriter("relay", config, &types.Relay{}, func(relay *types.Relay) (time.Time, string) {
+		return relay.RelayStartDatetime, relay.PoktChainID
+	}, logger)
+}
+
+// NewServiceRecordWriter returns a Writer of service records partitioned by the date
+// they are written and their chain
+func NewServiceRecordWriter(config Config, logger *zap.Logger) (*Writer[*types.ServiceRecord], error) {
+	return NewWriter("service_record", config, &types.ServiceRecord{}, func(serviceRecord *types.ServiceRecord) (time.Time, string) {
+		return serviceRecord.CreatedAt, serviceRecord.PoktChainID
+	}, logger)
+}
+
+// Write appends the items to the files of their partitions, rotating the files that
+// are due. Its signature matches the batch writers so it can be given to a batch.
+// When it fails none of the items are kept, so retrying doesn't archive them twice.
+func (w *Writer[T]) Write(ctx context.Context, items []T) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return errClosed
+	}
+
+	now := w.now()
+
+	// Files of partitions that stopped receiving items are rotated here by age
+	if err := w.rotateExpired(now); err != nil {
+		return err
+	}
+
+	partitionItems := make(map[partition][]T)
+	partitions := []partition{}
+	for _, item := range items {
+		p := w.partitionOf(item, now)
+		if _, ok := partitionItems[p]; !ok {
+			partitions = append(partitions, p)
+		}
+
+		partitionItems[p] = append(partitionItems[p], item)
+	}
+
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].dir() < partitions[j].dir()
+	})
+
+	written := []*file{}
+	for _, p := range partitions {
+		if err := ctx.Err(); err != nil {
+			w.rollback(written)
+			return err
+		}
+
+		f, err := w.writePartition(p, partitionItems[p], now)
+		if f != nil {
+			written = append(written, f)
+		}
+		if err != nil {
+			w.rollback(written)
+			return fmt.Errorf("error writing %s archive partition %s: %w", w.name, p.dir(), err)
+		}
+	}
+
+	for _, f := range written {
+		f.commit()
+	}
+
+	// The items are archived by now, so a file failing to close is left for the next
+	// writer to recover instead of failing the write
+	for _, f := range written {
+		if !w.due(f, now) {
+			continue
+		}
+
+		if err := w.closeFile(f.partition.dir()); err != nil {
+			w.log.Error(fmt.Sprintf("error closing %s archive file %s", w.name, f.path), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// rollback drops what the failed write added to the files, closing and removing the
+// files that can't be rolled back
+func (w *Writer[T]) rollback(written []*file) {
+	for _, f := range written {
+		ok, err := f.rollback()
+		if ok && f.records > 0 {
+			continue
+		}
+
+		if err != nil {
+			w.log.Error(fmt.Sprintf("error rolling back %s archive file %s", w.name, f.path), zap.Error(err))
+		}
+
+		delete(w.files, f.partition.dir())
+		f.osFile.Close()
+
+		if err := os.Remove(filepath.Join(w.dir, f.path+partialSuffix)); err != nil {
+			w.log.Error(fmt.Sprintf("error removing %s archive file %s", w.name, f.path), zap.Error(err))
+		}
+
+		if f.records > 0 {
+			w.log.Error(fmt.Sprintf("%s archive file %s dropped after a failed write", w.name, f.path),
+				zap.Int("records", f.records))
+		}
+	}
+}
+
+// Rotate closes the files that reached their max age, which is otherwise only done on the
+// next write, so it is called periodically for the files to be closed while no items come
+func (w *Writer[T]) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.rotateExpired(w.now())
+}
+
+// Close closes all the open files, after which the writer can't be used
+func (w *Writer[T]) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+
+	w.closed = true
+
+	var errs []error
+	for key := range w.files {
+		if err := w.closeFile(key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// partition is the date and chain an archive file holds the items of
+type partition struct {
+	date  string
+	chain string
+}
+
+func (p partition) dir() string {
+	return filepath.Join("date="+p.date, "chain="+url.PathEscape(p.chain))
+}
+
+func (w *Writer[T]) partitionOf(item T, now time.Time) partition {
+	date, chain := w.partition(item)
+	if date.IsZero() {
+		date = now
+	}
+
+	if chain == "" {
+		chain = unknownChain
+	}
+
+	return partition{date: date.UTC().Format(dateLayout), chain: chain}
+}
+
+// writePartition writes the items to the file of the partition, returning the file
+// once anything may have been written to it
+func (w *Writer[T]) writePartition(p partition, items []T, now time.Time) (*file, error) {
+	key := p.dir()
+
+	f, ok := w.files[key]
+	if ok && w.due(f, now) {
+		if err := w.closeFile(key); err != nil {
+			return nil, err
+		}
+
+		ok = false
+	}
+
+	if !ok {
+		var err error
+		f, err = w.openFile(p, now)
+		if err != nil {
+			return nil, err
+		}
+
+		w.files[key] = f
+	}
+
+	for _, item := range items {
+		if err := f.encoder.encode(item); err != nil {
+			return f, err
+		}
+	}
+
+	if err := f.encoder.flush(); err != nil {
+		return f, err
+	}
+
+	f.pending = len(items)
+
+	return f, nil
+}
+
+// due reports whether the file reached its max size or age, or is never kept open
+func (w *Writer[T]) due(f *file, now time.Time) bool {
+	if w.config.FilePerWrite {
+		return true
+	}
+
+	if w.config.MaxFileBytes > 0 && f.size >= w.config.MaxFileBytes {
+		return true
+	}
+
+	return w.config.MaxFileAge > 0 && now.Sub(f.openedAt) >= w.config.MaxFileAge
+}
+
+func (w *Writer[T]) rotateExpired(now time.Time) error {
+	if w.config.MaxFileAge <= 0 {
+		return nil
+	}
+
+	for key, f := range w.files {
+		if now.Sub(f.openedAt) >= w.config.MaxFileAge {
+			if err := w.closeFile(key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (w *Writer[T]) openFile(p partition, now time.Time) (*file, error) {
+	key := p.dir()
+
+	if err := os.MkdirAll(filepath.Join(w.dir, key), 0o755); err != nil {
+		return nil, err
+	}
+
+	// Names are only unique within a writer, so those of the files a previous one left are skipped
+	var name string
+	for {
+		w.seq++
+		name = fmt.Sprintf("%s-%s-%06d%s", w.name, now.UTC().Format(fileTimeLayout), w.seq, extension(w.config.Format))
+
+		if _, err := os.Stat(filepath.Join(w.dir, key, name)); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+	}
+
+	f := &file{
+		partition: p,
+		path:      filepath.Join(key, name),
+		openedAt:  now,
+	}
+
+	osFile, err := os.Create(filepath.Join(w.dir, f.path+partialSuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	f.osFile = osFile
+	f.counter = &countingWriter{w: osFile}
+
+	switch w.config.Format {
+	case FormatParquet:
+		f.encoder = newParquetEncoder(f.counter, w.model)
+	default:
+		f.encoder = newNDJSONEncoder(f.counter)
+	}
+
+	return f, nil
+}
+
+// closeFile finishes the file of the partition, moves it to its final name and adds it to the manifest
+func (w *Writer[T]) closeFile(key string) error {
+	f := w.files[key]
+	delete(w.files, key)
+
+	if err := f.encoder.close(); err != nil {
+		f.osFile.Close()
+		return err
+	}
+
+	if err := f.osFile.Close(); err != nil {
+		return err
+	}
+
+	partialPath := filepath.Join(w.dir, f.path+partialSuffix)
+	if err := os.Rename(partialPath, filepath.Join(w.dir, f.path)); err != nil {
+		return err
+	}
+
+	closedAt := w.now()
+
+	err := w.manifest.add(ManifestEntry{
+		Path:     filepath.ToSlash(f.path),
+		Date:     f.partition.date,
+		Chain:    f.partition.chain,
+		Format:   w.config.Format,
+		Records:  f.records,
+		Bytes:    f.counter.n,
+		OpenedAt: f.openedAt,
+		ClosedAt: closedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("error saving %s archive manifest: %w", w.name, err)
+	}
+
+	w.log.Debug(fmt.Sprintf("%s archive file %s closed", w.name, f.path),
+		zap.Int("records", f.records), zap.Int64("bytes", f.counter.n))
+
+	return nil
+}
+
+func extension(format Format) string {
+	if format == FormatParquet {
+		return ".parquet"
+	}
+
+	return ".ndjson.gz"
+}
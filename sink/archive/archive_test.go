@@ -0,0 +1,371 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func readNDJSON(t *testing.T, path string) []types.Relay {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gzipReader, err := gzip.NewReader(f)
+	require.NoError(t, err)
+
+	var relays []types.Relay
+	scanner := bufio.NewScanner(gzipReader)
+	for scanner.Scan() {
+		var relay types.Relay
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &relay))
+		relays = append(relays, relay)
+	}
+	require.NoError(t, scanner.Err())
+
+	return relays
+}
+
+func TestConfig_Validate(t *testing.T) {
+	c := require.New(t)
+
+	tests := []struct {
+		name        string
+		config      Config
+		expectedErr error
+	}{
+		{
+			name:   "Valid config",
+			config: Config{Dir: "archive", Format: FormatParquet},
+		},
+		{
+			name:        "Missing dir",
+			config:      Config{Format: FormatNDJSON},
+			expectedErr: errMissingDir,
+		},
+		{
+			name:        "Invalid format",
+			config:      Config{Dir: "archive", Format: "csv"},
+			expectedErr: errInvalidFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		c.ErrorIs(tt.config.Validate(), tt.expectedErr, tt.name)
+	}
+}
+
+func TestWriter_NDJSON(t *testing.T) {
+	c := require.New(t)
+
+	dir := t.TempDir()
+	now := time.Date(2023, time.October, 21, 7, 0, 0, 0, time.UTC)
+
+	writer, err := NewRelayWriter(Config{Dir: dir, Format: FormatNDJSON, MaxFileAge: time.Hour}, zap.NewNop())
+	c.NoError(err)
+	writer.now = func() time.Time { return now }
+
+	relays := []*types.Relay{
+		{PoktChainID: "0021", RelayStartDatetime: now, RequestID: "1"},
+		{PoktChainID: "0021", RelayStartDatetime: now, RequestID: "2"},
+		{PoktChainID: "0040", RelayStartDatetime: now.Add(-24 * time.Hour), RequestID: "3"},
+		{RequestID: "4"},
+	}
+
+	c.NoError(writer.Write(context.Background(), relays))
+
+	// Open files are not in the manifest yet
+	entries, err := ReadManifest(filepath.Join(dir, "relay"))
+	c.NoError(err)
+	c.Empty(entries)
+
+	// A write after the max age rotates the files of every partition
+	now = now.Add(time.Hour)
+	c.NoError(writer.Write(context.Background(), relays[:1]))
+	c.NoError(writer.Close())
+	c.ErrorIs(writer.Write(context.Background(), relays), errClosed)
+
+	entries, err = ReadManifest(filepath.Join(dir, "relay"))
+	c.NoError(err)
+	c.Len(entries, 4)
+
+	records := map[string]int{}
+	for _, entry := range entries {
+		c.Equal(FormatNDJSON, entry.Format)
+		c.FileExists(filepath.Join(dir, "relay", entry.Path))
+		c.Len(readNDJSON(t, filepath.Join(dir, "relay", entry.Path)), entry.Records)
+		records[entry.Date+"/"+entry.Chain] += entry.Records
+	}
+
+	c.Equal(map[string]int{
+		"2023-10-21/0021":    3,
+		"2023-10-20/0040":    1,
+		"2023-10-21/unknown": 1,
+	}, records)
+
+	partials, err := filepath.Glob(filepath.Join(dir, "relay", "*", "*", "*"+partialSuffix))
+	c.NoError(err)
+	c.Empty(partials)
+}
+
+func TestWriter_Rotate(t *testing.T) {
+	c := require.New(t)
+
+	dir := t.TempDir()
+	now := time.Date(2023, time.October, 21, 7, 0, 0, 0, time.UTC)
+
+	writer, err := NewRelayWriter(Config{Dir: dir, Format: FormatNDJSON, MaxFileAge: time.Hour}, zap.NewNop())
+	c.NoError(err)
+	writer.now = func() time.Time { return now }
+
+	c.NoError(writer.Write(context.Background(), []*types.Relay{{PoktChainID: "0021", RelayStartDatetime: now}}))
+
+	// The file is only closed once it reaches its max age, without any write
+	c.NoError(writer.Rotate())
+
+	entries, err := ReadManifest(filepath.Join(dir, "relay"))
+	c.NoError(err)
+	c.Empty(entries)
+
+	now = now.Add(time.Hour)
+	c.NoError(writer.Rotate())
+
+	entries, err = ReadManifest(filepath.Join(dir, "relay"))
+	c.NoError(err)
+	c.Len(entries, 1)
+	c.Equal(1, entries[0].Records)
+
+	c.NoError(writer.Close())
+}
+
+func TestWriter_SizeRotation(t *testing.T) {
+	c := require.New(t)
+
+	dir := t.TempDir()
+
+	writer, err := NewServiceRecordWriter(Config{Dir: dir, Format: FormatNDJSON, MaxFileBytes: 1}, zap.NewNop())
+	c.NoError(err)
+
+	serviceRecords := []*types.ServiceRecord{{PoktChainID: "0021"}, {PoktChainID: "0021"}}
+
+	// Every batch goes over the size limit, so each one ends up in its own file
+	c.NoError(writer.Write(context.Background(), serviceRecords))
+	c.NoError(writer.Write(context.Background(), serviceRecords))
+
+	entries, err := ReadManifest(filepath.Join(dir, "service_record"))
+	c.NoError(err)
+	c.Len(entries, 2)
+	c.NotEqual(entries[0].Path, entries[1].Path)
+	c.Equal(2, entries[0].Records)
+
+	// The manifest is kept across writers
+	writer, err = NewServiceRecordWriter(Config{Dir: dir, Format: FormatNDJSON, MaxFileBytes: 1}, zap.NewNop())
+	c.NoError(err)
+	c.NoError(writer.Write(context.Background(), serviceRecords))
+
+	entries, err = ReadManifest(filepath.Join(dir, "service_record"))
+	c.NoError(err)
+	c.Len(entries, 3)
+}
+
+func TestWriter_FilePerWrite(t *testing.T) {
+	c := require.New(t)
+
+	dir := t.TempDir()
+
+	writer, err := NewServiceRecordWriter(Config{Dir: dir, Format: FormatNDJSON, FilePerWrite: true}, zap.NewNop())
+	c.NoError(err)
+
+	serviceRecords := []*types.ServiceRecord{{PoktChainID: "0021"}, {PoktChainID: "0040"}}
+
+	// Each write closes its files, whatever their size
+	c.NoError(writer.Write(context.Background(), serviceRecords))
+
+	entries, err := ReadManifest(filepath.Join(dir, "service_record"))
+	c.NoError(err)
+	c.Len(entries, 2)
+
+	c.NoError(writer.Write(context.Background(), serviceRecords[:1]))
+
+	entries, err = ReadManifest(filepath.Join(dir, "service_record"))
+	c.NoError(err)
+	c.Len(entries, 3)
+
+	partials, err := filepath.Glob(filepath.Join(dir, "service_record", "*", "*", "*"+partialSuffix))
+	c.NoError(err)
+	c.Empty(partials)
+
+	c.NoError(writer.Close())
+}
+
+func TestWriter_Parquet(t *testing.T) {
+	c := require.New(t)
+
+	dir := t.TempDir()
+	now := time.Date(2023, time.October, 21, 7, 0, 0, 0, time.UTC)
+
+	writer, err := NewRelayWriter(Config{Dir: dir, Format: FormatParquet}, zap.NewNop())
+	c.NoError(err)
+
+	relays := []*types.Relay{
+		{
+			PoktChainID:         "0021",
+			SessionKey:          "21",
+			RelayStartDatetime:  now,
+			RelayChainMethodIDs: []string{"eth_blockNumber", "eth_chainId"},
+			ErrorSource:         types.ErrorSourceInternal,
+		},
+		{PoktChainID: "0021", RelayStartDatetime: now},
+	}
+
+	c.NoError(writer.Write(context.Background(), relays[:1]))
+	c.NoError(writer.Write(context.Background(), relays[1:]))
+	c.NoError(writer.Close())
+
+	entries, err := ReadManifest(filepath.Join(dir, "relay"))
+	c.NoError(err)
+	c.Len(entries, 1)
+	c.Equal(2, entries[0].Records)
+	c.Equal(".parquet", filepath.Ext(entries[0].Path))
+
+	storedRelays, err := parquet.ReadFile[types.Relay](filepath.Join(dir, "relay", entries[0].Path))
+	c.NoError(err)
+	c.Len(storedRelays, 2)
+	c.Equal(relays[0].SessionKey, storedRelays[0].SessionKey)
+	c.Equal(relays[0].RelayChainMethodIDs, storedRelays[0].RelayChainMethodIDs)
+	c.Equal(relays[0].ErrorSource, storedRelays[0].ErrorSource)
+	c.True(now.Equal(storedRelays[0].RelayStartDatetime))
+}
+
+// testItem fails to encode when Fail is set
+type testItem struct {
+	Chain string `json:"chain"`
+	Fail  bool   `json:"-"`
+}
+
+func (i testItem) MarshalJSON() ([]byte, error) {
+	if i.Fail {
+		return nil, errors.New("encoding failed")
+	}
+
+	return json.Marshal(map[string]string{"chain": i.Chain})
+}
+
+func newTestWriter(t *testing.T, dir string) *Writer[testItem] {
+	t.Helper()
+
+	writer, err := NewWriter("test", Config{Dir: dir, Format: FormatNDJSON}, testItem{}, func(item testItem) (time.Time, string) {
+		return time.Date(2023, time.October, 21, 7, 0, 0, 0, time.UTC), item.Chain
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	return writer
+}
+
+func TestWriter_FailedWrite(t *testing.T) {
+	c := require.New(t)
+
+	dir := t.TempDir()
+	writer := newTestWriter(t, dir)
+
+	c.NoError(writer.Write(context.Background(), []testItem{{Chain: "0021"}}))
+
+	// The items of the partitions written before the failing one are dropped too
+	c.Error(writer.Write(context.Background(), []testItem{{Chain: "0021"}, {Chain: "0040"}, {Chain: "0040", Fail: true}}))
+
+	partials, err := filepath.Glob(filepath.Join(dir, "test", "*", "*", "*"+partialSuffix))
+	c.NoError(err)
+	c.Len(partials, 1)
+
+	// Retrying the write without the failing item archives each item once
+	c.NoError(writer.Write(context.Background(), []testItem{{Chain: "0021"}, {Chain: "0040"}}))
+	c.NoError(writer.Close())
+
+	entries, err := ReadManifest(filepath.Join(dir, "test"))
+	c.NoError(err)
+
+	records := map[string]int{}
+	for _, entry := range entries {
+		c.Len(readNDJSON(t, filepath.Join(dir, "test", entry.Path)), entry.Records)
+		records[entry.Chain] += entry.Records
+	}
+
+	c.Equal(map[string]int{"0021": 2, "0040": 1}, records)
+}
+
+func TestWriter_RecoverPartials(t *testing.T) {
+	c := require.New(t)
+
+	dir := t.TempDir()
+
+	// A writer that never closes its files, as after a crash
+	writer := newTestWriter(t, dir)
+	c.NoError(writer.Write(context.Background(), []testItem{{Chain: "0021"}, {Chain: "0021"}, {Chain: "0040"}}))
+	c.NoError(writer.Write(context.Background(), []testItem{{Chain: "0021"}}))
+
+	partials, err := filepath.Glob(filepath.Join(dir, "test", "*", "chain=0021", "*"+partialSuffix))
+	c.NoError(err)
+	c.Len(partials, 1)
+
+	// The start of a batch the crash interrupted
+	var member bytes.Buffer
+	gzipWriter := gzip.NewWriter(&member)
+	_, err = gzipWriter.Write([]byte(`{"chain":"0021"}` + "\n"))
+	c.NoError(err)
+	c.NoError(gzipWriter.Close())
+
+	f, err := os.OpenFile(partials[0], os.O_APPEND|os.O_WRONLY, 0o644)
+	c.NoError(err)
+	_, err = f.Write(member.Bytes()[:member.Len()/2])
+	c.NoError(err)
+	c.NoError(f.Close())
+
+	// A Parquet file can't be read without its footer
+	parquetPartial := filepath.Join(dir, "test", "date=2023-10-21", "chain=0040", "test.parquet"+partialSuffix)
+	c.NoError(os.WriteFile(parquetPartial, []byte("PAR1"), 0o644))
+
+	// An entry the crash left half written
+	f, err = os.OpenFile(filepath.Join(dir, "test", manifestFileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	c.NoError(err)
+	_, err = f.Write([]byte(`{"path":"date=`))
+	c.NoError(err)
+	c.NoError(f.Close())
+
+	entries, err := ReadManifest(filepath.Join(dir, "test"))
+	c.NoError(err)
+	c.Empty(entries)
+
+	writer = newTestWriter(t, dir)
+	c.NoError(writer.Write(context.Background(), []testItem{{Chain: "0021"}}))
+	c.NoError(writer.Close())
+
+	partials, err = filepath.Glob(filepath.Join(dir, "test", "*", "*", "*"+partialSuffix))
+	c.NoError(err)
+	c.Empty(partials)
+
+	entries, err = ReadManifest(filepath.Join(dir, "test"))
+	c.NoError(err)
+	c.Len(entries, 3)
+
+	records := map[string]int{}
+	for _, entry := range entries {
+		c.Len(readNDJSON(t, filepath.Join(dir, "test", entry.Path)), entry.Records)
+		records[entry.Chain] += entry.Records
+	}
+
+	c.Equal(map[string]int{"0021": 4, "0040": 1}, records)
+}
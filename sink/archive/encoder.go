@@ -0,0 +1,146 @@
+package archive
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// file is an archive file being written
+type file struct {
+	partition partition
+	path      string
+	osFile    *os.File
+	counter   *countingWriter
+	encoder   encoder
+	openedAt  time.Time
+	// records and size are the items and bytes of the batches written successfully,
+	// pending the items of the batch being written
+	records int
+	size    int64
+	pending int
+}
+
+// commit counts the batch written since the last commit
+func (f *file) commit() {
+	f.records += f.pending
+	f.size = f.counter.n
+	f.pending = 0
+}
+
+// rollback drops the batch written since the last commit, reporting false when the
+// encoder can't resume from there
+func (f *file) rollback() (bool, error) {
+	f.pending = 0
+
+	if !f.encoder.reset() {
+		return false, nil
+	}
+
+	if err := f.osFile.Truncate(f.size); err != nil {
+		return false, err
+	}
+
+	if _, err := f.osFile.Seek(f.size, io.SeekStart); err != nil {
+		return false, err
+	}
+
+	f.counter.n = f.size
+
+	return true, nil
+}
+
+// encoder writes items in one of the archive formats
+type encoder interface {
+	encode(item any) error
+	// flush writes the buffered items to the file, called after each batch
+	flush() error
+	// reset drops the items encoded since the last flush, reporting false when the
+	// encoder is unusable after a failed batch and the file must be dropped
+	reset() bool
+	close() error
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// ndjsonEncoder writes each batch as a gzip member of its own, so a file is readable
+// up to its last complete batch and a failed batch can be cut off its end
+type ndjsonEncoder struct {
+	w           io.Writer
+	gzipWriter  *gzip.Writer
+	jsonEncoder *json.Encoder
+}
+
+func newNDJSONEncoder(w io.Writer) *ndjsonEncoder {
+	return &ndjsonEncoder{w: w}
+}
+
+func (e *ndjsonEncoder) encode(item any) error {
+	if e.gzipWriter == nil {
+		e.gzipWriter = gzip.NewWriter(e.w)
+		e.jsonEncoder = json.NewEncoder(e.gzipWriter)
+	}
+
+	return e.jsonEncoder.Encode(item)
+}
+
+func (e *ndjsonEncoder) flush() error {
+	if e.gzipWriter == nil {
+		return nil
+	}
+
+	err := e.gzipWriter.Close()
+	e.gzipWriter = nil
+
+	return err
+}
+
+func (e *ndjsonEncoder) reset() bool {
+	e.gzipWriter = nil
+	return true
+}
+
+func (e *ndjsonEncoder) close() error {
+	return e.flush()
+}
+
+// parquetEncoder writes a row group per batch, the file only being readable once closed
+type parquetEncoder struct {
+	writer *parquet.Writer
+}
+
+func newParquetEncoder(w io.Writer, model any) *parquetEncoder {
+	return &parquetEncoder{
+		writer: parquet.NewWriter(w, parquet.SchemaOf(model), parquet.Compression(&parquet.Zstd)),
+	}
+}
+
+func (e *parquetEncoder) encode(item any) error {
+	return e.writer.Write(item)
+}
+
+func (e *parquetEncoder) flush() error {
+	return e.writer.Flush()
+}
+
+// reset can't undo the row groups written, the footer of the file listing them
+func (e *parquetEncoder) reset() bool {
+	return false
+}
+
+func (e *parquetEncoder) close() error {
+	return e.writer.Close()
+}
@@ -0,0 +1,130 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const manifestFileName = "manifest.ndjson"
+
+// ManifestEntry describes a closed archive file
+type ManifestEntry struct {
+	// Path is relative to the writer directory
+	Path     string    `json:"path"`
+	Date     string    `json:"date"`
+	Chain    string    `json:"chain"`
+	Format   Format    `json:"format"`
+	Records  int       `json:"records"`
+	Bytes    int64     `json:"bytes"`
+	OpenedAt time.Time `json:"openedAt"`
+	ClosedAt time.Time `json:"closedAt"`
+}
+
+// manifest lists the closed files of a writer, one JSON entry per line, each file
+// close appending its entry
+type manifest struct {
+	path string
+}
+
+// openManifest returns the manifest at path, cutting off the entry a crash may
+// have left half written at its end
+func openManifest(path string) (*manifest, error) {
+	if err := repairLines(path); err != nil {
+		return nil, err
+	}
+
+	return &manifest{path: path}, nil
+}
+
+// ReadManifest returns the entries of the manifest of the archive writer at dir
+func ReadManifest(dir string) ([]ManifestEntry, error) {
+	return readLines[ManifestEntry](filepath.Join(dir, manifestFileName))
+}
+
+// add appends the entry to the manifest
+func (m *manifest) add(entry ManifestEntry) error {
+	return appendLine(m.path, entry)
+}
+
+// readLines returns the JSON values of the file at path, one per line, skipping a last
+// line without its newline as it is still being appended
+func readLines[T any](path string) ([]T, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var values []T
+
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return values, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var value T
+		if err := json.Unmarshal(line, &value); err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+}
+
+// repairLines cuts off the last line of the file at path when it has no newline, as
+// left by a crash while it was being appended
+func repairLines(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if complete := bytes.LastIndexByte(data, '\n') + 1; complete < len(data) {
+		return os.Truncate(path, int64(complete))
+	}
+
+	return nil
+}
+
+// appendLine appends value as a JSON line, written in a single call so it is never
+// interleaved with another
+func appendLine(path string, value any) error {
+	line, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,202 @@
+package archive
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetReadSize is how many rows are decoded at once from a Parquet file
+const parquetReadSize = 256
+
+// replayJournalFileName is the file recording how far the replays of a writer got
+const replayJournalFileName = "replayed.ndjson"
+
+// ReplayStats counts what a replay wrote
+type ReplayStats struct {
+	Files   int
+	Records int
+	// Skipped counts the files a previous replay wrote in full
+	Skipped int
+}
+
+// replayProgress is how many records of an archive file were replayed, a journal
+// record being appended after each batch
+type replayProgress struct {
+	Path    string `json:"path"`
+	Records int    `json:"records"`
+}
+
+// ReadFile calls fn with each item of the archive file at path in the order they were written,
+// stopping at the first error fn returns
+func ReadFile[T any](path string, format Format, fn func(item *T) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if format == FormatParquet {
+		return decodeParquet(f, fn)
+	}
+
+	return decodeNDJSON(f, fn)
+}
+
+func decodeNDJSON[T any](r io.Reader, fn func(item *T) error) error {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gzipReader.Close()
+
+	decoder := json.NewDecoder(gzipReader)
+	for {
+		item := new(T)
+
+		err := decoder.Decode(item)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+}
+
+func decodeParquet[T any](f *os.File, fn func(item *T) error) error {
+	reader := parquet.NewGenericReader[T](f)
+	defer reader.Close()
+
+	for {
+		// A new buffer every time, as the items are handed over to fn
+		rows := make([]T, parquetReadSize)
+
+		n, err := reader.Read(rows)
+		for i := 0; i < n; i++ {
+			if err := fn(&rows[i]); err != nil {
+				return err
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Replay writes the items of the archive files of the writer at dir that match filter, in
+// batches of up to batchSize items and in the order the files were closed. Files still being
+// written are not in the manifest, so they are left out. It stops at the first error, the
+// stats telling how far it got.
+//
+// Every batch written is recorded in the replay journal of dir, so replaying again picks up
+// where the last replay stopped and never writes an item twice. A nil write only counts the
+// items left to replay, leaving the journal alone.
+func Replay[T any](ctx context.Context, dir string, filter func(entry ManifestEntry) bool, batchSize int,
+	write func(ctx context.Context, items []*T) error) (ReplayStats, error) {
+	var stats ReplayStats
+
+	entries, err := ReadManifest(dir)
+	if err != nil {
+		return stats, err
+	}
+
+	journalPath := filepath.Join(dir, replayJournalFileName)
+
+	// A crash may have left the last record of the journal half written
+	if err := repairLines(journalPath); err != nil {
+		return stats, err
+	}
+
+	progress, err := readLines[replayProgress](journalPath)
+	if err != nil {
+		return stats, fmt.Errorf("error reading the replay journal: %w", err)
+	}
+
+	replayed := make(map[string]int)
+	for _, p := range progress {
+		replayed[p.Path] = p.Records
+	}
+
+	for _, entry := range entries {
+		if filter != nil && !filter(entry) {
+			continue
+		}
+
+		skip := replayed[entry.Path]
+		if skip >= entry.Records {
+			stats.Skipped++
+			continue
+		}
+
+		var items []*T
+		done := skip
+
+		flush := func() error {
+			if len(items) == 0 {
+				return nil
+			}
+
+			if write != nil {
+				if err := write(ctx, items); err != nil {
+					return err
+				}
+
+				if err := appendLine(journalPath, replayProgress{Path: entry.Path, Records: done + len(items)}); err != nil {
+					return fmt.Errorf("error recording the replay: %w", err)
+				}
+			}
+
+			done += len(items)
+			stats.Records += len(items)
+			items = nil
+
+			return nil
+		}
+
+		read := 0
+		err := ReadFile(filepath.Join(dir, filepath.FromSlash(entry.Path)), entry.Format, func(item *T) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			// The items a previous replay wrote
+			if read++; read <= skip {
+				return nil
+			}
+
+			items = append(items, item)
+			if len(items) < batchSize {
+				return nil
+			}
+
+			return flush()
+		})
+		if err == nil {
+			err = flush()
+		}
+
+		if err != nil {
+			return stats, fmt.Errorf("error replaying %s: %w", entry.Path, err)
+		}
+
+		stats.Files++
+	}
+
+	return stats, nil
+}
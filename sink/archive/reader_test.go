@@ -0,0 +1,148 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestReplay(t *testing.T) {
+	c := require.New(t)
+
+	now := time.Date(2023, time.October, 21, 7, 0, 0, 0, time.UTC)
+
+	relays := []*types.Relay{
+		{PoktChainID: "0021", RelayStartDatetime: now, RequestID: "1", RelayChainMethodIDs: []string{"eth_chainId"}},
+		{PoktChainID: "0021", RelayStartDatetime: now, RequestID: "2"},
+		{PoktChainID: "0021", RelayStartDatetime: now, RequestID: "3"},
+		{PoktChainID: "0040", RelayStartDatetime: now.Add(-24 * time.Hour), RequestID: "4"},
+	}
+
+	for _, format := range []Format{FormatNDJSON, FormatParquet} {
+		dir := t.TempDir()
+
+		writer, err := NewRelayWriter(Config{Dir: dir, Format: format}, zap.NewNop())
+		c.NoError(err)
+		c.NoError(writer.Write(context.Background(), relays))
+		c.NoError(writer.Close())
+
+		var batches [][]*types.Relay
+		write := func(ctx context.Context, items []*types.Relay) error {
+			batches = append(batches, items)
+			return nil
+		}
+
+		onlyToday := func(entry ManifestEntry) bool {
+			return entry.Date == "2023-10-21"
+		}
+
+		stats, err := Replay(context.Background(), filepath.Join(dir, "relay"), onlyToday, 2, write)
+		c.NoError(err, format)
+		c.Equal(ReplayStats{Files: 1, Records: 3}, stats, format)
+		c.Len(batches, 2, format)
+		c.Len(batches[0], 2, format)
+		c.Equal("1", batches[0][0].RequestID, format)
+		c.Equal([]string{"eth_chainId"}, batches[0][0].RelayChainMethodIDs, format)
+		c.True(now.Equal(batches[0][0].RelayStartDatetime), format)
+		c.Equal("3", batches[1][0].RequestID, format)
+
+		// The file replayed already is skipped
+		stats, err = Replay(context.Background(), filepath.Join(dir, "relay"), nil, 10, write)
+		c.NoError(err, format)
+		c.Equal(ReplayStats{Files: 1, Records: 1, Skipped: 1}, stats, format)
+		c.Len(batches, 3, format)
+		c.Equal("4", batches[2][0].RequestID, format)
+	}
+}
+
+func TestReplay_Twice(t *testing.T) {
+	c := require.New(t)
+
+	dir := t.TempDir()
+
+	writer, err := NewRelayWriter(Config{Dir: dir, Format: FormatNDJSON, FilePerWrite: true}, zap.NewNop())
+	c.NoError(err)
+
+	for i := 0; i < 3; i++ {
+		c.NoError(writer.Write(context.Background(), []*types.Relay{{PoktChainID: "0021"}, {PoktChainID: "0021"}, {PoktChainID: "0021"}}))
+	}
+
+	errDummy := errors.New("dummy")
+	written := 0
+	failAt := 5
+
+	write := func(ctx context.Context, items []*types.Relay) error {
+		if written+len(items) > failAt {
+			return errDummy
+		}
+
+		written += len(items)
+		return nil
+	}
+
+	// The replay stops in the middle of the second file
+	stats, err := Replay(context.Background(), filepath.Join(dir, "relay"), nil, 2, write)
+	c.ErrorIs(err, errDummy)
+	c.Equal(ReplayStats{Files: 1, Records: 5}, stats)
+
+	// A dry run counts what is left without recording anything
+	stats, err = Replay[types.Relay](context.Background(), filepath.Join(dir, "relay"), nil, 2, nil)
+	c.NoError(err)
+	c.Equal(ReplayStats{Files: 2, Records: 4, Skipped: 1}, stats)
+
+	// The next replays pick up where it stopped, writing every item once
+	failAt = 100
+
+	stats, err = Replay(context.Background(), filepath.Join(dir, "relay"), nil, 2, write)
+	c.NoError(err)
+	c.Equal(ReplayStats{Files: 2, Records: 4, Skipped: 1}, stats)
+
+	stats, err = Replay(context.Background(), filepath.Join(dir, "relay"), nil, 2, write)
+	c.NoError(err)
+	c.Equal(ReplayStats{Skipped: 3}, stats)
+	c.Equal(9, written)
+}
+
+func TestReplay_WriteError(t *testing.T) {
+	c := require.New(t)
+
+	dir := t.TempDir()
+
+	writer, err := NewServiceRecordWriter(Config{Dir: dir, Format: FormatNDJSON, FilePerWrite: true}, zap.NewNop())
+	c.NoError(err)
+
+	serviceRecords := []*types.ServiceRecord{{PoktChainID: "0021"}, {PoktChainID: "0021"}}
+
+	// Each batch goes over the size limit, so each one ends up in its own file
+	c.NoError(writer.Write(context.Background(), serviceRecords))
+	c.NoError(writer.Write(context.Background(), serviceRecords))
+
+	errDummy := errors.New("dummy")
+	calls := 0
+
+	stats, err := Replay(context.Background(), filepath.Join(dir, "service_record"), nil, 10,
+		func(ctx context.Context, items []*types.ServiceRecord) error {
+			calls++
+			if calls > 1 {
+				return errDummy
+			}
+
+			return nil
+		})
+	c.ErrorIs(err, errDummy)
+	c.Equal(ReplayStats{Files: 1, Records: 2}, stats)
+
+	// The manifest of a writer that never closed a file is empty
+	stats, err = Replay(context.Background(), t.TempDir(), nil, 10,
+		func(ctx context.Context, items []*types.ServiceRecord) error {
+			return nil
+		})
+	c.NoError(err)
+	c.Zero(stats)
+}
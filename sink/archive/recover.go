@@ -0,0 +1,212 @@
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// recoverPartials closes the partial files a writer that didn't shut down cleanly left
+// behind. NDJSON files keep their complete batches and are added to the manifest, while
+// Parquet files are unreadable without the footer written on close and are removed.
+func (w *Writer[T]) recoverPartials() error {
+	paths, err := filepath.Glob(filepath.Join(w.dir, "date=*", "chain=*", "*"+partialSuffix))
+	if err != nil {
+		return err
+	}
+
+	for _, partialPath := range paths {
+		if err := w.recoverPartial(partialPath); err != nil {
+			return fmt.Errorf("error recovering %s: %w", partialPath, err)
+		}
+	}
+
+	return nil
+}
+
+func (w *Writer[T]) recoverPartial(partialPath string) error {
+	path := strings.TrimSuffix(partialPath, partialSuffix)
+
+	relPath, err := filepath.Rel(w.dir, path)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasSuffix(path, extension(FormatParquet)) {
+		w.log.Warn(fmt.Sprintf("removing %s archive file %s left unfinished", w.name, relPath))
+		return os.Remove(partialPath)
+	}
+
+	info, err := os.Stat(partialPath)
+	if err != nil {
+		return err
+	}
+
+	records, size, err := completeMembers(partialPath)
+	if err != nil {
+		return err
+	}
+
+	if records == 0 {
+		return os.Remove(partialPath)
+	}
+
+	if size < info.Size() {
+		w.log.Warn(fmt.Sprintf("cutting the incomplete batch off %s archive file %s", w.name, relPath),
+			zap.Int64("bytes", info.Size()-size))
+
+		if err := os.Truncate(partialPath, size); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(partialPath, path); err != nil {
+		return err
+	}
+
+	p, err := partitionOfPath(relPath)
+	if err != nil {
+		return err
+	}
+
+	err = w.manifest.add(ManifestEntry{
+		Path:     filepath.ToSlash(relPath),
+		Date:     p.date,
+		Chain:    p.chain,
+		Format:   FormatNDJSON,
+		Records:  records,
+		Bytes:    size,
+		OpenedAt: openedAtOfPath(relPath, info.ModTime()),
+		ClosedAt: info.ModTime(),
+	})
+	if err != nil {
+		return err
+	}
+
+	w.log.Info(fmt.Sprintf("%s archive file %s recovered", w.name, relPath),
+		zap.Int("records", records), zap.Int64("bytes", size))
+
+	return nil
+}
+
+// completeMembers returns the records and the size of the complete gzip members at the
+// start of the NDJSON file at path, each member holding a batch
+func completeMembers(path string) (int, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	// Given an io.ByteReader, gzip reads no further than the end of each member
+	reader := &countingReader{r: bufio.NewReader(f)}
+
+	var records int
+	var size int64
+	for {
+		if _, err := reader.r.Peek(1); errors.Is(err, io.EOF) {
+			return records, size, nil
+		}
+
+		n, err := countMember(reader)
+		if err != nil {
+			// The rest of the file is a batch the writer didn't finish
+			return records, size, nil
+		}
+
+		records += n
+		size = reader.n
+	}
+}
+
+// countMember reads the next gzip member, returning how many records it holds
+func countMember(r *countingReader) (int, error) {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, err
+	}
+	gzipReader.Multistream(false)
+
+	decoder := json.NewDecoder(gzipReader)
+
+	var records int
+	for {
+		var record json.RawMessage
+
+		err := decoder.Decode(&record)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		records++
+	}
+
+	// The checksum is only verified once the member is read to its end
+	if _, err := io.Copy(io.Discard, gzipReader); err != nil {
+		return 0, err
+	}
+
+	return records, nil
+}
+
+type countingReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+func (cr *countingReader) ReadByte() (byte, error) {
+	b, err := cr.r.ReadByte()
+	if err == nil {
+		cr.n++
+	}
+	return b, err
+}
+
+// partitionOfPath returns the partition of a file path relative to the writer directory
+func partitionOfPath(relPath string) (partition, error) {
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	if len(parts) != 3 || !strings.HasPrefix(parts[0], "date=") || !strings.HasPrefix(parts[1], "chain=") {
+		return partition{}, fmt.Errorf("unexpected archive file path %s", relPath)
+	}
+
+	chain, err := url.PathUnescape(strings.TrimPrefix(parts[1], "chain="))
+	if err != nil {
+		return partition{}, err
+	}
+
+	return partition{date: strings.TrimPrefix(parts[0], "date="), chain: chain}, nil
+}
+
+// openedAtOfPath returns the time in the name of the file, or fallback when it has none
+func openedAtOfPath(relPath string, fallback time.Time) time.Time {
+	parts := strings.Split(filepath.Base(relPath), "-")
+	if len(parts) < 3 {
+		return fallback
+	}
+
+	openedAt, err := time.Parse(fileTimeLayout, parts[len(parts)-2])
+	if err != nil {
+		return fallback
+	}
+
+	return openedAt
+}
@@ -0,0 +1,222 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Acks is how many replicas must acknowledge a record before it is considered written
+type Acks string
+
+const (
+	AcksAll    Acks = "all"
+	AcksLeader Acks = "leader"
+	AcksNone   Acks = "none"
+)
+
+// Compression is the codec the record batches are compressed with
+type Compression string
+
+const (
+	CompressionNone   Compression = "none"
+	CompressionGzip   Compression = "gzip"
+	CompressionSnappy Compression = "snappy"
+	CompressionLz4    Compression = "lz4"
+	CompressionZstd   Compression = "zstd"
+)
+
+// KeyBy is the field records are keyed by, which decides their partition
+type KeyBy string
+
+const (
+	KeyBySessionKey KeyBy = "session_key"
+	KeyByChainID    KeyBy = "chain_id"
+)
+
+var (
+	errMissingBrokers     = errors.New("at least one broker must be set")
+	errMissingTopic       = errors.New("the topic must be set")
+	errInvalidAcks        = errors.New("invalid acks, must be all, leader or none")
+	errInvalidCompression = errors.New("invalid compression, must be none, gzip, snappy, lz4 or zstd")
+	errInvalidKeyBy       = errors.New("invalid key, must be session_key or chain_id")
+	errIdempotenceAcks    = errors.New("idempotent writes require all acks")
+)
+
+// Config holds the settings of a producer
+type Config struct {
+	Brokers     []string
+	Topic       string
+	ClientID    string
+	Acks        Acks
+	Idempotent  bool
+	Compression Compression
+	KeyBy       KeyBy
+}
+
+// Validate checks the config can be used to create a producer
+func (c Config) Validate() error {
+	if len(c.Brokers) == 0 {
+		return errMissingBrokers
+	}
+
+	if c.Topic == "" {
+		return errMissingTopic
+	}
+
+	if _, err := c.acks(); err != nil {
+		return err
+	}
+
+	if _, err := c.compression(); err != nil {
+		return err
+	}
+
+	if c.KeyBy != KeyBySessionKey && c.KeyBy != KeyByChainID {
+		return errInvalidKeyBy
+	}
+
+	if c.Idempotent && c.Acks != AcksAll {
+		return errIdempotenceAcks
+	}
+
+	return nil
+}
+
+func (c Config) acks() (kgo.Acks, error) {
+	switch c.Acks {
+	case AcksAll:
+		return kgo.AllISRAcks(), nil
+	case AcksLeader:
+		return kgo.LeaderAck(), nil
+	case AcksNone:
+		return kgo.NoAck(), nil
+	default:
+		return kgo.Acks{}, errInvalidAcks
+	}
+}
+
+func (c Config) compression() (kgo.CompressionCodec, error) {
+	switch c.Compression {
+	case CompressionNone:
+		return kgo.NoCompression(), nil
+	case CompressionGzip:
+		return kgo.GzipCompression(), nil
+	case CompressionSnappy:
+		return kgo.SnappyCompression(), nil
+	case CompressionLz4:
+		return kgo.Lz4Compression(), nil
+	case CompressionZstd:
+		return kgo.ZstdCompression(), nil
+	default:
+		return kgo.CompressionCodec{}, errInvalidCompression
+	}
+}
+
+func (c Config) clientOptions() ([]kgo.Opt, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	acks, _ := c.acks()
+	compression, _ := c.compression()
+
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(c.Brokers...),
+		kgo.DefaultProduceTopic(c.Topic),
+		kgo.RequiredAcks(acks),
+		kgo.ProducerBatchCompression(compression),
+	}
+
+	if c.ClientID != "" {
+		opts = append(opts, kgo.ClientID(c.ClientID))
+	}
+
+	if !c.Idempotent {
+		opts = append(opts, kgo.DisableIdempotentWrite())
+	}
+
+	return opts, nil
+}
+
+// KeyFunc returns the key of the record of an item
+type KeyFunc[T any] func(item T) string
+
+// Producer publishes items as JSON records to a topic
+type Producer[T any] struct {
+	client *kgo.Client
+	key    KeyFunc[T]
+}
+
+// NewProducer returns a Producer publishing to the config topic with the key returned by key
+func NewProducer[T any](config Config, key KeyFunc[T]) (*Producer[T], error) {
+	opts, err := config.clientOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Producer[T]{client: client, key: key}, nil
+}
+
+// NewRelayProducer returns a Producer of relays keyed by their session key or chain ID
+func NewRelayProducer(config Config) (*Producer[*types.Relay], error) {
+	return NewProducer(config, func(relay *types.Relay) string {
+		if config.KeyBy == KeyByChainID {
+			return relay.PoktChainID
+		}
+
+		return relay.SessionKey
+	})
+}
+
+// NewServiceRecordProducer returns a Producer of service records keyed by their session key or chain ID
+func NewServiceRecordProducer(config Config) (*Producer[*types.ServiceRecord], error) {
+	return NewProducer(config, func(serviceRecord *types.ServiceRecord) string {
+		if config.KeyBy == KeyByChainID {
+			return serviceRecord.PoktChainID
+		}
+
+		return serviceRecord.SessionKey
+	})
+}
+
+// Write publishes the items and waits until all of them are acknowledged. Its signature
+// matches the batch writers so it can be given to a batch.
+func (p *Producer[T]) Write(ctx context.Context, items []T) error {
+	records := make([]*kgo.Record, 0, len(items))
+	for _, item := range items {
+		value, err := json.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("error encoding record: %w", err)
+		}
+
+		records = append(records, &kgo.Record{
+			Key:   []byte(p.key(item)),
+			Value: value,
+		})
+	}
+
+	return p.client.ProduceSync(ctx, records...).FirstErr()
+}
+
+// Ping checks a broker of the cluster can be reached
+func (p *Producer[T]) Ping(ctx context.Context) error {
+	return p.client.Ping(ctx)
+}
+
+// Close waits for the buffered records to be published and closes the client
+func (p *Producer[T]) Close() error {
+	err := p.client.Flush(context.Background())
+	p.client.Close()
+
+	return err
+}
@@ -0,0 +1,154 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	c := require.New(t)
+
+	validConfig := Config{
+		Brokers:     []string{"localhost:9092"},
+		Topic:       "relays",
+		Acks:        AcksAll,
+		Idempotent:  true,
+		Compression: CompressionZstd,
+		KeyBy:       KeyBySessionKey,
+	}
+
+	tests := []struct {
+		name        string
+		modify      func(config *Config)
+		expectedErr error
+	}{
+		{
+			name:   "Valid config",
+			modify: func(config *Config) {},
+		},
+		{
+			name:        "Missing brokers",
+			modify:      func(config *Config) { config.Brokers = nil },
+			expectedErr: errMissingBrokers,
+		},
+		{
+			name:        "Missing topic",
+			modify:      func(config *Config) { config.Topic = "" },
+			expectedErr: errMissingTopic,
+		},
+		{
+			name:        "Invalid acks",
+			modify:      func(config *Config) { config.Acks = "some" },
+			expectedErr: errInvalidAcks,
+		},
+		{
+			name:        "Invalid compression",
+			modify:      func(config *Config) { config.Compression = "brotli" },
+			expectedErr: errInvalidCompression,
+		},
+		{
+			name:        "Invalid key",
+			modify:      func(config *Config) { config.KeyBy = "request_id" },
+			expectedErr: errInvalidKeyBy,
+		},
+		{
+			name:        "Idempotence without all acks",
+			modify:      func(config *Config) { config.Acks = AcksLeader },
+			expectedErr: errIdempotenceAcks,
+		},
+		{
+			name: "Leader acks without idempotence",
+			modify: func(config *Config) {
+				config.Acks = AcksLeader
+				config.Idempotent = false
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		config := validConfig
+		tt.modify(&config)
+		c.ErrorIs(config.Validate(), tt.expectedErr, tt.name)
+	}
+}
+
+func TestProducer_Write(t *testing.T) {
+	c := require.New(t)
+
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(3, "relays"))
+	c.NoError(err)
+	defer cluster.Close()
+
+	tests := []struct {
+		name        string
+		keyBy       KeyBy
+		compression Compression
+		expectedKey string
+	}{
+		{
+			name:        "Keyed by session key",
+			keyBy:       KeyBySessionKey,
+			compression: CompressionZstd,
+			expectedKey: "session-21",
+		},
+		{
+			name:        "Keyed by chain ID",
+			keyBy:       KeyByChainID,
+			compression: CompressionNone,
+			expectedKey: "0021",
+		},
+	}
+
+	consumer, err := kgo.NewClient(kgo.SeedBrokers(cluster.ListenAddrs()...), kgo.ConsumeTopics("relays"))
+	c.NoError(err)
+	defer consumer.Close()
+
+	for _, tt := range tests {
+		producer, err := NewRelayProducer(Config{
+			Brokers:     cluster.ListenAddrs(),
+			Topic:       "relays",
+			Acks:        AcksAll,
+			Idempotent:  true,
+			Compression: tt.compression,
+			KeyBy:       tt.keyBy,
+		})
+		c.NoError(err, tt.name)
+
+		c.NoError(producer.Ping(context.Background()), tt.name)
+
+		relays := []*types.Relay{
+			{PoktChainID: "0021", SessionKey: "session-21", RequestID: "1"},
+			{PoktChainID: "0021", SessionKey: "session-21", RequestID: "2"},
+		}
+
+		c.NoError(producer.Write(context.Background(), relays), tt.name)
+		c.NoError(producer.Close(), tt.name)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+		var records []*kgo.Record
+		for len(records) < len(relays) {
+			fetches := consumer.PollFetches(ctx)
+			c.NoError(ctx.Err(), tt.name)
+			records = append(records, fetches.Records()...)
+		}
+
+		cancel()
+
+		c.Len(records, len(relays), tt.name)
+		for i, record := range records {
+			c.Equal(tt.expectedKey, string(record.Key), tt.name)
+
+			var relay types.Relay
+			c.NoError(json.Unmarshal(record.Value, &relay), tt.name)
+			c.Equal(relays[i].RequestID, relay.RequestID, tt.name)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	cacheRelay         = "relay"
+	cacheServiceRecord = "service_record"
+
+	resultHit    = "hit"
+	resultMiss   = "miss"
+	resultShared = "shared"
+)
+
+var (
+	cacheRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "transaction_http_db",
+		Name:      "cache_requests_total",
+		Help:      "Number of cached reads by result: a hit, a miss read from storage or a miss that waited for a concurrent read of the same item.",
+	}, []string{"cache", "result"})
+	cacheEntries = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "transaction_http_db",
+		Name:      "cache_entries",
+		Help:      "Number of items in the cache.",
+	}, []string{"cache"})
+)
+
+// Config holds the settings of the cache
+type Config struct {
+	// Size is the max number of relays and of service records kept
+	Size int
+	// TTL is how long an item is kept, forever if zero
+	TTL time.Duration
+	// ReadTimeout bounds a read shared by concurrent misses, which is not cancelled along
+	// with the request that started it, no timeout if zero
+	ReadTimeout time.Duration
+}
+
+// Driver caches the relays and service records read through it, which never change once
+// written. Concurrent misses of the same item are collapsed into a single read.
+type Driver struct {
+	storage.Driver
+	relays             *lru[int, types.Relay]
+	serviceRecords     *lru[int, types.ServiceRecord]
+	relayGroup         singleflight.Group
+	serviceRecordGroup singleflight.Group
+	readTimeout        time.Duration
+}
+
+// New returns a Driver caching the reads of driver
+func New(driver storage.Driver, config Config) *Driver {
+	return &Driver{
+		Driver:         driver,
+		relays:         newLRU[int, types.Relay](config.Size, config.TTL),
+		serviceRecords: newLRU[int, types.ServiceRecord](config.Size, config.TTL),
+		readTimeout:    config.ReadTimeout,
+	}
+}
+
+func (d *Driver) ReadRelay(ctx context.Context, relayID int) (types.Relay, error) {
+	return readThrough(ctx, cacheRelay, d.relays, &d.relayGroup, d.readTimeout, relayID, d.Driver.ReadRelay)
+}
+
+func (d *Driver) ReadServiceRecord(ctx context.Context, serviceRecordID int) (types.ServiceRecord, error) {
+	return readThrough(ctx, cacheServiceRecord, d.serviceRecords, &d.serviceRecordGroup, d.readTimeout, serviceRecordID, d.Driver.ReadServiceRecord)
+}
+
+// readThrough returns the cached item or reads it, errors not being cached. The read is
+// shared by the concurrent misses, so a caller giving up doesn't cancel it for the others.
+func readThrough[V any](ctx context.Context, name string, cache *lru[int, V], group *singleflight.Group, readTimeout time.Duration,
+	id int, read func(context.Context, int) (V, error)) (V, error) {
+	if value, ok := cache.get(id); ok {
+		cacheRequestsTotal.WithLabelValues(name, resultHit).Inc()
+		return value, nil
+	}
+
+	results := group.DoChan(strconv.Itoa(id), func() (any, error) {
+		readCtx := context.WithoutCancel(ctx)
+		if readTimeout > 0 {
+			var cancel context.CancelFunc
+			readCtx, cancel = context.WithTimeout(readCtx, readTimeout)
+			defer cancel()
+		}
+
+		value, err := read(readCtx, id)
+		if err != nil {
+			return value, err
+		}
+
+		cache.add(id, value)
+		cacheEntries.WithLabelValues(name).Set(float64(cache.len()))
+
+		return value, nil
+	})
+
+	var result singleflight.Result
+	select {
+	case result = <-results:
+	case <-ctx.Done():
+		var value V
+		return value, ctx.Err()
+	}
+
+	if result.Shared {
+		cacheRequestsTotal.WithLabelValues(name, resultShared).Inc()
+	} else {
+		cacheRequestsTotal.WithLabelValues(name, resultMiss).Inc()
+	}
+
+	return result.Val.(V), result.Err
+}
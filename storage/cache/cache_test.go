@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDriver_ReadRelay(t *testing.T) {
+	c := require.New(t)
+
+	driverMock := &storage.MockDriver{}
+	driverMock.On("ReadRelay", mock.Anything, 21).Return(types.Relay{RelayID: 21}, nil).Once()
+	driverMock.On("ReadRelay", mock.Anything, 22).Return(types.Relay{}, errors.New("dummy")).Twice()
+
+	driver := New(driverMock, Config{Size: 21, TTL: time.Hour})
+
+	hitsBefore := testutil.ToFloat64(cacheRequestsTotal.WithLabelValues(cacheRelay, resultHit))
+
+	for i := 0; i < 3; i++ {
+		relay, err := driver.ReadRelay(context.Background(), 21)
+		c.NoError(err)
+		c.Equal(21, relay.RelayID)
+	}
+
+	// Errors are not cached, so both reads reach the driver
+	for i := 0; i < 2; i++ {
+		_, err := driver.ReadRelay(context.Background(), 22)
+		c.Error(err)
+	}
+
+	driverMock.AssertExpectations(t)
+	c.Equal(hitsBefore+2, testutil.ToFloat64(cacheRequestsTotal.WithLabelValues(cacheRelay, resultHit)))
+}
+
+func TestDriver_ReadServiceRecord(t *testing.T) {
+	c := require.New(t)
+
+	release := make(chan struct{})
+
+	driverMock := &storage.MockDriver{}
+	driverMock.On("ReadServiceRecord", mock.Anything, 21).
+		Run(func(args mock.Arguments) { <-release }).
+		Return(types.ServiceRecord{ServiceRecordID: 21}, nil).Once()
+
+	driver := New(driverMock, Config{Size: 21})
+
+	// Concurrent misses of the same service record are served by a single read
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			serviceRecord, err := driver.ReadServiceRecord(context.Background(), 21)
+			c.NoError(err)
+			c.Equal(21, serviceRecord.ServiceRecordID)
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	serviceRecord, err := driver.ReadServiceRecord(context.Background(), 21)
+	c.NoError(err)
+	c.Equal(21, serviceRecord.ServiceRecordID)
+
+	driverMock.AssertExpectations(t)
+}
+
+func TestDriver_ReadRelay_cancelled(t *testing.T) {
+	c := require.New(t)
+
+	release := make(chan struct{})
+
+	driverMock := &storage.MockDriver{}
+	driverMock.On("ReadRelay", mock.Anything, 21).
+		Run(func(args mock.Arguments) {
+			<-release
+
+			// The shared read is not cancelled along with the request that started it
+			ctx := args.Get(0).(context.Context)
+			c.NoError(ctx.Err())
+
+			_, ok := ctx.Deadline()
+			c.True(ok)
+		}).
+		Return(types.Relay{RelayID: 21}, nil).Once()
+
+	driver := New(driverMock, Config{Size: 21, ReadTimeout: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cancelled := make(chan error)
+	go func() {
+		_, err := driver.ReadRelay(ctx, 21)
+		cancelled <- err
+	}()
+
+	shared := make(chan types.Relay)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+
+		relay, err := driver.ReadRelay(context.Background(), 21)
+		c.NoError(err)
+		shared <- relay
+	}()
+
+	// The cancelled caller returns without waiting for the read
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	c.ErrorIs(<-cancelled, context.Canceled)
+
+	close(release)
+	c.Equal(21, (<-shared).RelayID)
+
+	driverMock.AssertExpectations(t)
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU(t *testing.T) {
+	c := require.New(t)
+
+	now := time.Now()
+
+	cache := newLRU[int, string](2, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.add(1, "1")
+	cache.add(2, "2")
+
+	// Reading 1 makes 2 the least recently used entry, so it is the one evicted
+	value, ok := cache.get(1)
+	c.True(ok)
+	c.Equal("1", value)
+
+	cache.add(3, "3")
+	c.Equal(2, cache.len())
+
+	_, ok = cache.get(2)
+	c.False(ok)
+
+	// Updating an entry renews its TTL
+	now = now.Add(30 * time.Second)
+	cache.add(3, "three")
+
+	now = now.Add(30 * time.Second)
+	_, ok = cache.get(1)
+	c.False(ok)
+
+	value, ok = cache.get(3)
+	c.True(ok)
+	c.Equal("three", value)
+	c.Equal(1, cache.len())
+}
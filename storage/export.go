@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+)
+
+// ErrExportUnsupported is returned when the backend can't list the relays of a time range
+var ErrExportUnsupported = errors.New("storage backend does not support exports")
+
+// RelayExporter is implemented by the backends that can list the relays of a time range
+type RelayExporter interface {
+	// ExportRelays calls fn with each relay started from from and before to, in the order
+	// they were written, stopping at the first error fn returns
+	ExportRelays(ctx context.Context, from, to time.Time, fn func(relay types.Relay) error) error
+}
+
+// ExportRelays calls fn with each relay of driver started from from and before to
+func ExportRelays(ctx context.Context, driver Driver, from, to time.Time, fn func(relay types.Relay) error) error {
+	exporter, ok := driver.(RelayExporter)
+	if !ok {
+		return ErrExportUnsupported
+	}
+
+	return exporter.ExportRelays(ctx, from, to, fn)
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/stretchr/testify/require"
+)
+
+type exporterDriver struct {
+	MockDriver
+	relays []types.Relay
+}
+
+func (d *exporterDriver) ExportRelays(ctx context.Context, from, to time.Time, fn func(relay types.Relay) error) error {
+	for _, relay := range d.relays {
+		if err := fn(relay); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestExportRelays(t *testing.T) {
+	c := require.New(t)
+
+	var exported []types.Relay
+	collect := func(relay types.Relay) error {
+		exported = append(exported, relay)
+		return nil
+	}
+
+	from := time.Date(2023, time.October, 21, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	err := ExportRelays(context.Background(), &MockDriver{}, from, to, collect)
+	c.ErrorIs(err, ErrExportUnsupported)
+	c.Empty(exported)
+
+	driver := &exporterDriver{relays: []types.Relay{{RelayID: 1}, {RelayID: 2}}}
+	c.NoError(ExportRelays(context.Background(), driver, from, to, collect))
+	c.Equal(driver.relays, exported)
+}
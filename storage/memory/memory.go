@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+)
+
+// Name is the name the backend is registered with
+const Name = "memory"
+
+var (
+	ErrRelayNotFound         = errors.New("relay not found")
+	ErrServiceRecordNotFound = errors.New("service record not found")
+)
+
+func init() {
+	storage.Register(Name, Open)
+}
+
+// Driver is a storage.Driver keeping everything in memory, meant for local development
+// and tests. It validates items and rejects repeated session keys like the Postgres backend,
+// and batch writes are all or nothing.
+type Driver struct {
+	mu             sync.RWMutex
+	sessions       map[string]types.PocketSession
+	regions        map[string]types.PortalRegion
+	relays         []types.Relay
+	serviceRecords []types.ServiceRecord
+	now            func() time.Time
+}
+
+// NewDriver returns an empty Driver
+func NewDriver() *Driver {
+	return &Driver{
+		sessions: make(map[string]types.PocketSession),
+		regions:  make(map[string]types.PortalRegion),
+		now:      time.Now,
+	}
+}
+
+// Open is the storage.Factory of the in-memory backend, which takes no config
+func Open(_ context.Context, _ storage.Config) (storage.Driver, func() error, error) {
+	return NewDriver(), func() error { return nil }, nil
+}
+
+// Ping always succeeds as there is nothing to reach
+func (d *Driver) Ping(_ context.Context) error {
+	return nil
+}
+
+func (d *Driver) WriteSession(_ context.Context, session types.PocketSession) error {
+	if err := session.Validate(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.sessions[session.SessionKey]; ok {
+		return types.ErrRepeatedSessionKey
+	}
+
+	now := d.now()
+	session.CreatedAt = now
+	session.UpdatedAt = now
+
+	d.sessions[session.SessionKey] = session
+
+	return nil
+}
+
+func (d *Driver) WriteRegion(_ context.Context, region types.PortalRegion) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.regions[region.PortalRegionName] = region
+
+	return nil
+}
+
+func (d *Driver) WriteRelay(ctx context.Context, relay types.Relay) error {
+	return d.WriteRelays(ctx, []*types.Relay{&relay})
+}
+
+func (d *Driver) WriteRelays(_ context.Context, relays []*types.Relay) error {
+	for i, relay := range relays {
+		if err := relay.Validate(); err != nil {
+			return fmt.Errorf("relay %d: %w", i, err)
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	for _, relay := range relays {
+		stored := *relay
+		stored.RelayID = len(d.relays) + 1
+		stored.CreatedAt = now
+		stored.UpdatedAt = now
+		// The session and region are joined on read
+		stored.Session = types.PocketSession{}
+		stored.Region = types.PortalRegion{}
+
+		d.relays = append(d.relays, stored)
+	}
+
+	return nil
+}
+
+func (d *Driver) ReadRelay(_ context.Context, relayID int) (types.Relay, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if relayID < 1 || relayID > len(d.relays) {
+		return types.Relay{}, ErrRelayNotFound
+	}
+
+	relay := d.relays[relayID-1]
+	relay.Session = d.sessions[relay.SessionKey]
+	relay.Region = d.regions[relay.PortalRegionName]
+	relay.RelayChainMethodIDs = append([]string(nil), relay.RelayChainMethodIDs...)
+
+	return relay, nil
+}
+
+func (d *Driver) WriteServiceRecord(ctx context.Context, serviceRecord types.ServiceRecord) error {
+	return d.WriteServiceRecords(ctx, []*types.ServiceRecord{&serviceRecord})
+}
+
+func (d *Driver) WriteServiceRecords(_ context.Context, serviceRecords []*types.ServiceRecord) error {
+	for i, serviceRecord := range serviceRecords {
+		if err := serviceRecord.Validate(); err != nil {
+			return fmt.Errorf("service record %d: %w", i, err)
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	for _, serviceRecord := range serviceRecords {
+		stored := *serviceRecord
+		stored.ServiceRecordID = len(d.serviceRecords) + 1
+		stored.CreatedAt = now
+		stored.UpdatedAt = now
+
+		d.serviceRecords = append(d.serviceRecords, stored)
+	}
+
+	return nil
+}
+
+func (d *Driver) ReadServiceRecord(_ context.Context, serviceRecordID int) (types.ServiceRecord, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if serviceRecordID < 1 || serviceRecordID > len(d.serviceRecords) {
+		return types.ServiceRecord{}, ErrServiceRecordNotFound
+	}
+
+	return d.serviceRecords[serviceRecordID-1], nil
+}
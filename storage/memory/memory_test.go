@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDriver_WriteSession(t *testing.T) {
+	c := require.New(t)
+
+	driver := NewDriver()
+
+	tests := []struct {
+		name        string
+		session     types.PocketSession
+		expectedErr error
+	}{
+		{
+			name: "Success",
+			session: types.PocketSession{
+				SessionKey:       "21",
+				SessionHeight:    21,
+				PortalRegionName: "La Colonia Tovar",
+			},
+		},
+		{
+			name: "Repeated session key",
+			session: types.PocketSession{
+				SessionKey:       "21",
+				SessionHeight:    22,
+				PortalRegionName: "La Colonia Tovar",
+			},
+			expectedErr: types.ErrRepeatedSessionKey,
+		},
+	}
+
+	for _, tt := range tests {
+		err := driver.WriteSession(context.Background(), tt.session)
+		c.ErrorIs(err, tt.expectedErr, tt.name)
+	}
+
+	c.Error(driver.WriteSession(context.Background(), types.PocketSession{}))
+}
+
+func TestDriver_Relays(t *testing.T) {
+	c := require.New(t)
+
+	now := time.Date(2023, time.October, 21, 0, 0, 0, 0, time.UTC)
+
+	driver := NewDriver()
+	driver.now = func() time.Time { return now }
+
+	ctx := context.Background()
+
+	session := types.PocketSession{SessionKey: "21", SessionHeight: 21, PortalRegionName: "La Colonia Tovar"}
+	region := types.PortalRegion{PortalRegionName: "La Colonia Tovar"}
+
+	c.NoError(driver.WriteSession(ctx, session))
+	c.NoError(driver.WriteRegion(ctx, region))
+
+	relay := types.Relay{
+		PoktChainID:              "21",
+		EndpointID:               "21",
+		SessionKey:               "21",
+		ProtocolAppPublicKey:     "21",
+		RelaySourceURL:           "pablo.com",
+		PoktNodeAddress:          "21",
+		PoktNodeDomain:           "pablos.com",
+		PoktNodePublicKey:        "aaa",
+		RelayStartDatetime:       now,
+		RelayReturnDatetime:      now,
+		RelayRoundtripTime:       1,
+		RelayChainMethodIDs:      []string{"get_height"},
+		RelayDataSize:            21,
+		RelayPortalTripTime:      21,
+		RelayNodeTripTime:        21,
+		RelayURLIsPublicEndpoint: false,
+		PortalRegionName:         "La Colonia Tovar",
+		RequestID:                "21",
+		PoktTxID:                 "21",
+	}
+
+	c.NoError(driver.WriteRelay(ctx, relay))
+	c.NoError(driver.WriteRelays(ctx, []*types.Relay{&relay, &relay}))
+
+	// A single invalid relay fails the whole batch
+	c.Error(driver.WriteRelays(ctx, []*types.Relay{&relay, {}}))
+	c.Error(driver.WriteRelay(ctx, types.Relay{}))
+
+	storedRelay, err := driver.ReadRelay(ctx, 3)
+	c.NoError(err)
+	c.Equal(3, storedRelay.RelayID)
+	c.Equal(now, storedRelay.CreatedAt)
+	c.Equal(now, storedRelay.UpdatedAt)
+	c.Equal(relay.RelayChainMethodIDs, storedRelay.RelayChainMethodIDs)
+	c.Equal(session.SessionKey, storedRelay.Session.SessionKey)
+	c.Equal(now, storedRelay.Session.CreatedAt)
+	c.Equal(region, storedRelay.Region)
+
+	_, err = driver.ReadRelay(ctx, 4)
+	c.ErrorIs(err, ErrRelayNotFound)
+
+	_, err = driver.ReadRelay(ctx, 0)
+	c.ErrorIs(err, ErrRelayNotFound)
+}
+
+func TestDriver_ServiceRecords(t *testing.T) {
+	c := require.New(t)
+
+	driver := NewDriver()
+
+	ctx := context.Background()
+
+	serviceRecord := types.ServiceRecord{
+		SessionKey:             "21",
+		NodePublicKey:          "21",
+		PoktChainID:            "21",
+		RequestID:              "21",
+		PortalRegionName:       "La Colonia Tovar",
+		Latency:                21.07,
+		Tickets:                2,
+		Result:                 "a",
+		Available:              true,
+		Successes:              21,
+		Failures:               7,
+		P90SuccessLatency:      21.07,
+		MedianSuccessLatency:   21.07,
+		WeightedSuccessLatency: 21.07,
+		SuccessRate:            21,
+	}
+
+	c.NoError(driver.WriteServiceRecord(ctx, serviceRecord))
+	c.NoError(driver.WriteServiceRecords(ctx, []*types.ServiceRecord{&serviceRecord}))
+	c.Error(driver.WriteServiceRecords(ctx, []*types.ServiceRecord{&serviceRecord, {}}))
+
+	storedServiceRecord, err := driver.ReadServiceRecord(ctx, 2)
+	c.NoError(err)
+	c.Equal(2, storedServiceRecord.ServiceRecordID)
+	c.Equal(serviceRecord.Latency, storedServiceRecord.Latency)
+	c.False(storedServiceRecord.CreatedAt.IsZero())
+
+	_, err = driver.ReadServiceRecord(ctx, 3)
+	c.ErrorIs(err, ErrServiceRecordNotFound)
+
+	c.NoError(driver.Ping(ctx))
+}
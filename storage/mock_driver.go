@@ -1,6 +1,6 @@
 // Code generated by mockery v1.0.0. DO NOT EDIT.
 
-package router
+package storage
 
 import (
 	context "context"
@@ -14,6 +14,20 @@ type MockDriver struct {
 	mock.Mock
 }
 
+// Ping provides a mock function with given fields: ctx
+func (_m *MockDriver) Ping(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ReadRelay provides a mock function with given fields: ctx, relayID
 func (_m *MockDriver) ReadRelay(ctx context.Context, relayID int) (types.Relay, error) {
 	ret := _m.Called(ctx, relayID)
@@ -84,6 +98,20 @@ func (_m *MockDriver) WriteRelay(ctx context.Context, relay types.Relay) error {
 	return r0
 }
 
+// WriteRelays provides a mock function with given fields: ctx, relays
+func (_m *MockDriver) WriteRelays(ctx context.Context, relays []*types.Relay) error {
+	ret := _m.Called(ctx, relays)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*types.Relay) error); ok {
+		r0 = rf(ctx, relays)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // WriteServiceRecord provides a mock function with given fields: ctx, serviceRecord
 func (_m *MockDriver) WriteServiceRecord(ctx context.Context, serviceRecord types.ServiceRecord) error {
 	ret := _m.Called(ctx, serviceRecord)
@@ -98,6 +126,20 @@ func (_m *MockDriver) WriteServiceRecord(ctx context.Context, serviceRecord type
 	return r0
 }
 
+// WriteServiceRecords provides a mock function with given fields: ctx, serviceRecords
+func (_m *MockDriver) WriteServiceRecords(ctx context.Context, serviceRecords []*types.ServiceRecord) error {
+	ret := _m.Called(ctx, serviceRecords)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*types.ServiceRecord) error); ok {
+		r0 = rf(ctx, serviceRecords)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // WriteSession provides a mock function with given fields: ctx, session
 func (_m *MockDriver) WriteSession(ctx context.Context, session types.PocketSession) error {
 	ret := _m.Called(ctx, session)
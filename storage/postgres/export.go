@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pokt-foundation/transaction-db/types"
+)
+
+// exportRelaysQuery lists the relays of a time range with their session and region. The chain
+// method IDs are read as JSON, an array or a comma separated string depending on the column type.
+const exportRelaysQuery = `SELECT
+	r.id, r.pokt_chain_id, r.endpoint_id, r.session_key, r.protocol_app_public_key, r.relay_source_url,
+	r.pokt_node_address, r.pokt_node_domain, r.pokt_node_public_key, r.relay_start_datetime,
+	r.relay_return_datetime, r.is_error, COALESCE(r.error_code, 0), COALESCE(r.error_name, ''),
+	COALESCE(r.error_message, ''), COALESCE(r.error_source::text, ''), COALESCE(r.error_type, ''),
+	r.relay_roundtrip_time, COALESCE(to_jsonb(r.relay_chain_method_ids)::text, 'null'), r.relay_data_size,
+	r.relay_portal_trip_time, r.relay_node_trip_time, r.relay_url_is_public_endpoint, r.portal_region_name,
+	r.is_altruist_relay, r.is_user_relay, r.request_id, COALESCE(r.pokt_tx_id, ''), r.created_at, r.updated_at,
+	COALESCE(s.session_key, ''), COALESCE(s.session_height, 0), COALESCE(s.portal_region_name, ''),
+	s.created_at, s.updated_at, COALESCE(pr.portal_region_name, '')
+FROM relay r
+LEFT JOIN pocket_session s ON s.session_key = r.session_key
+LEFT JOIN portal_region pr ON pr.portal_region_name = r.portal_region_name
+WHERE r.relay_start_datetime >= $1 AND r.relay_start_datetime < $2
+ORDER BY r.id`
+
+// ExportRelays calls fn with each relay started from from and before to, in the order they
+// were written. It reads from the primary, as a lagging replica would silently cut the export short.
+func (d *Driver) ExportRelays(ctx context.Context, from, to time.Time, fn func(relay types.Relay) error) error {
+	rows, err := d.pool.Query(ctx, exportRelaysQuery, from, to)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		relay, err := scanRelay(rows)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(relay); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func scanRelay(rows pgx.Rows) (types.Relay, error) {
+	var (
+		relay                              types.Relay
+		errorSource, methodIDs             string
+		sessionCreatedAt, sessionUpdatedAt *time.Time
+	)
+
+	err := rows.Scan(
+		&relay.RelayID, &relay.PoktChainID, &relay.EndpointID, &relay.SessionKey, &relay.ProtocolAppPublicKey,
+		&relay.RelaySourceURL, &relay.PoktNodeAddress, &relay.PoktNodeDomain, &relay.PoktNodePublicKey,
+		&relay.RelayStartDatetime, &relay.RelayReturnDatetime, &relay.IsError, &relay.ErrorCode, &relay.ErrorName,
+		&relay.ErrorMessage, &errorSource, &relay.ErrorType, &relay.RelayRoundtripTime, &methodIDs,
+		&relay.RelayDataSize, &relay.RelayPortalTripTime, &relay.RelayNodeTripTime, &relay.RelayURLIsPublicEndpoint,
+		&relay.PortalRegionName, &relay.IsAltruistRelay, &relay.IsUserRelay, &relay.RequestID, &relay.PoktTxID,
+		&relay.CreatedAt, &relay.UpdatedAt, &relay.Session.SessionKey, &relay.Session.SessionHeight,
+		&relay.Session.PortalRegionName, &sessionCreatedAt, &sessionUpdatedAt, &relay.Region.PortalRegionName,
+	)
+	if err != nil {
+		return types.Relay{}, err
+	}
+
+	relay.ErrorSource = types.ErrorSource(errorSource)
+
+	if sessionCreatedAt != nil {
+		relay.Session.CreatedAt = *sessionCreatedAt
+	}
+	if sessionUpdatedAt != nil {
+		relay.Session.UpdatedAt = *sessionUpdatedAt
+	}
+
+	relay.RelayChainMethodIDs, err = decodeMethodIDs(methodIDs)
+	if err != nil {
+		return types.Relay{}, err
+	}
+
+	return relay, nil
+}
+
+// decodeMethodIDs decodes the chain method IDs read as JSON, which are an array when the
+// column is one and a string of comma separated IDs when it is text
+func decodeMethodIDs(raw string) ([]string, error) {
+	var value any
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return nil, fmt.Errorf("error decoding relay chain method IDs: %w", err)
+	}
+
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+
+	case string:
+		if value == "" {
+			return nil, nil
+		}
+
+		return strings.Split(value, ","), nil
+
+	default:
+		var methodIDs []string
+		if err := json.Unmarshal([]byte(raw), &methodIDs); err != nil {
+			return nil, fmt.Errorf("error decoding relay chain method IDs: %w", err)
+		}
+
+		return methodIDs, nil
+	}
+}
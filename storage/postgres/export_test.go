@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeMethodIDs(t *testing.T) {
+	c := require.New(t)
+
+	tests := []struct {
+		name              string
+		raw               string
+		expectedMethodIDs []string
+		expectErr         bool
+	}{
+		{
+			name:              "Array column",
+			raw:               `["eth_blockNumber", "eth_chainId"]`,
+			expectedMethodIDs: []string{"eth_blockNumber", "eth_chainId"},
+		},
+		{
+			name:              "Text column",
+			raw:               `"eth_blockNumber,eth_chainId"`,
+			expectedMethodIDs: []string{"eth_blockNumber", "eth_chainId"},
+		},
+		{
+			name: "Empty text column",
+			raw:  `""`,
+		},
+		{
+			name: "Null column",
+			raw:  `null`,
+		},
+		{
+			name:      "Unsupported column",
+			raw:       `{"method": "eth_chainId"}`,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		methodIDs, err := decodeMethodIDs(tt.raw)
+		if tt.expectErr {
+			c.Error(err, tt.name)
+			continue
+		}
+
+		c.NoError(err, tt.name)
+		c.Equal(tt.expectedMethodIDs, methodIDs, tt.name)
+	}
+}
@@ -0,0 +1,224 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	poolPrimary = "primary"
+	poolReplica = "replica"
+)
+
+var errInvalidPoolConfig = errors.New("invalid pool configuration")
+
+// poolOptions tunes the connection pool, pgx defaults being kept for the zero values
+type poolOptions struct {
+	maxConns          int32
+	minConns          int32
+	maxConnLifetime   time.Duration
+	maxConnIdleTime   time.Duration
+	healthCheckPeriod time.Duration
+}
+
+func parsePoolOptions(config storage.Config) (poolOptions, error) {
+	var (
+		opts poolOptions
+		errs []error
+	)
+
+	parseInt := func(key string, value *int32) {
+		raw := config[key]
+		if raw == "" {
+			return
+		}
+
+		parsed, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil || parsed < 0 {
+			errs = append(errs, fmt.Errorf("%w: %s must be a non negative integer, got %q", errInvalidPoolConfig, key, raw))
+			return
+		}
+
+		*value = int32(parsed)
+	}
+
+	parseDuration := func(key string, value *time.Duration) {
+		raw := config[key]
+		if raw == "" {
+			return
+		}
+
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < 0 {
+			errs = append(errs, fmt.Errorf("%w: %s must be a non negative duration, got %q", errInvalidPoolConfig, key, raw))
+			return
+		}
+
+		*value = parsed
+	}
+
+	parseInt(ConfigMaxConns, &opts.maxConns)
+	parseInt(ConfigMinConns, &opts.minConns)
+	parseDuration(ConfigMaxConnLifetime, &opts.maxConnLifetime)
+	parseDuration(ConfigMaxConnIdleTime, &opts.maxConnIdleTime)
+	parseDuration(ConfigHealthCheckPeriod, &opts.healthCheckPeriod)
+
+	if opts.maxConns > 0 && opts.minConns > opts.maxConns {
+		errs = append(errs, fmt.Errorf("%w: %s %d is greater than %s %d", errInvalidPoolConfig,
+			ConfigMinConns, opts.minConns, ConfigMaxConns, opts.maxConns))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return poolOptions{}, err
+	}
+
+	return opts, nil
+}
+
+// apply sets the non zero options on the pool config
+func (o poolOptions) apply(config *pgxpool.Config) {
+	if o.maxConns > 0 {
+		config.MaxConns = o.maxConns
+	}
+	if o.minConns > 0 {
+		config.MinConns = o.minConns
+	}
+	if o.maxConnLifetime > 0 {
+		config.MaxConnLifetime = o.maxConnLifetime
+	}
+	if o.maxConnIdleTime > 0 {
+		config.MaxConnIdleTime = o.maxConnIdleTime
+	}
+	if o.healthCheckPeriod > 0 {
+		config.HealthCheckPeriod = o.healthCheckPeriod
+	}
+}
+
+var (
+	// poolMetrics exports the pools of every open driver, registered along with the first one
+	poolMetrics         = newPoolCollector()
+	registerPoolMetrics sync.Once
+	errRegisterPool     error
+)
+
+// registerPools adds the pools to the exported metrics, returning a func removing them
+func registerPools(pools map[string]*pgxpool.Pool) (func(), error) {
+	registerPoolMetrics.Do(func() {
+		errRegisterPool = prometheus.Register(poolMetrics)
+	})
+
+	if errRegisterPool != nil {
+		return nil, errRegisterPool
+	}
+
+	poolMetrics.add(pools)
+
+	return func() {
+		poolMetrics.remove(pools)
+	}, nil
+}
+
+// poolCollector exports the statistics of the connection pools as metrics, those of
+// the pools of the same name being added up
+type poolCollector struct {
+	mu    sync.Mutex
+	pools map[*pgxpool.Pool]string
+
+	acquiredConns   *prometheus.Desc
+	idleConns       *prometheus.Desc
+	totalConns      *prometheus.Desc
+	maxConns        *prometheus.Desc
+	acquires        *prometheus.Desc
+	waits           *prometheus.Desc
+	acquireDuration *prometheus.Desc
+}
+
+func newPoolCollector() *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName("transaction_http_db", "db_pool", name), help, []string{"pool"}, nil)
+	}
+
+	return &poolCollector{
+		pools:           make(map[*pgxpool.Pool]string),
+		acquiredConns:   desc("acquired_connections", "Number of connections currently in use."),
+		idleConns:       desc("idle_connections", "Number of idle connections."),
+		totalConns:      desc("total_connections", "Number of open connections."),
+		maxConns:        desc("max_connections", "Max number of connections of the pool."),
+		acquires:        desc("acquires_total", "Number of connections acquired from the pool."),
+		waits:           desc("waits_total", "Number of acquires that waited for a connection as none was idle."),
+		acquireDuration: desc("acquire_wait_seconds_total", "Time spent waiting to acquire connections."),
+	}
+}
+
+func (c *poolCollector) add(pools map[string]*pgxpool.Pool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for name, pool := range pools {
+		c.pools[pool] = name
+	}
+}
+
+func (c *poolCollector) remove(pools map[string]*pgxpool.Pool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, pool := range pools {
+		delete(c.pools, pool)
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.totalConns
+	ch <- c.maxConns
+	ch <- c.acquires
+	ch <- c.waits
+	ch <- c.acquireDuration
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	type poolStat struct {
+		acquiredConns, idleConns, totalConns, maxConns, acquires, waits float64
+		acquireDuration                                                 float64
+	}
+
+	c.mu.Lock()
+	stats := make(map[string]*poolStat)
+	for pool, name := range c.pools {
+		stat := pool.Stat()
+
+		s, ok := stats[name]
+		if !ok {
+			s = &poolStat{}
+			stats[name] = s
+		}
+
+		s.acquiredConns += float64(stat.AcquiredConns())
+		s.idleConns += float64(stat.IdleConns())
+		s.totalConns += float64(stat.TotalConns())
+		s.maxConns += float64(stat.MaxConns())
+		s.acquires += float64(stat.AcquireCount())
+		s.waits += float64(stat.EmptyAcquireCount())
+		s.acquireDuration += stat.AcquireDuration().Seconds()
+	}
+	c.mu.Unlock()
+
+	for name, s := range stats {
+		ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, s.acquiredConns, name)
+		ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, s.idleConns, name)
+		ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, s.totalConns, name)
+		ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, s.maxConns, name)
+		ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, s.acquires, name)
+		ch <- prometheus.MustNewConstMetric(c.waits, prometheus.CounterValue, s.waits, name)
+		ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, s.acquireDuration, name)
+	}
+}
@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePoolOptions(t *testing.T) {
+	c := require.New(t)
+
+	tests := []struct {
+		name            string
+		config          storage.Config
+		expectedOptions poolOptions
+		expectedErr     error
+	}{
+		{
+			name: "No pool settings",
+		},
+		{
+			name: "All pool settings",
+			config: storage.Config{
+				ConfigMaxConns:          "20",
+				ConfigMinConns:          "2",
+				ConfigMaxConnLifetime:   "1h",
+				ConfigMaxConnIdleTime:   "30m",
+				ConfigHealthCheckPeriod: "1m",
+			},
+			expectedOptions: poolOptions{
+				maxConns:          20,
+				minConns:          2,
+				maxConnLifetime:   time.Hour,
+				maxConnIdleTime:   30 * time.Minute,
+				healthCheckPeriod: time.Minute,
+			},
+		},
+		{
+			name:        "Negative max conns",
+			config:      storage.Config{ConfigMaxConns: "-1"},
+			expectedErr: errInvalidPoolConfig,
+		},
+		{
+			name:        "Invalid duration",
+			config:      storage.Config{ConfigMaxConnIdleTime: "30"},
+			expectedErr: errInvalidPoolConfig,
+		},
+		{
+			name:        "Min conns over max conns",
+			config:      storage.Config{ConfigMaxConns: "2", ConfigMinConns: "5"},
+			expectedErr: errInvalidPoolConfig,
+		},
+	}
+
+	for _, tt := range tests {
+		opts, err := parsePoolOptions(tt.config)
+		c.ErrorIs(err, tt.expectedErr, tt.name)
+		c.Equal(tt.expectedOptions, opts, tt.name)
+	}
+
+	// Every invalid setting is reported at once
+	_, err := parsePoolOptions(storage.Config{ConfigMaxConns: "many", ConfigHealthCheckPeriod: "often"})
+	c.ErrorContains(err, ConfigMaxConns)
+	c.ErrorContains(err, ConfigHealthCheckPeriod)
+}
+
+func TestPoolOptions_apply(t *testing.T) {
+	c := require.New(t)
+
+	config, err := pgxpool.ParseConfig("host=localhost port=5432")
+	c.NoError(err)
+
+	defaultIdleTime := config.MaxConnIdleTime
+
+	poolOptions{maxConns: 20, maxConnLifetime: time.Hour}.apply(config)
+
+	c.Equal(int32(20), config.MaxConns)
+	c.Equal(time.Hour, config.MaxConnLifetime)
+	c.Equal(defaultIdleTime, config.MaxConnIdleTime)
+}
+
+func newTestPool(t *testing.T, maxConns int32) *pgxpool.Pool {
+	t.Helper()
+
+	config, err := pgxpool.ParseConfig("host=localhost port=5432")
+	require.NoError(t, err)
+
+	poolOptions{maxConns: maxConns}.apply(config)
+
+	// The pool connects lazily so no database is needed
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	return pool
+}
+
+func TestPoolCollector(t *testing.T) {
+	c := require.New(t)
+
+	collector := newPoolCollector()
+	collector.add(map[string]*pgxpool.Pool{poolPrimary: newTestPool(t, 7)})
+
+	c.Equal(7, testutil.CollectAndCount(collector))
+	c.NoError(testutil.CollectAndCompare(collector, strings.NewReader(`
+# HELP transaction_http_db_db_pool_max_connections Max number of connections of the pool.
+# TYPE transaction_http_db_db_pool_max_connections gauge
+transaction_http_db_db_pool_max_connections{pool="primary"} 7
+`), "transaction_http_db_db_pool_max_connections"))
+}
+
+func TestRegisterPools(t *testing.T) {
+	c := require.New(t)
+
+	// Every open driver registers its pools, the pools of the same name being added up
+	unregisterFirst, err := registerPools(map[string]*pgxpool.Pool{poolPrimary: newTestPool(t, 7)})
+	c.NoError(err)
+
+	unregisterSecond, err := registerPools(map[string]*pgxpool.Pool{poolPrimary: newTestPool(t, 3)})
+	c.NoError(err)
+
+	c.NoError(testutil.GatherAndCompare(prometheus.DefaultGatherer, strings.NewReader(`
+# HELP transaction_http_db_db_pool_max_connections Max number of connections of the pool.
+# TYPE transaction_http_db_db_pool_max_connections gauge
+transaction_http_db_db_pool_max_connections{pool="primary"} 10
+`), "transaction_http_db_db_pool_max_connections"))
+
+	unregisterFirst()
+
+	c.NoError(testutil.GatherAndCompare(prometheus.DefaultGatherer, strings.NewReader(`
+# HELP transaction_http_db_db_pool_max_connections Max number of connections of the pool.
+# TYPE transaction_http_db_db_pool_max_connections gauge
+transaction_http_db_db_pool_max_connections{pool="primary"} 3
+`), "transaction_http_db_db_pool_max_connections"))
+
+	unregisterSecond()
+}
@@ -0,0 +1,321 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/cloudsqlconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	postgresdriver "github.com/pokt-foundation/transaction-db/postgres-driver"
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+)
+
+// Name is the name the backend is registered with
+const Name = "postgres"
+
+// Config keys read by the backend
+const (
+	// Required for all Envs.
+	ConfigUser     = "user"
+	ConfigPassword = "password"
+	ConfigDatabase = "database"
+	// Required for development/test Env.
+	ConfigHost = "host"
+	ConfigPort = "port"
+	// Required for production Env.
+	ConfigInstanceConnectionName = "instance_connection_name"
+	ConfigPrivateIP              = "private_ip"
+	// Optional read replica, reached with the same credentials as the primary.
+	ConfigReplicaHost                   = "replica_host"
+	ConfigReplicaPort                   = "replica_port"
+	ConfigReplicaInstanceConnectionName = "replica_instance_connection_name"
+	// ConfigReplicaMaxStaleness is a duration such as "5s", reads going to the primary
+	// while the replica lags behind it by more than that.
+	ConfigReplicaMaxStaleness = "replica_max_staleness"
+	// Optional pool settings, also used for the replica. The durations are strings
+	// such as "30m", pgx defaults being kept for the unset ones.
+	ConfigMaxConns          = "max_conns"
+	ConfigMinConns          = "min_conns"
+	ConfigMaxConnLifetime   = "max_conn_lifetime"
+	ConfigMaxConnIdleTime   = "max_conn_idle_time"
+	ConfigHealthCheckPeriod = "health_check_period"
+)
+
+var (
+	errMissingCredentials = errors.New("the user, password and database must be set")
+	errInvalidDBConfig    = errors.New("invalid DB configuration, either the instance connection name or the host and port must be set")
+)
+
+func init() {
+	storage.Register(Name, Open)
+}
+
+type (
+	options struct {
+		user, password, database string
+		// Local DB options - Required for development/test Env.
+		host, port string
+		// CloudSQL DB options - Required for production Env.
+		instanceConnectionName string
+		privateIP              bool
+		// Connection pool options - Optional.
+		pool poolOptions
+		// Read replica options - Optional.
+		replica             *options
+		replicaMaxStaleness time.Duration
+	}
+
+	// DB config structs
+	DBConfig interface {
+		GetPool(ctx context.Context) (pool *pgxpool.Pool, cleanup func() error, err error)
+	}
+	cloudSQLConfig struct {
+		options
+	}
+	testDBConfig struct {
+		options
+	}
+)
+
+// Driver adapts the transaction-db Postgres driver to the storage.Driver interface,
+// serving the reads from the replica when one is configured
+type Driver struct {
+	*postgresdriver.PostgresDriver
+	pool    *pgxpool.Pool
+	replica *replica
+}
+
+// Ping checks a connection of the pool can reach the database
+func (d *Driver) Ping(ctx context.Context) error {
+	return d.pool.Ping(ctx)
+}
+
+// Pool returns the connection pool of the driver
+func (d *Driver) Pool() *pgxpool.Pool {
+	return d.pool
+}
+
+func (d *Driver) ReadRelay(ctx context.Context, relayID int) (types.Relay, error) {
+	return read(ctx, d.PostgresDriver, d.replica, func(r reader) (types.Relay, error) {
+		return r.ReadRelay(ctx, relayID)
+	})
+}
+
+func (d *Driver) ReadServiceRecord(ctx context.Context, serviceRecordID int) (types.ServiceRecord, error) {
+	return read(ctx, d.PostgresDriver, d.replica, func(r reader) (types.ServiceRecord, error) {
+		return r.ReadServiceRecord(ctx, serviceRecordID)
+	})
+}
+
+// cloudSQLConfig.GetPool connects to a GCP CloudSQL instance using the cloudsqlconn lib.
+// Intended for production use. Will be used if the instance connection name is set.
+func (c *cloudSQLConfig) GetPool(ctx context.Context) (pool *pgxpool.Pool, cleanup func() error, err error) {
+	var dialOptions []cloudsqlconn.DialOption
+	if c.options.privateIP {
+		dialOptions = append(dialOptions, cloudsqlconn.WithPrivateIP())
+	}
+
+	dialer, err := cloudsqlconn.NewDialer(ctx, cloudsqlconn.WithDefaultDialOptions(dialOptions...))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	poolConfig, err := pgxpool.ParseConfig(fmt.Sprintf("user=%s password=%s dbname=%s sslmode=disable",
+		c.options.user,
+		c.options.password,
+		c.options.database,
+	))
+	if err != nil {
+		dialer.Close()
+		return nil, nil, err
+	}
+
+	c.options.pool.apply(poolConfig)
+
+	poolConfig.ConnConfig.DialFunc = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.Dial(ctx, c.options.instanceConnectionName)
+	}
+
+	pool, err = pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		dialer.Close()
+		return nil, nil, err
+	}
+
+	cleanup = func() error {
+		pool.Close()
+		return dialer.Close()
+	}
+
+	return pool, cleanup, nil
+}
+
+// testDBConfig.GetPool connects to a Postgres database using standard connection string and user/PW.
+// Intended to be used for running tests on a local Docker container. Will be used if the host and port are set.
+func (c *testDBConfig) GetPool(ctx context.Context) (pool *pgxpool.Pool, cleanup func() error, err error) {
+	connectionString := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		c.options.host,
+		c.options.port,
+		c.options.user,
+		c.options.password,
+		c.options.database,
+	)
+
+	poolConfig, err := pgxpool.ParseConfig(connectionString)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.options.pool.apply(poolConfig)
+
+	pool, err = pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cleanup = func() error {
+		pool.Close()
+		return nil
+	}
+
+	return pool, cleanup, nil
+}
+
+func parseOptions(config storage.Config) (options, error) {
+	opts := options{
+		user:                   config[ConfigUser],
+		password:               config[ConfigPassword],
+		database:               config[ConfigDatabase],
+		host:                   config[ConfigHost],
+		port:                   config[ConfigPort],
+		instanceConnectionName: config[ConfigInstanceConnectionName],
+	}
+
+	if opts.user == "" || opts.password == "" || opts.database == "" {
+		return options{}, errMissingCredentials
+	}
+
+	if rawPrivateIP := config[ConfigPrivateIP]; rawPrivateIP != "" {
+		privateIP, err := strconv.ParseBool(rawPrivateIP)
+		if err != nil {
+			return options{}, fmt.Errorf("invalid %s: %w", ConfigPrivateIP, err)
+		}
+
+		opts.privateIP = privateIP
+	}
+
+	pool, err := parsePoolOptions(config)
+	if err != nil {
+		return options{}, err
+	}
+
+	opts.pool = pool
+
+	replicaHost, replicaPort := config[ConfigReplicaHost], config[ConfigReplicaPort]
+	replicaInstanceConnectionName := config[ConfigReplicaInstanceConnectionName]
+
+	if replicaHost != "" || replicaPort != "" || replicaInstanceConnectionName != "" {
+		opts.replica = &options{
+			user:                   opts.user,
+			password:               opts.password,
+			database:               opts.database,
+			host:                   replicaHost,
+			port:                   replicaPort,
+			instanceConnectionName: replicaInstanceConnectionName,
+			privateIP:              opts.privateIP,
+			pool:                   opts.pool,
+		}
+	}
+
+	if rawMaxStaleness := config[ConfigReplicaMaxStaleness]; rawMaxStaleness != "" {
+		maxStaleness, err := time.ParseDuration(rawMaxStaleness)
+		if err != nil {
+			return options{}, fmt.Errorf("invalid %s: %w", ConfigReplicaMaxStaleness, err)
+		}
+
+		opts.replicaMaxStaleness = maxStaleness
+	}
+
+	return opts, nil
+}
+
+// getDBConfig chooses the DB configuration based on the DB config options
+func getDBConfig(opts options) (DBConfig, error) {
+	switch {
+	// For CloudSQL DB
+	case opts.instanceConnectionName != "":
+		return &cloudSQLConfig{options: opts}, nil
+
+	// For local DB
+	case opts.host != "" && opts.port != "":
+		return &testDBConfig{options: opts}, nil
+
+	default:
+		return nil, errInvalidDBConfig
+	}
+}
+
+// Open is the storage.Factory of the Postgres backend
+func Open(ctx context.Context, config storage.Config) (storage.Driver, func() error, error) {
+	opts, err := parseOptions(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dbConfig, err := getDBConfig(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var replicaDBConfig DBConfig
+	if opts.replica != nil {
+		replicaDBConfig, err = getDBConfig(*opts.replica)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid replica configuration: %w", err)
+		}
+	}
+
+	pool, cleanup, err := dbConfig.GetPool(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	driver := &Driver{
+		PostgresDriver: postgresdriver.NewPostgresDriverFromDBInstance(pool),
+		pool:           pool,
+	}
+	pools := map[string]*pgxpool.Pool{poolPrimary: pool}
+
+	if replicaDBConfig != nil {
+		replicaPool, replicaCleanup, err := replicaDBConfig.GetPool(ctx)
+		if err != nil {
+			_ = cleanup()
+			return nil, nil, fmt.Errorf("error connecting to replica: %w", err)
+		}
+
+		driver.replica = newReplica(postgresdriver.NewPostgresDriverFromDBInstance(replicaPool), replicaPool, opts.replicaMaxStaleness)
+		pools[poolReplica] = replicaPool
+
+		primaryCleanup := cleanup
+		cleanup = func() error {
+			driver.replica.close()
+			return errors.Join(replicaCleanup(), primaryCleanup())
+		}
+	}
+
+	unregister, err := registerPools(pools)
+	if err != nil {
+		_ = cleanup()
+		return nil, nil, fmt.Errorf("error registering pool metrics: %w", err)
+	}
+
+	return driver, func() error {
+		unregister()
+		return cleanup()
+	}, nil
+}
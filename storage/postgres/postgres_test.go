@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOptions(t *testing.T) {
+	c := require.New(t)
+
+	tests := []struct {
+		name             string
+		config           storage.Config
+		expectedOptions  options
+		expectedErr      error
+		expectedDBConfig DBConfig
+		expectedDBErr    error
+	}{
+		{
+			name: "CloudSQL config",
+			config: storage.Config{
+				ConfigUser:                   "user",
+				ConfigPassword:               "password",
+				ConfigDatabase:               "database",
+				ConfigInstanceConnectionName: "project:region:instance",
+				ConfigPrivateIP:              "true",
+			},
+			expectedOptions: options{
+				user:                   "user",
+				password:               "password",
+				database:               "database",
+				instanceConnectionName: "project:region:instance",
+				privateIP:              true,
+			},
+			expectedDBConfig: &cloudSQLConfig{},
+		},
+		{
+			name: "Local config",
+			config: storage.Config{
+				ConfigUser:     "user",
+				ConfigPassword: "password",
+				ConfigDatabase: "database",
+				ConfigHost:     "localhost",
+				ConfigPort:     "5432",
+			},
+			expectedOptions: options{
+				user:     "user",
+				password: "password",
+				database: "database",
+				host:     "localhost",
+				port:     "5432",
+			},
+			expectedDBConfig: &testDBConfig{},
+		},
+		{
+			name: "Local config with replica",
+			config: storage.Config{
+				ConfigUser:                "user",
+				ConfigPassword:            "password",
+				ConfigDatabase:            "database",
+				ConfigHost:                "localhost",
+				ConfigPort:                "5432",
+				ConfigReplicaHost:         "replica",
+				ConfigReplicaPort:         "5433",
+				ConfigReplicaMaxStaleness: "5s",
+			},
+			expectedOptions: options{
+				user:     "user",
+				password: "password",
+				database: "database",
+				host:     "localhost",
+				port:     "5432",
+				replica: &options{
+					user:     "user",
+					password: "password",
+					database: "database",
+					host:     "replica",
+					port:     "5433",
+				},
+				replicaMaxStaleness: 5 * time.Second,
+			},
+			expectedDBConfig: &testDBConfig{},
+		},
+		{
+			name: "No host",
+			config: storage.Config{
+				ConfigUser:     "user",
+				ConfigPassword: "password",
+				ConfigDatabase: "database",
+			},
+			expectedOptions: options{
+				user:     "user",
+				password: "password",
+				database: "database",
+			},
+			expectedDBErr: errInvalidDBConfig,
+		},
+		{
+			name: "Missing credentials",
+			config: storage.Config{
+				ConfigHost: "localhost",
+				ConfigPort: "5432",
+			},
+			expectedErr: errMissingCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		opts, err := parseOptions(tt.config)
+		c.ErrorIs(err, tt.expectedErr, tt.name)
+		c.Equal(tt.expectedOptions, opts, tt.name)
+
+		if tt.expectedErr != nil {
+			continue
+		}
+
+		dbConfig, err := getDBConfig(opts)
+		c.ErrorIs(err, tt.expectedDBErr, tt.name)
+		c.IsType(tt.expectedDBConfig, dbConfig, tt.name)
+	}
+
+	_, err := parseOptions(storage.Config{
+		ConfigUser:                "user",
+		ConfigPassword:            "password",
+		ConfigDatabase:            "database",
+		ConfigReplicaMaxStaleness: "5",
+	})
+	c.Error(err)
+}
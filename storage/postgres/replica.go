@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	replicaReadReplica  = "replica"
+	replicaReadFallback = "fallback"
+	replicaReadStale    = "stale"
+
+	// lagCheckInterval is how often the replica lag is measured, each measure being bounded by it
+	lagCheckInterval = 5 * time.Second
+	// maxLagAge is how long a lag measure is trusted while the next ones don't get through
+	maxLagAge = 3 * lagCheckInterval
+
+	// lagQuery returns how far behind the primary the replica is in seconds, which is zero
+	// when it replayed everything it received, as the last replay time of an idle primary
+	// would otherwise look like lag
+	lagQuery = `SELECT CASE
+		WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END`
+)
+
+var replicaReadsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "transaction_http_db",
+	Name:      "replica_reads_total",
+	Help:      "Number of reads by where they were served: the replica, the primary after the replica failed or the primary because the replica was too stale.",
+}, []string{"result"})
+
+// reader is the read side of the driver
+type reader interface {
+	ReadRelay(ctx context.Context, relayID int) (types.Relay, error)
+	ReadServiceRecord(ctx context.Context, serviceRecordID int) (types.ServiceRecord, error)
+}
+
+// replica serves the reads in place of the primary while it is not too far behind it
+type replica struct {
+	reader reader
+	// lag measures how far behind the primary the replica is
+	lag func(ctx context.Context) (time.Duration, error)
+	// maxStaleness is the lag over which reads go to the primary, disabled if zero
+	maxStaleness time.Duration
+
+	mu         sync.RWMutex
+	lastLag    time.Duration
+	lastErr    error
+	measuredAt time.Time
+	now        func() time.Time
+
+	stop context.CancelFunc
+	done chan struct{}
+}
+
+// newReplica returns the replica of the reader, measuring its lag in the background when
+// there is a max staleness
+func newReplica(reader reader, pool *pgxpool.Pool, maxStaleness time.Duration) *replica {
+	r := &replica{
+		reader: reader,
+		lag: func(ctx context.Context) (time.Duration, error) {
+			var seconds float64
+			if err := pool.QueryRow(ctx, lagQuery).Scan(&seconds); err != nil {
+				return 0, err
+			}
+
+			return time.Duration(seconds * float64(time.Second)), nil
+		},
+		maxStaleness: maxStaleness,
+		now:          time.Now,
+	}
+
+	if maxStaleness > 0 {
+		ctx, stop := context.WithCancel(context.Background())
+		r.stop = stop
+		r.done = make(chan struct{})
+
+		go r.measureEvery(ctx, lagCheckInterval)
+	}
+
+	return r
+}
+
+// measureEvery measures the lag right away and then every interval, until ctx is done
+func (r *replica) measureEvery(ctx context.Context, interval time.Duration) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.measure(ctx, interval)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// measure measures the lag, keeping the result only when the query finished within timeout
+func (r *replica) measure(ctx context.Context, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	lag, err := r.lag(ctx)
+	if ctx.Err() != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastLag, r.lastErr, r.measuredAt = lag, err, r.now()
+}
+
+// fresh reports whether the replica is within the max staleness as of the last lag measure,
+// which reads don't wait for. Until the lag is measured, or when the measures stop getting
+// through, the replica is not fresh.
+func (r *replica) fresh() bool {
+	if r.maxStaleness <= 0 {
+		return true
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.measuredAt.IsZero() || r.now().Sub(r.measuredAt) > maxLagAge {
+		return false
+	}
+
+	return r.lastErr == nil && r.lastLag <= r.maxStaleness
+}
+
+// close stops measuring the lag
+func (r *replica) close() {
+	if r.stop == nil {
+		return
+	}
+
+	r.stop()
+	<-r.done
+}
+
+// read serves the read from the replica when there is a fresh one, falling back to the primary
+// if it fails, which also covers rows that did not reach the replica yet
+func read[T any](ctx context.Context, primary reader, replica *replica, readFn func(reader) (T, error)) (T, error) {
+	if replica == nil {
+		return readFn(primary)
+	}
+
+	if !replica.fresh() {
+		replicaReadsTotal.WithLabelValues(replicaReadStale).Inc()
+		return readFn(primary)
+	}
+
+	result, err := readFn(replica.reader)
+	if err == nil {
+		replicaReadsTotal.WithLabelValues(replicaReadReplica).Inc()
+		return result, nil
+	}
+
+	replicaReadsTotal.WithLabelValues(replicaReadFallback).Inc()
+
+	return readFn(primary)
+}
@@ -0,0 +1,181 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeReader returns relays whose chain ID tells which reader served them
+type fakeReader struct {
+	name string
+	err  error
+}
+
+func (r *fakeReader) ReadRelay(ctx context.Context, relayID int) (types.Relay, error) {
+	if r.err != nil {
+		return types.Relay{}, r.err
+	}
+
+	return types.Relay{RelayID: relayID, PoktChainID: r.name}, nil
+}
+
+func (r *fakeReader) ReadServiceRecord(ctx context.Context, serviceRecordID int) (types.ServiceRecord, error) {
+	if r.err != nil {
+		return types.ServiceRecord{}, r.err
+	}
+
+	return types.ServiceRecord{ServiceRecordID: serviceRecordID, PoktChainID: r.name}, nil
+}
+
+func TestRead(t *testing.T) {
+	c := require.New(t)
+
+	errDummy := errors.New("dummy")
+	primary := &fakeReader{name: "primary"}
+
+	tests := []struct {
+		name           string
+		replicaErr     error
+		lag            time.Duration
+		lagErr         error
+		maxStaleness   time.Duration
+		noReplica      bool
+		expectedReader string
+	}{
+		{
+			name:           "No replica",
+			noReplica:      true,
+			expectedReader: "primary",
+		},
+		{
+			name:           "Replica without max staleness",
+			lag:            time.Hour,
+			expectedReader: "replica",
+		},
+		{
+			name:           "Replica within max staleness",
+			lag:            time.Second,
+			maxStaleness:   5 * time.Second,
+			expectedReader: "replica",
+		},
+		{
+			name:           "Replica over max staleness",
+			lag:            10 * time.Second,
+			maxStaleness:   5 * time.Second,
+			expectedReader: "primary",
+		},
+		{
+			name:           "Replica lag unknown",
+			lagErr:         errDummy,
+			maxStaleness:   5 * time.Second,
+			expectedReader: "primary",
+		},
+		{
+			name:           "Replica failing",
+			replicaErr:     errDummy,
+			expectedReader: "primary",
+		},
+	}
+
+	for _, tt := range tests {
+		var r *replica
+		if !tt.noReplica {
+			r = &replica{
+				reader: &fakeReader{name: "replica", err: tt.replicaErr},
+				lag: func(ctx context.Context) (time.Duration, error) {
+					return tt.lag, tt.lagErr
+				},
+				maxStaleness: tt.maxStaleness,
+				now:          time.Now,
+			}
+			r.measure(context.Background(), time.Second)
+		}
+
+		relay, err := read(context.Background(), primary, r, func(rd reader) (types.Relay, error) {
+			return rd.ReadRelay(context.Background(), 21)
+		})
+		c.NoError(err, tt.name)
+		c.Equal(tt.expectedReader, relay.PoktChainID, tt.name)
+		c.Equal(21, relay.RelayID, tt.name)
+	}
+}
+
+func TestReplica_fresh(t *testing.T) {
+	c := require.New(t)
+
+	now := time.Now()
+	lag := time.Second
+
+	r := &replica{
+		lag: func(ctx context.Context) (time.Duration, error) {
+			return lag, nil
+		},
+		maxStaleness: 5 * time.Second,
+		now:          func() time.Time { return now },
+	}
+
+	// The replica is not fresh until its lag is measured
+	c.False(r.fresh())
+
+	r.measure(context.Background(), time.Second)
+	c.True(r.fresh())
+
+	// Reads reuse the last measure
+	lag = time.Minute
+	c.True(r.fresh())
+
+	r.measure(context.Background(), time.Second)
+	c.False(r.fresh())
+
+	lag = time.Second
+	r.measure(context.Background(), time.Second)
+	c.True(r.fresh())
+
+	// A measure that doesn't finish in time is not kept
+	r.lag = func(ctx context.Context) (time.Duration, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+	r.measure(context.Background(), 10*time.Millisecond)
+	c.True(r.fresh())
+
+	// The last measure is not trusted for long once the next ones don't get through
+	now = now.Add(maxLagAge + time.Second)
+	c.False(r.fresh())
+}
+
+func TestReplica_measureEvery(t *testing.T) {
+	c := require.New(t)
+
+	var measures atomic.Int32
+
+	ctx, stop := context.WithCancel(context.Background())
+
+	r := &replica{
+		lag: func(ctx context.Context) (time.Duration, error) {
+			measures.Add(1)
+			return time.Second, nil
+		},
+		maxStaleness: 5 * time.Second,
+		now:          time.Now,
+		stop:         stop,
+		done:         make(chan struct{}),
+	}
+
+	go r.measureEvery(ctx, 10*time.Millisecond)
+
+	c.Eventually(func() bool { return measures.Load() >= 2 }, time.Second, 5*time.Millisecond)
+	c.True(r.fresh())
+
+	// Closing stops the measures
+	r.close()
+	stopped := measures.Load()
+	time.Sleep(30 * time.Millisecond)
+	c.Equal(stopped, measures.Load())
+}
@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pokt-foundation/transaction-http-db/storage"
+)
+
+// schemaQuery lists the columns of the tables written by the driver, a column counting
+// as having a default when it is an identity or generated column too
+const schemaQuery = `SELECT table_name, column_name, is_nullable = 'YES',
+	column_default IS NOT NULL OR is_identity = 'YES' OR is_generated <> 'NEVER'
+FROM information_schema.columns
+WHERE table_schema = current_schema() AND table_name = ANY($1)`
+
+// supportedSchema holds the columns read and written by the transaction-db driver
+// this build uses, by table
+var supportedSchema = map[string][]string{
+	"portal_region": {"portal_region_name"},
+	"pocket_session": {
+		"id", "session_key", "session_height", "portal_region_name", "created_at", "updated_at",
+	},
+	"relay": {
+		"id", "pokt_chain_id", "endpoint_id", "session_key", "protocol_app_public_key", "relay_source_url",
+		"pokt_node_address", "pokt_node_domain", "pokt_node_public_key", "relay_start_datetime",
+		"relay_return_datetime", "is_error", "error_code", "error_name", "error_message", "error_source",
+		"error_type", "relay_roundtrip_time", "relay_chain_method_ids", "relay_data_size",
+		"relay_portal_trip_time", "relay_node_trip_time", "relay_url_is_public_endpoint",
+		"portal_region_name", "is_altruist_relay", "is_user_relay", "request_id", "pokt_tx_id",
+		"created_at", "updated_at",
+	},
+	"service_record": {
+		"id", "node_public_key", "pokt_chain_id", "session_key", "request_id", "portal_region_name",
+		"latency", "tickets", "result", "available", "successes", "failures", "p90_success_latency",
+		"median_success_latency", "weighted_success_latency", "success_rate", "created_at", "updated_at",
+	},
+}
+
+type column struct {
+	table, name string
+	nullable    bool
+	hasDefault  bool
+}
+
+// CheckSchema checks the primary database has every table and column the driver uses and
+// no required column it doesn't know how to fill, which would make every insert fail
+func (d *Driver) CheckSchema(ctx context.Context) error {
+	tables := make([]string, 0, len(supportedSchema))
+	for table := range supportedSchema {
+		tables = append(tables, table)
+	}
+
+	rows, err := d.pool.Query(ctx, schemaQuery, tables)
+	if err != nil {
+		return fmt.Errorf("error reading database schema: %w", err)
+	}
+	defer rows.Close()
+
+	var columns []column
+	for rows.Next() {
+		var col column
+		if err := rows.Scan(&col.table, &col.name, &col.nullable, &col.hasDefault); err != nil {
+			return fmt.Errorf("error reading database schema: %w", err)
+		}
+
+		columns = append(columns, col)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error reading database schema: %w", err)
+	}
+
+	return compareSchema(supportedSchema, columns)
+}
+
+// compareSchema reports every difference between the supported and the actual schema at once
+func compareSchema(supported map[string][]string, columns []column) error {
+	actual := make(map[string]map[string]column)
+	for _, col := range columns {
+		if actual[col.table] == nil {
+			actual[col.table] = make(map[string]column)
+		}
+
+		actual[col.table][col.name] = col
+	}
+
+	tables := make([]string, 0, len(supported))
+	for table := range supported {
+		tables = append(tables, table)
+	}
+
+	sort.Strings(tables)
+
+	var problems []string
+
+	for _, table := range tables {
+		actualColumns, ok := actual[table]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("missing table %s", table))
+			continue
+		}
+
+		known := make(map[string]bool, len(supported[table]))
+		for _, name := range supported[table] {
+			known[name] = true
+
+			if _, ok := actualColumns[name]; !ok {
+				problems = append(problems, fmt.Sprintf("missing column %s.%s", table, name))
+			}
+		}
+
+		var unknown []string
+		for name, col := range actualColumns {
+			if !known[name] && !col.nullable && !col.hasDefault {
+				unknown = append(unknown, name)
+			}
+		}
+
+		sort.Strings(unknown)
+
+		for _, name := range unknown {
+			problems = append(problems, fmt.Sprintf("unsupported required column %s.%s", table, name))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w, this build supports a different transaction-db version: %s",
+			storage.ErrIncompatibleSchema, strings.Join(problems, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,293 @@
+package postgres
+
+import (
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareSchema(t *testing.T) {
+	c := require.New(t)
+
+	supported := map[string][]string{
+		"relay":         {"id", "request_id"},
+		"portal_region": {"portal_region_name"},
+	}
+
+	supportedColumns := []column{
+		{table: "relay", name: "id", hasDefault: true},
+		{table: "relay", name: "request_id"},
+		{table: "portal_region", name: "portal_region_name"},
+	}
+
+	tests := []struct {
+		name            string
+		columns         []column
+		expectedErr     error
+		expectedMessage string
+	}{
+		{
+			name:    "Same schema",
+			columns: supportedColumns,
+		},
+		{
+			name: "New optional columns",
+			columns: append([]column{
+				{table: "relay", name: "region", nullable: true},
+				{table: "relay", name: "weight", hasDefault: true},
+			}, supportedColumns...),
+		},
+		{
+			name:            "New required column",
+			columns:         append([]column{{table: "relay", name: "region"}}, supportedColumns...),
+			expectedErr:     storage.ErrIncompatibleSchema,
+			expectedMessage: "unsupported required column relay.region",
+		},
+		{
+			name:            "Missing column",
+			columns:         supportedColumns[1:],
+			expectedErr:     storage.ErrIncompatibleSchema,
+			expectedMessage: "missing column relay.id",
+		},
+		{
+			name:            "Missing table",
+			columns:         supportedColumns[:2],
+			expectedErr:     storage.ErrIncompatibleSchema,
+			expectedMessage: "missing table portal_region",
+		},
+	}
+
+	for _, tt := range tests {
+		err := compareSchema(supported, tt.columns)
+		c.ErrorIs(err, tt.expectedErr, tt.name)
+
+		if tt.expectedErr != nil {
+			c.ErrorContains(err, tt.expectedMessage, tt.name)
+		}
+	}
+
+	// Every problem is reported at once
+	err := compareSchema(supported, nil)
+	c.ErrorContains(err, "missing table portal_region, missing table relay")
+}
+
+// TestSupportedSchema checks supportedSchema against the migrations of the transaction-db
+// version in go.mod, so that bumping it fails here rather than at startup
+func TestSupportedSchema(t *testing.T) {
+	c := require.New(t)
+
+	out, err := exec.Command("go", "list", "-m", "-f", "{{.Dir}}", "github.com/pokt-foundation/transaction-db").Output()
+	dir := strings.TrimSpace(string(out))
+	if err != nil || dir == "" {
+		t.Skip("transaction-db module not downloaded")
+	}
+
+	var files []string
+	c.NoError(filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && filepath.Ext(path) == ".sql" && !strings.Contains(strings.ToLower(d.Name()), "down") {
+			files = append(files, path)
+		}
+
+		return err
+	}))
+
+	sort.Strings(files)
+
+	var migrations []string
+	for _, file := range files {
+		migration, err := os.ReadFile(file)
+		c.NoError(err)
+
+		migrations = append(migrations, string(migration))
+	}
+
+	columns := parseMigrations(migrations)
+	c.NotEmpty(columns, "no table created by the migrations of %s", dir)
+	c.NoError(compareSchema(supportedSchema, columns))
+}
+
+func TestParseMigrations(t *testing.T) {
+	c := require.New(t)
+
+	columns := parseMigrations([]string{`
+-- +goose Up
+CREATE TABLE IF NOT EXISTS relay (
+	id BIGSERIAL PRIMARY KEY,
+	pokt_chain_id VARCHAR(4) NOT NULL, -- the chain
+	region VARCHAR NOT NULL DEFAULT 'us',
+	error_code INT,
+	CONSTRAINT fk_region FOREIGN KEY (region) REFERENCES portal_region(portal_region_name)
+);
+-- +goose Down
+DROP TABLE relay;`, `
+ALTER TABLE relay ADD COLUMN request_id TEXT NOT NULL, DROP COLUMN error_code;
+ALTER TABLE relay RENAME COLUMN region TO portal_region_name;
+ALTER TABLE relay ALTER COLUMN portal_region_name DROP DEFAULT;`,
+	})
+
+	c.Equal([]column{
+		{table: "relay", name: "id", hasDefault: true},
+		{table: "relay", name: "pokt_chain_id"},
+		{table: "relay", name: "portal_region_name"},
+		{table: "relay", name: "request_id"},
+	}, columns)
+}
+
+var (
+	createTableRe = regexp.MustCompile(`(?is)create\s+table\s+(?:if\s+not\s+exists\s+)?(?:\w+\.)?"?(\w+)"?\s*\((.*?)\)\s*;`)
+	alterTableRe  = regexp.MustCompile(`(?is)alter\s+table\s+(?:if\s+exists\s+)?(?:only\s+)?(?:\w+\.)?"?(\w+)"?\s+(.*?);`)
+	commentRe     = regexp.MustCompile(`--[^\n]*`)
+	spacesRe      = regexp.MustCompile(`\s+`)
+)
+
+// parseMigrations returns the columns of the tables created by the up migrations, in order
+func parseMigrations(migrations []string) []column {
+	tables := make(map[string][]column)
+
+	for _, migration := range migrations {
+		if i := strings.Index(strings.ToLower(migration), "+goose down"); i >= 0 {
+			migration = migration[:i]
+		}
+
+		migration = commentRe.ReplaceAllString(migration, "")
+
+		statements := createTableRe.FindAllStringSubmatchIndex(migration, -1)
+		statements = append(statements, alterTableRe.FindAllStringSubmatchIndex(migration, -1)...)
+		sort.Slice(statements, func(i, j int) bool { return statements[i][0] < statements[j][0] })
+
+		for _, loc := range statements {
+			table := strings.ToLower(migration[loc[2]:loc[3]])
+			body := migration[loc[4]:loc[5]]
+
+			if strings.HasPrefix(strings.ToLower(migration[loc[0]:loc[1]]), "create") {
+				tables[table] = nil
+				for _, definition := range splitTopLevel(body) {
+					if col, ok := parseColumn(table, definition); ok {
+						tables[table] = append(tables[table], col)
+					}
+				}
+
+				continue
+			}
+
+			for _, action := range splitTopLevel(body) {
+				tables[table] = alterColumns(table, tables[table], action)
+			}
+		}
+	}
+
+	var columns []column
+	for _, table := range sortedKeys(tables) {
+		columns = append(columns, tables[table]...)
+	}
+
+	return columns
+}
+
+// splitTopLevel splits s on the commas outside of parentheses
+func splitTopLevel(s string) []string {
+	var parts []string
+
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+func parseColumn(table, definition string) (column, bool) {
+	definition = strings.ToLower(spacesRe.ReplaceAllString(strings.TrimSpace(definition), " "))
+
+	fields := strings.Fields(definition)
+	if len(fields) < 2 {
+		return column{}, false
+	}
+
+	switch fields[0] {
+	case "constraint", "primary", "foreign", "unique", "check", "exclude", "like":
+		return column{}, false
+	}
+
+	return column{
+		table:      table,
+		name:       strings.Trim(fields[0], `"`),
+		nullable:   !strings.Contains(definition, "not null") && !strings.Contains(definition, "primary key"),
+		hasDefault: strings.Contains(definition, "default") || strings.Contains(fields[1], "serial") || strings.Contains(definition, "generated"),
+	}, true
+}
+
+// alterColumns applies the column changes of an ALTER TABLE action to columns
+func alterColumns(table string, columns []column, action string) []column {
+	action = strings.ToLower(spacesRe.ReplaceAllString(strings.TrimSpace(action), " "))
+	fields := strings.Fields(action)
+
+	if len(fields) < 3 || fields[1] != "column" {
+		return columns
+	}
+
+	definition := strings.TrimPrefix(strings.Join(fields[2:], " "), "if not exists ")
+	definition = strings.TrimPrefix(definition, "if exists ")
+	name := strings.Trim(strings.Fields(definition)[0], `"`)
+
+	i := -1
+	for j, col := range columns {
+		if col.name == name {
+			i = j
+		}
+	}
+
+	switch {
+	case fields[0] == "add":
+		if col, ok := parseColumn(table, definition); ok && i < 0 {
+			columns = append(columns, col)
+		}
+	case i < 0:
+	case fields[0] == "drop":
+		columns = append(columns[:i], columns[i+1:]...)
+	case fields[0] == "rename" && len(fields) >= 5:
+		columns[i].name = strings.Trim(fields[len(fields)-1], `"`)
+	case fields[0] == "alter":
+		switch {
+		case strings.Contains(action, "set not null"):
+			columns[i].nullable = false
+		case strings.Contains(action, "drop not null"):
+			columns[i].nullable = true
+		case strings.Contains(action, "set default"):
+			columns[i].hasDefault = true
+		case strings.Contains(action, "drop default"):
+			columns[i].hasDefault = false
+		}
+	}
+
+	return columns
+}
+
+func sortedKeys(m map[string][]column) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Config holds the settings of a backend by name, each backend reading the ones it understands
+type Config map[string]string
+
+// Factory opens a backend, returning the driver and a function that releases its resources
+type Factory func(ctx context.Context, config Config) (driver Driver, cleanup func() error, err error)
+
+var (
+	factoriesMutex sync.RWMutex
+	factories      = make(map[string]Factory)
+)
+
+// Register makes a backend available under name. It panics if the name is
+// already taken, as it is meant to be called from the init of each backend package.
+func Register(name string, factory Factory) {
+	factoriesMutex.Lock()
+	defer factoriesMutex.Unlock()
+
+	if _, ok := factories[name]; ok {
+		panic(fmt.Sprintf("storage backend %s registered twice", name))
+	}
+
+	factories[name] = factory
+}
+
+// Backends returns the names of the registered backends, sorted
+func Backends() []string {
+	factoriesMutex.RLock()
+	defer factoriesMutex.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Open opens the backend registered under name with config
+func Open(ctx context.Context, name string, config Config) (driver Driver, cleanup func() error, err error) {
+	factoriesMutex.RLock()
+	factory, ok := factories[name]
+	factoriesMutex.RUnlock()
+
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown storage backend %q, available backends: %v", name, Backends())
+	}
+
+	driver, cleanup, err = factory(ctx, config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening %s storage backend: %w", name, err)
+	}
+
+	return driver, cleanup, nil
+}
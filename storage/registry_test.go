@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen(t *testing.T) {
+	c := require.New(t)
+
+	driver := &MockDriver{}
+	errDummy := errors.New("dummy")
+
+	Register("test_ok", func(ctx context.Context, config Config) (Driver, func() error, error) {
+		c.Equal(Config{"key": "value"}, config)
+		return driver, func() error { return nil }, nil
+	})
+	Register("test_error", func(ctx context.Context, config Config) (Driver, func() error, error) {
+		return nil, nil, errDummy
+	})
+
+	c.Subset(Backends(), []string{"test_error", "test_ok"})
+	c.Panics(func() {
+		Register("test_ok", nil)
+	})
+
+	tests := []struct {
+		name           string
+		backend        string
+		expectedDriver Driver
+		expectedErr    error
+	}{
+		{
+			name:           "Registered backend",
+			backend:        "test_ok",
+			expectedDriver: driver,
+		},
+		{
+			name:        "Failing backend",
+			backend:     "test_error",
+			expectedErr: errDummy,
+		},
+		{
+			name:    "Unknown backend",
+			backend: "dummy",
+		},
+	}
+
+	for _, tt := range tests {
+		openedDriver, cleanup, err := Open(context.Background(), tt.backend, Config{"key": "value"})
+		if tt.expectedDriver == nil {
+			c.Error(err, tt.name)
+			if tt.expectedErr != nil {
+				c.ErrorIs(err, tt.expectedErr, tt.name)
+			}
+			continue
+		}
+
+		c.NoError(err, tt.name)
+		c.Equal(tt.expectedDriver, openedDriver, tt.name)
+		c.NoError(cleanup(), tt.name)
+	}
+}
@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrIncompatibleSchema is returned when the database schema is not one the backend can write to
+var ErrIncompatibleSchema = errors.New("incompatible database schema")
+
+// SchemaChecker is implemented by the backends whose schema is managed outside of this service
+type SchemaChecker interface {
+	CheckSchema(ctx context.Context) error
+}
+
+// CheckSchema checks the database schema of driver is one it supports,
+// drivers that own their schema always being compatible
+func CheckSchema(ctx context.Context, driver Driver) error {
+	checker, ok := driver.(SchemaChecker)
+	if !ok {
+		return nil
+	}
+
+	return checker.CheckSchema(ctx)
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type schemaCheckerDriver struct {
+	MockDriver
+	err error
+}
+
+func (d *schemaCheckerDriver) CheckSchema(ctx context.Context) error {
+	return d.err
+}
+
+func TestCheckSchema(t *testing.T) {
+	c := require.New(t)
+
+	incompatibleErr := fmt.Errorf("%w: missing table relay", ErrIncompatibleSchema)
+
+	tests := []struct {
+		name        string
+		driver      Driver
+		expectedErr error
+	}{
+		{
+			name:   "Driver without schema check",
+			driver: &MockDriver{},
+		},
+		{
+			name:   "Compatible schema",
+			driver: &schemaCheckerDriver{},
+		},
+		{
+			name:        "Incompatible schema",
+			driver:      &schemaCheckerDriver{err: incompatibleErr},
+			expectedErr: ErrIncompatibleSchema,
+		},
+	}
+
+	for _, tt := range tests {
+		c.ErrorIs(CheckSchema(context.Background(), tt.driver), tt.expectedErr, tt.name)
+	}
+}
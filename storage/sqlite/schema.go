@@ -0,0 +1,74 @@
+package sqlite
+
+// schema mirrors the transaction-db tables, with timestamps stored as RFC3339 text
+// and the relay chain method IDs as a JSON array
+const schema = `
+CREATE TABLE IF NOT EXISTS portal_region (
+	portal_region_name TEXT PRIMARY KEY
+);
+
+CREATE TABLE IF NOT EXISTS pocket_session (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	session_key TEXT NOT NULL UNIQUE,
+	session_height INTEGER NOT NULL,
+	portal_region_name TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS relay (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	pokt_chain_id TEXT NOT NULL,
+	endpoint_id TEXT NOT NULL,
+	session_key TEXT NOT NULL,
+	protocol_app_public_key TEXT NOT NULL,
+	relay_source_url TEXT NOT NULL,
+	pokt_node_address TEXT NOT NULL,
+	pokt_node_domain TEXT NOT NULL,
+	pokt_node_public_key TEXT NOT NULL,
+	relay_start_datetime TEXT NOT NULL,
+	relay_return_datetime TEXT NOT NULL,
+	is_error INTEGER NOT NULL,
+	error_code INTEGER,
+	error_name TEXT,
+	error_message TEXT,
+	error_source TEXT,
+	error_type TEXT,
+	relay_roundtrip_time REAL NOT NULL,
+	relay_chain_method_ids TEXT NOT NULL,
+	relay_data_size INTEGER NOT NULL,
+	relay_portal_trip_time REAL NOT NULL,
+	relay_node_trip_time REAL NOT NULL,
+	relay_url_is_public_endpoint INTEGER NOT NULL,
+	portal_region_name TEXT NOT NULL,
+	is_altruist_relay INTEGER NOT NULL,
+	is_user_relay INTEGER NOT NULL,
+	request_id TEXT NOT NULL,
+	pokt_tx_id TEXT,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS relay_session_key_idx ON relay (session_key);
+
+CREATE TABLE IF NOT EXISTS service_record (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	node_public_key TEXT NOT NULL,
+	pokt_chain_id TEXT NOT NULL,
+	session_key TEXT NOT NULL,
+	request_id TEXT NOT NULL,
+	portal_region_name TEXT NOT NULL,
+	latency REAL NOT NULL,
+	tickets INTEGER NOT NULL,
+	result TEXT NOT NULL,
+	available INTEGER NOT NULL,
+	successes INTEGER NOT NULL,
+	failures INTEGER NOT NULL,
+	p90_success_latency REAL NOT NULL,
+	median_success_latency REAL NOT NULL,
+	weighted_success_latency REAL NOT NULL,
+	success_rate REAL NOT NULL,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
+`
@@ -0,0 +1,395 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/pokt-foundation/transaction-http-db/storage"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Name is the name the backend is registered with
+const Name = "sqlite"
+
+// ConfigPath is the config key of the database file path
+const ConfigPath = "path"
+
+const (
+	// busyTimeout is how long a write waits for the lock held by another connection
+	busyTimeout = 5 * time.Second
+	timeLayout  = time.RFC3339Nano
+)
+
+const (
+	insertSessionQuery = `INSERT INTO pocket_session (session_key, session_height, portal_region_name, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?)`
+	insertRegionQuery = `INSERT INTO portal_region (portal_region_name) VALUES (?)
+	ON CONFLICT (portal_region_name) DO NOTHING`
+	insertRelayQuery = `INSERT INTO relay (
+		pokt_chain_id, endpoint_id, session_key, protocol_app_public_key, relay_source_url, pokt_node_address,
+		pokt_node_domain, pokt_node_public_key, relay_start_datetime, relay_return_datetime, is_error, error_code,
+		error_name, error_message, error_source, error_type, relay_roundtrip_time, relay_chain_method_ids,
+		relay_data_size, relay_portal_trip_time, relay_node_trip_time, relay_url_is_public_endpoint,
+		portal_region_name, is_altruist_relay, is_user_relay, request_id, pokt_tx_id, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	selectRelaysQuery = `SELECT
+		r.id, r.pokt_chain_id, r.endpoint_id, r.session_key, r.protocol_app_public_key, r.relay_source_url,
+		r.pokt_node_address, r.pokt_node_domain, r.pokt_node_public_key, r.relay_start_datetime,
+		r.relay_return_datetime, r.is_error, COALESCE(r.error_code, 0), COALESCE(r.error_name, ''),
+		COALESCE(r.error_message, ''), COALESCE(r.error_source, ''), COALESCE(r.error_type, ''),
+		r.relay_roundtrip_time, r.relay_chain_method_ids, r.relay_data_size, r.relay_portal_trip_time,
+		r.relay_node_trip_time, r.relay_url_is_public_endpoint, r.portal_region_name, r.is_altruist_relay,
+		r.is_user_relay, r.request_id, COALESCE(r.pokt_tx_id, ''), r.created_at, r.updated_at,
+		COALESCE(s.session_key, ''), COALESCE(s.session_height, 0), COALESCE(s.portal_region_name, ''),
+		COALESCE(s.created_at, ''), COALESCE(s.updated_at, ''), COALESCE(pr.portal_region_name, '')
+	FROM relay r
+	LEFT JOIN pocket_session s ON s.session_key = r.session_key
+	LEFT JOIN portal_region pr ON pr.portal_region_name = r.portal_region_name`
+	selectRelayQuery = selectRelaysQuery + `
+	WHERE r.id = ?`
+	// The start times are compared as instants, as RFC3339 texts with a different number of
+	// fractional digits don't sort in time order
+	exportRelaysQuery = selectRelaysQuery + `
+	WHERE julianday(r.relay_start_datetime) >= julianday(?) AND julianday(r.relay_start_datetime) < julianday(?)
+	ORDER BY r.id`
+	insertServiceRecordQuery = `INSERT INTO service_record (
+		node_public_key, pokt_chain_id, session_key, request_id, portal_region_name, latency, tickets, result,
+		available, successes, failures, p90_success_latency, median_success_latency, weighted_success_latency,
+		success_rate, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	selectServiceRecordQuery = `SELECT
+		id, node_public_key, pokt_chain_id, session_key, request_id, portal_region_name, latency, tickets, result,
+		available, successes, failures, p90_success_latency, median_success_latency, weighted_success_latency,
+		success_rate, created_at, updated_at
+	FROM service_record
+	WHERE id = ?`
+)
+
+var (
+	ErrRelayNotFound         = errors.New("relay not found")
+	ErrServiceRecordNotFound = errors.New("service record not found")
+
+	errMissingPath = errors.New("the database path must be set")
+)
+
+func init() {
+	storage.Register(Name, Open)
+}
+
+// Driver is a storage.Driver backed by a SQLite database file, so an instance can keep
+// its data locally without a database server
+type Driver struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+// NewDriver opens the database at path in WAL mode and creates the tables it is missing
+func NewDriver(ctx context.Context, path string) (*Driver, error) {
+	if path == "" {
+		return nil, errMissingPath
+	}
+
+	query := url.Values{}
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds()))
+	query.Add("_pragma", "synchronous(NORMAL)")
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error creating schema: %w", err)
+	}
+
+	return &Driver{db: db, now: time.Now}, nil
+}
+
+// Open is the storage.Factory of the SQLite backend
+func Open(ctx context.Context, config storage.Config) (storage.Driver, func() error, error) {
+	driver, err := NewDriver(ctx, config[ConfigPath])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return driver, driver.Close, nil
+}
+
+// Close closes the database
+func (d *Driver) Close() error {
+	return d.db.Close()
+}
+
+// Ping checks the database file can still be reached
+func (d *Driver) Ping(ctx context.Context) error {
+	return d.db.PingContext(ctx)
+}
+
+func (d *Driver) WriteSession(ctx context.Context, session types.PocketSession) error {
+	if err := session.Validate(); err != nil {
+		return err
+	}
+
+	now := formatTime(d.now())
+
+	_, err := d.db.ExecContext(ctx, insertSessionQuery,
+		session.SessionKey, session.SessionHeight, session.PortalRegionName, now, now)
+	if isUniqueViolation(err) {
+		return types.ErrRepeatedSessionKey
+	}
+
+	return err
+}
+
+func (d *Driver) WriteRegion(ctx context.Context, region types.PortalRegion) error {
+	_, err := d.db.ExecContext(ctx, insertRegionQuery, region.PortalRegionName)
+	return err
+}
+
+func (d *Driver) WriteRelay(ctx context.Context, relay types.Relay) error {
+	return d.WriteRelays(ctx, []*types.Relay{&relay})
+}
+
+// WriteRelays inserts the relays in a single transaction, none being saved if any fails
+func (d *Driver) WriteRelays(ctx context.Context, relays []*types.Relay) error {
+	for i, relay := range relays {
+		if err := relay.Validate(); err != nil {
+			return fmt.Errorf("relay %d: %w", i, err)
+		}
+	}
+
+	now := formatTime(d.now())
+
+	return d.inTx(ctx, insertRelayQuery, len(relays), func(stmt *sql.Stmt, i int) error {
+		relay := relays[i]
+
+		methodIDs, err := json.Marshal(relay.RelayChainMethodIDs)
+		if err != nil {
+			return err
+		}
+
+		_, err = stmt.ExecContext(ctx,
+			relay.PoktChainID, relay.EndpointID, relay.SessionKey, relay.ProtocolAppPublicKey, relay.RelaySourceURL,
+			relay.PoktNodeAddress, relay.PoktNodeDomain, relay.PoktNodePublicKey, formatTime(relay.RelayStartDatetime),
+			formatTime(relay.RelayReturnDatetime), relay.IsError, relay.ErrorCode, relay.ErrorName, relay.ErrorMessage,
+			string(relay.ErrorSource), relay.ErrorType, relay.RelayRoundtripTime, string(methodIDs), relay.RelayDataSize,
+			relay.RelayPortalTripTime, relay.RelayNodeTripTime, relay.RelayURLIsPublicEndpoint, relay.PortalRegionName,
+			relay.IsAltruistRelay, relay.IsUserRelay, relay.RequestID, relay.PoktTxID, now, now,
+		)
+
+		return err
+	})
+}
+
+func (d *Driver) ReadRelay(ctx context.Context, relayID int) (types.Relay, error) {
+	relay, err := scanRelay(d.db.QueryRowContext(ctx, selectRelayQuery, relayID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Relay{}, ErrRelayNotFound
+	}
+
+	return relay, err
+}
+
+// ExportRelays calls fn with each relay started from from and before to, in the order they were written
+func (d *Driver) ExportRelays(ctx context.Context, from, to time.Time, fn func(relay types.Relay) error) error {
+	rows, err := d.db.QueryContext(ctx, exportRelaysQuery, formatTime(from), formatTime(to))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		relay, err := scanRelay(rows)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(relay); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// scanner is a row of a query, either from QueryRow or from Query
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRelay(row scanner) (types.Relay, error) {
+	var (
+		relay                                               types.Relay
+		errorSource, methodIDs                              string
+		startDatetime, returnDatetime, createdAt, updatedAt string
+		sessionCreatedAt, sessionUpdatedAt                  string
+	)
+
+	err := row.Scan(
+		&relay.RelayID, &relay.PoktChainID, &relay.EndpointID, &relay.SessionKey, &relay.ProtocolAppPublicKey,
+		&relay.RelaySourceURL, &relay.PoktNodeAddress, &relay.PoktNodeDomain, &relay.PoktNodePublicKey,
+		&startDatetime, &returnDatetime, &relay.IsError, &relay.ErrorCode, &relay.ErrorName, &relay.ErrorMessage,
+		&errorSource, &relay.ErrorType, &relay.RelayRoundtripTime, &methodIDs, &relay.RelayDataSize,
+		&relay.RelayPortalTripTime, &relay.RelayNodeTripTime, &relay.RelayURLIsPublicEndpoint,
+		&relay.PortalRegionName, &relay.IsAltruistRelay, &relay.IsUserRelay, &relay.RequestID, &relay.PoktTxID,
+		&createdAt, &updatedAt, &relay.Session.SessionKey, &relay.Session.SessionHeight,
+		&relay.Session.PortalRegionName, &sessionCreatedAt, &sessionUpdatedAt, &relay.Region.PortalRegionName,
+	)
+	if err != nil {
+		return types.Relay{}, err
+	}
+
+	relay.ErrorSource = types.ErrorSource(errorSource)
+
+	if err := json.Unmarshal([]byte(methodIDs), &relay.RelayChainMethodIDs); err != nil {
+		return types.Relay{}, fmt.Errorf("error decoding relay chain method IDs: %w", err)
+	}
+
+	err = parseTimes(
+		timeField{startDatetime, &relay.RelayStartDatetime},
+		timeField{returnDatetime, &relay.RelayReturnDatetime},
+		timeField{createdAt, &relay.CreatedAt},
+		timeField{updatedAt, &relay.UpdatedAt},
+		timeField{sessionCreatedAt, &relay.Session.CreatedAt},
+		timeField{sessionUpdatedAt, &relay.Session.UpdatedAt},
+	)
+	if err != nil {
+		return types.Relay{}, err
+	}
+
+	return relay, nil
+}
+
+func (d *Driver) WriteServiceRecord(ctx context.Context, serviceRecord types.ServiceRecord) error {
+	return d.WriteServiceRecords(ctx, []*types.ServiceRecord{&serviceRecord})
+}
+
+// WriteServiceRecords inserts the service records in a single transaction, none being saved if any fails
+func (d *Driver) WriteServiceRecords(ctx context.Context, serviceRecords []*types.ServiceRecord) error {
+	for i, serviceRecord := range serviceRecords {
+		if err := serviceRecord.Validate(); err != nil {
+			return fmt.Errorf("service record %d: %w", i, err)
+		}
+	}
+
+	now := formatTime(d.now())
+
+	return d.inTx(ctx, insertServiceRecordQuery, len(serviceRecords), func(stmt *sql.Stmt, i int) error {
+		serviceRecord := serviceRecords[i]
+
+		_, err := stmt.ExecContext(ctx,
+			serviceRecord.NodePublicKey, serviceRecord.PoktChainID, serviceRecord.SessionKey, serviceRecord.RequestID,
+			serviceRecord.PortalRegionName, serviceRecord.Latency, serviceRecord.Tickets, serviceRecord.Result,
+			serviceRecord.Available, serviceRecord.Successes, serviceRecord.Failures, serviceRecord.P90SuccessLatency,
+			serviceRecord.MedianSuccessLatency, serviceRecord.WeightedSuccessLatency, serviceRecord.SuccessRate,
+			now, now,
+		)
+
+		return err
+	})
+}
+
+func (d *Driver) ReadServiceRecord(ctx context.Context, serviceRecordID int) (types.ServiceRecord, error) {
+	var (
+		serviceRecord        types.ServiceRecord
+		createdAt, updatedAt string
+	)
+
+	err := d.db.QueryRowContext(ctx, selectServiceRecordQuery, serviceRecordID).Scan(
+		&serviceRecord.ServiceRecordID, &serviceRecord.NodePublicKey, &serviceRecord.PoktChainID,
+		&serviceRecord.SessionKey, &serviceRecord.RequestID, &serviceRecord.PortalRegionName, &serviceRecord.Latency,
+		&serviceRecord.Tickets, &serviceRecord.Result, &serviceRecord.Available, &serviceRecord.Successes,
+		&serviceRecord.Failures, &serviceRecord.P90SuccessLatency, &serviceRecord.MedianSuccessLatency,
+		&serviceRecord.WeightedSuccessLatency, &serviceRecord.SuccessRate, &createdAt, &updatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.ServiceRecord{}, ErrServiceRecordNotFound
+	}
+	if err != nil {
+		return types.ServiceRecord{}, err
+	}
+
+	err = parseTimes(
+		timeField{createdAt, &serviceRecord.CreatedAt},
+		timeField{updatedAt, &serviceRecord.UpdatedAt},
+	)
+	if err != nil {
+		return types.ServiceRecord{}, err
+	}
+
+	return serviceRecord, nil
+}
+
+// inTx runs exec n times with query prepared in a transaction, committing only if all succeed
+func (d *Driver) inTx(ctx context.Context, query string, n int, exec func(stmt *sql.Stmt, i int) error) error {
+	if n == 0 {
+		return nil
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	for i := 0; i < n; i++ {
+		if err := exec(stmt, i); err != nil {
+			_ = stmt.Close()
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	if err := stmt.Close(); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+type timeField struct {
+	raw string
+	dst *time.Time
+}
+
+// parseTimes parses the stored timestamps, leaving the empty ones of missing joins as zero
+func parseTimes(fields ...timeField) error {
+	for _, field := range fields {
+		if field.raw == "" {
+			continue
+		}
+
+		t, err := time.Parse(timeLayout, field.raw)
+		if err != nil {
+			return fmt.Errorf("error parsing time %q: %w", field.raw, err)
+		}
+
+		*field.dst = t
+	}
+
+	return nil
+}
@@ -0,0 +1,232 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pokt-foundation/transaction-db/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDriver(t *testing.T) *Driver {
+	t.Helper()
+
+	driver, err := NewDriver(context.Background(), filepath.Join(t.TempDir(), "transactions.db"))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, driver.Close())
+	})
+
+	return driver
+}
+
+func TestNewDriver(t *testing.T) {
+	c := require.New(t)
+
+	_, err := NewDriver(context.Background(), "")
+	c.ErrorIs(err, errMissingPath)
+
+	driver := newTestDriver(t)
+
+	var journalMode string
+	c.NoError(driver.db.QueryRow("PRAGMA journal_mode").Scan(&journalMode))
+	c.Equal("wal", journalMode)
+}
+
+func TestDriver_WriteSession(t *testing.T) {
+	c := require.New(t)
+
+	driver := newTestDriver(t)
+
+	tests := []struct {
+		name        string
+		session     types.PocketSession
+		expectedErr error
+	}{
+		{
+			name: "Success",
+			session: types.PocketSession{
+				SessionKey:       "21",
+				SessionHeight:    21,
+				PortalRegionName: "La Colonia Tovar",
+			},
+		},
+		{
+			name: "Repeated session key",
+			session: types.PocketSession{
+				SessionKey:       "21",
+				SessionHeight:    22,
+				PortalRegionName: "La Colonia Tovar",
+			},
+			expectedErr: types.ErrRepeatedSessionKey,
+		},
+	}
+
+	for _, tt := range tests {
+		err := driver.WriteSession(context.Background(), tt.session)
+		c.ErrorIs(err, tt.expectedErr, tt.name)
+	}
+
+	c.Error(driver.WriteSession(context.Background(), types.PocketSession{}))
+}
+
+func TestDriver_Relays(t *testing.T) {
+	c := require.New(t)
+
+	now := time.Date(2023, time.October, 21, 0, 0, 0, 0, time.UTC)
+
+	driver := newTestDriver(t)
+	driver.now = func() time.Time { return now }
+
+	ctx := context.Background()
+
+	session := types.PocketSession{SessionKey: "21", SessionHeight: 21, PortalRegionName: "La Colonia Tovar"}
+	region := types.PortalRegion{PortalRegionName: "La Colonia Tovar"}
+
+	c.NoError(driver.WriteSession(ctx, session))
+	c.NoError(driver.WriteRegion(ctx, region))
+
+	relay := types.Relay{
+		PoktChainID:              "21",
+		EndpointID:               "21",
+		SessionKey:               "21",
+		ProtocolAppPublicKey:     "21",
+		RelaySourceURL:           "pablo.com",
+		PoktNodeAddress:          "21",
+		PoktNodeDomain:           "pablos.com",
+		PoktNodePublicKey:        "aaa",
+		RelayStartDatetime:       now,
+		RelayReturnDatetime:      now,
+		RelayRoundtripTime:       1,
+		RelayChainMethodIDs:      []string{"get_height"},
+		RelayDataSize:            21,
+		RelayPortalTripTime:      21,
+		RelayNodeTripTime:        21,
+		RelayURLIsPublicEndpoint: false,
+		PortalRegionName:         "La Colonia Tovar",
+		RequestID:                "21",
+		PoktTxID:                 "21",
+	}
+
+	c.NoError(driver.WriteRelay(ctx, relay))
+	c.NoError(driver.WriteRelays(ctx, []*types.Relay{&relay, &relay}))
+
+	// A single invalid relay fails the whole batch
+	c.Error(driver.WriteRelays(ctx, []*types.Relay{&relay, {}}))
+	c.Error(driver.WriteRelay(ctx, types.Relay{}))
+
+	storedRelay, err := driver.ReadRelay(ctx, 3)
+	c.NoError(err)
+	c.Equal(3, storedRelay.RelayID)
+	c.Equal(now, storedRelay.CreatedAt)
+	c.Equal(now, storedRelay.UpdatedAt)
+	c.Equal(relay.RelayChainMethodIDs, storedRelay.RelayChainMethodIDs)
+	c.Equal(relay.RelayStartDatetime, storedRelay.RelayStartDatetime)
+	c.Equal(relay.PoktTxID, storedRelay.PoktTxID)
+	c.Equal(session.SessionKey, storedRelay.Session.SessionKey)
+	c.Equal(now, storedRelay.Session.CreatedAt)
+	c.Equal(region, storedRelay.Region)
+
+	_, err = driver.ReadRelay(ctx, 4)
+	c.ErrorIs(err, ErrRelayNotFound)
+
+	_, err = driver.ReadRelay(ctx, 0)
+	c.ErrorIs(err, ErrRelayNotFound)
+}
+
+func TestDriver_ExportRelays(t *testing.T) {
+	c := require.New(t)
+
+	start := time.Date(2023, time.October, 21, 0, 0, 0, 0, time.UTC)
+
+	driver := newTestDriver(t)
+	ctx := context.Background()
+
+	c.NoError(driver.WriteRegion(ctx, types.PortalRegion{PortalRegionName: "La Colonia Tovar"}))
+
+	// Start times with and without fractional seconds, whose texts don't sort in time order
+	startTimes := []time.Time{
+		start,
+		start.Add(time.Hour),
+		start.Add(time.Hour + 500*time.Millisecond),
+		start.Add(3 * time.Hour),
+	}
+
+	var relays []*types.Relay
+	for _, startTime := range startTimes {
+		relays = append(relays, &types.Relay{
+			PoktChainID:         "21",
+			SessionKey:          "21",
+			PoktNodeAddress:     "21",
+			PortalRegionName:    "La Colonia Tovar",
+			RelayStartDatetime:  startTime,
+			RelayChainMethodIDs: []string{"get_height"},
+		})
+	}
+
+	c.NoError(driver.WriteRelays(ctx, relays))
+
+	var exported []types.Relay
+	err := driver.ExportRelays(ctx, start.Add(time.Hour), start.Add(3*time.Hour), func(relay types.Relay) error {
+		exported = append(exported, relay)
+		return nil
+	})
+	c.NoError(err)
+	c.Len(exported, 2)
+	c.Equal(2, exported[0].RelayID)
+	c.Equal(3, exported[1].RelayID)
+	c.Equal(startTimes[2], exported[1].RelayStartDatetime)
+	c.Equal([]string{"get_height"}, exported[1].RelayChainMethodIDs)
+	c.Equal("La Colonia Tovar", exported[0].Region.PortalRegionName)
+
+	stopErr := errors.New("stop")
+	err = driver.ExportRelays(ctx, start, start.Add(24*time.Hour), func(relay types.Relay) error {
+		return stopErr
+	})
+	c.ErrorIs(err, stopErr)
+}
+
+func TestDriver_ServiceRecords(t *testing.T) {
+	c := require.New(t)
+
+	driver := newTestDriver(t)
+
+	ctx := context.Background()
+
+	serviceRecord := types.ServiceRecord{
+		SessionKey:             "21",
+		NodePublicKey:          "21",
+		PoktChainID:            "21",
+		RequestID:              "21",
+		PortalRegionName:       "La Colonia Tovar",
+		Latency:                21.07,
+		Tickets:                2,
+		Result:                 "a",
+		Available:              true,
+		Successes:              21,
+		Failures:               7,
+		P90SuccessLatency:      21.07,
+		MedianSuccessLatency:   21.07,
+		WeightedSuccessLatency: 21.07,
+		SuccessRate:            21,
+	}
+
+	c.NoError(driver.WriteServiceRecord(ctx, serviceRecord))
+	c.NoError(driver.WriteServiceRecords(ctx, []*types.ServiceRecord{&serviceRecord}))
+	c.Error(driver.WriteServiceRecords(ctx, []*types.ServiceRecord{&serviceRecord, {}}))
+
+	storedServiceRecord, err := driver.ReadServiceRecord(ctx, 2)
+	c.NoError(err)
+	c.Equal(2, storedServiceRecord.ServiceRecordID)
+	c.Equal(serviceRecord.Latency, storedServiceRecord.Latency)
+	c.False(storedServiceRecord.CreatedAt.IsZero())
+
+	_, err = driver.ReadServiceRecord(ctx, 3)
+	c.ErrorIs(err, ErrServiceRecordNotFound)
+
+	c.NoError(driver.Ping(ctx))
+}
@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/pokt-foundation/transaction-db/types"
+)
+
+// Driver is the set of operations every storage backend supports, covering the
+// reads and single writes done by the router and the batch writes done by the batches
+type Driver interface {
+	WriteSession(ctx context.Context, session types.PocketSession) error
+	WriteRegion(ctx context.Context, region types.PortalRegion) error
+	WriteRelay(ctx context.Context, relay types.Relay) error
+	WriteRelays(ctx context.Context, relays []*types.Relay) error
+	ReadRelay(ctx context.Context, relayID int) (types.Relay, error)
+	WriteServiceRecord(ctx context.Context, serviceRecord types.ServiceRecord) error
+	WriteServiceRecords(ctx context.Context, serviceRecords []*types.ServiceRecord) error
+	ReadServiceRecord(ctx context.Context, serviceRecordID int) (types.ServiceRecord, error)
+	Ping(ctx context.Context) error
+}
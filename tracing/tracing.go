@@ -0,0 +1,119 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+const (
+	defaultServiceName = "transaction-http-db"
+	defaultTimeout     = 10 * time.Second
+)
+
+// Config holds the settings of the OTLP exporter of the spans
+type Config struct {
+	// Endpoint is the URL of an OTLP HTTP collector such as http://localhost:4318, the spans
+	// being sent to its /v1/traces path unless it has a path. Spans are not exported when empty.
+	Endpoint string
+	// SampleRatio is the fraction of the traces started by the service that are sampled,
+	// the traces of incoming requests following the decision of their caller
+	SampleRatio    float64
+	ServiceName    string
+	ServiceVersion string
+	// Timeout bounds each export, defaulting to 10 seconds
+	Timeout time.Duration
+}
+
+// Validate checks the config can be used to export spans
+func (c Config) Validate() error {
+	var errs []error
+
+	if c.Endpoint != "" {
+		if u, err := url.Parse(c.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("the endpoint must be an http or https URL, got %q", c.Endpoint))
+		}
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("the sample ratio must be between 0 and 1, got %v", c.SampleRatio))
+	}
+	if c.Timeout < 0 {
+		errs = append(errs, errors.New("the timeout must not be negative"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Setup propagates the W3C trace context of incoming requests and, when an endpoint is set,
+// exports the spans to it. The returned shutdown exports the spans left and must be called
+// before exiting.
+func Setup(ctx context.Context, config Config) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if config.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	if config.ServiceName == "" {
+		config.ServiceName = defaultServiceName
+	}
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+
+	exporter, err := otlptracehttp.New(ctx, exporterOptions(config)...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating OTLP exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(config.ServiceName),
+		semconv.ServiceVersion(config.ServiceVersion),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+func exporterOptions(config Config) []otlptracehttp.Option {
+	// The endpoint was validated
+	u, _ := url.Parse(config.Endpoint)
+
+	options := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(u.Host),
+		otlptracehttp.WithTimeout(config.Timeout),
+	}
+
+	if u.Scheme == "http" {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+
+	if u.Path != "" && u.Path != "/" {
+		options = append(options, otlptracehttp.WithURLPath(u.Path))
+	}
+
+	return options
+}
@@ -0,0 +1,150 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// collector is a stand-in of an OTLP HTTP collector keeping the spans it receives
+type collector struct {
+	mu       sync.Mutex
+	paths    []string
+	spans    map[string]map[string]string
+	resource map[string]string
+}
+
+func newCollector(t *testing.T) (*collector, *httptest.Server) {
+	t.Helper()
+
+	col := &collector{spans: make(map[string]map[string]string), resource: make(map[string]string)}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var req collectortrace.ExportTraceServiceRequest
+		if err := proto.Unmarshal(body, &req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		col.mu.Lock()
+		defer col.mu.Unlock()
+
+		col.paths = append(col.paths, r.URL.Path)
+
+		for _, resourceSpans := range req.ResourceSpans {
+			for _, attr := range resourceSpans.Resource.Attributes {
+				col.resource[attr.Key] = attr.Value.GetStringValue()
+			}
+
+			for _, scopeSpans := range resourceSpans.ScopeSpans {
+				for _, span := range scopeSpans.Spans {
+					attrs := make(map[string]string)
+					for _, attr := range span.Attributes {
+						attrs[attr.Key] = attr.Value.GetStringValue()
+					}
+
+					col.spans[span.Name] = attrs
+				}
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(server.Close)
+
+	return col, server
+}
+
+func TestSetup(t *testing.T) {
+	c := require.New(t)
+
+	col, server := newCollector(t)
+
+	shutdown, err := Setup(context.Background(), Config{Endpoint: server.URL, SampleRatio: 1, ServiceVersion: "v1.2.3"})
+	c.NoError(err)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "Batch.Save")
+	span.SetAttributes(attribute.String("batch.name", "relay"))
+	span.End()
+
+	// Shutting down exports the spans left
+	c.NoError(shutdown(context.Background()))
+
+	col.mu.Lock()
+	defer col.mu.Unlock()
+
+	c.Equal([]string{"/v1/traces"}, col.paths)
+	c.Equal(map[string]string{"batch.name": "relay"}, col.spans["Batch.Save"])
+	c.Equal("transaction-http-db", col.resource["service.name"])
+	c.Equal("v1.2.3", col.resource["service.version"])
+
+	// The W3C trace context is propagated
+	c.ElementsMatch([]string{"traceparent", "tracestate", "baggage"}, otel.GetTextMapPropagator().Fields())
+}
+
+func TestSetup_Disabled(t *testing.T) {
+	c := require.New(t)
+
+	shutdown, err := Setup(context.Background(), Config{})
+	c.NoError(err)
+	c.NoError(shutdown(context.Background()))
+
+	_, err = Setup(context.Background(), Config{Endpoint: "localhost:4318"})
+	c.ErrorContains(err, "endpoint")
+}
+
+func TestConfig_Validate(t *testing.T) {
+	c := require.New(t)
+
+	tests := []struct {
+		name      string
+		config    Config
+		expectErr bool
+	}{
+		{
+			name:   "Disabled",
+			config: Config{},
+		},
+		{
+			name:   "HTTPS endpoint with path",
+			config: Config{Endpoint: "https://collector.example.com/otlp/v1/traces", SampleRatio: 0.1},
+		},
+		{
+			name:      "Endpoint without scheme",
+			config:    Config{Endpoint: "collector:4318"},
+			expectErr: true,
+		},
+		{
+			name:      "Invalid sample ratio",
+			config:    Config{SampleRatio: 1.5},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		err := tt.config.Validate()
+		if tt.expectErr {
+			c.Error(err, tt.name)
+			continue
+		}
+
+		c.NoError(err, tt.name)
+	}
+}
@@ -0,0 +1,211 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Counters holds the usage accounted for a key label
+type Counters struct {
+	// Requests counts the requests served, which excludes those of RequestsRejected
+	Requests uint64 `json:"requests"`
+	// RequestsRejected counts the requests rejected by the requests rate limit, whose items are never read
+	RequestsRejected uint64 `json:"requestsRejected"`
+	ItemsAccepted    uint64 `json:"itemsAccepted"`
+	ItemsRejected    uint64 `json:"itemsRejected"`
+	Bytes            uint64 `json:"bytes"`
+}
+
+func (c *Counters) add(other Counters) {
+	c.Requests += other.Requests
+	c.RequestsRejected += other.RequestsRejected
+	c.ItemsAccepted += other.ItemsAccepted
+	c.ItemsRejected += other.ItemsRejected
+	c.Bytes += other.Bytes
+}
+
+// Record is the usage of a key label during a single hour
+type Record struct {
+	Label string    `json:"label"`
+	Hour  time.Time `json:"hour"`
+	Counters
+}
+
+// Store persists usage records between restarts
+type Store interface {
+	Load() ([]Record, error)
+	Save(records []Record) error
+}
+
+type recordKey struct {
+	label string
+	hour  time.Time
+}
+
+// Tracker accounts the usage of each key label per hour
+type Tracker struct {
+	mutex     sync.Mutex
+	records   map[recordKey]*Counters
+	store     Store
+	retention time.Duration
+	log       *zap.Logger
+	now       func() time.Time
+}
+
+// NewTracker returns a Tracker loaded with the records found in store,
+// which can be nil to keep the usage in memory only
+func NewTracker(store Store, retention time.Duration, logger *zap.Logger) (*Tracker, error) {
+	tracker := &Tracker{
+		records:   make(map[recordKey]*Counters),
+		store:     store,
+		retention: retention,
+		log:       logger,
+		now:       time.Now,
+	}
+
+	if store == nil {
+		return tracker, nil
+	}
+
+	records, err := store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("error loading usage records: %w", err)
+	}
+
+	for _, record := range records {
+		tracker.counters(record.Label, record.Hour).add(record.Counters)
+	}
+
+	return tracker, nil
+}
+
+// counters must be called with the mutex held or before the tracker is shared
+func (t *Tracker) counters(label string, at time.Time) *Counters {
+	key := recordKey{label: label, hour: at.UTC().Truncate(time.Hour)}
+
+	counters, ok := t.records[key]
+	if !ok {
+		counters = &Counters{}
+		t.records[key] = counters
+	}
+
+	return counters
+}
+
+// AddRequest accounts a request of the key label and the bytes of its body
+func (t *Tracker) AddRequest(label string, bytes int64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	counters := t.counters(label, t.now())
+	counters.Requests++
+	counters.Bytes += uint64(bytes)
+}
+
+// AddRejectedRequest accounts a request of the key label rejected before its items were read
+func (t *Tracker) AddRejectedRequest(label string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.counters(label, t.now()).RequestsRejected++
+}
+
+// AddItems accounts the items of the key label that were accepted or rejected
+func (t *Tracker) AddItems(label string, accepted, rejected int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	counters := t.counters(label, t.now())
+	counters.ItemsAccepted += uint64(accepted)
+	counters.ItemsRejected += uint64(rejected)
+}
+
+// Report returns the records of the hours in the [from, to) range sorted by hour and label.
+// An empty label returns the records of every key label.
+func (t *Tracker) Report(from, to time.Time, label string) []Record {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	from = from.UTC().Truncate(time.Hour)
+
+	records := []Record{}
+	for key, counters := range t.records {
+		if key.hour.Before(from) || !key.hour.Before(to) {
+			continue
+		}
+
+		if label != "" && key.label != label {
+			continue
+		}
+
+		records = append(records, Record{Label: key.label, Hour: key.hour, Counters: *counters})
+	}
+
+	sortRecords(records)
+
+	return records
+}
+
+func sortRecords(records []Record) {
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].Hour.Equal(records[j].Hour) {
+			return records[i].Hour.Before(records[j].Hour)
+		}
+
+		return records[i].Label < records[j].Label
+	})
+}
+
+// Flush drops the records older than the retention and saves the rest in the store
+func (t *Tracker) Flush() error {
+	t.mutex.Lock()
+
+	oldest := t.now().UTC().Add(-t.retention)
+
+	records := make([]Record, 0, len(t.records))
+	for key, counters := range t.records {
+		if t.retention > 0 && key.hour.Add(time.Hour).Before(oldest) {
+			delete(t.records, key)
+			continue
+		}
+
+		records = append(records, Record{Label: key.label, Hour: key.hour, Counters: *counters})
+	}
+
+	t.mutex.Unlock()
+
+	if t.store == nil {
+		return nil
+	}
+
+	sortRecords(records)
+
+	return t.store.Save(records)
+}
+
+// Run flushes the tracker every interval until the context is done, flushing one last time before returning
+func (t *Tracker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := t.Flush(); err != nil {
+				t.log.Error(fmt.Sprintf("error flushing usage records: %s", err), zap.String("err", err.Error()))
+			}
+
+		case <-ctx.Done():
+			if err := t.Flush(); err != nil {
+				t.log.Error(fmt.Sprintf("error flushing usage records: %s", err), zap.String("err", err.Error()))
+			}
+
+			return
+		}
+	}
+}
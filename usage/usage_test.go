@@ -0,0 +1,98 @@
+package usage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTracker_Report(t *testing.T) {
+	c := require.New(t)
+
+	tracker, err := NewTracker(nil, 0, zap.NewNop())
+	c.NoError(err)
+
+	now := time.Date(2023, 10, 21, 10, 30, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+
+	tracker.AddRequest("gateway", 100)
+	tracker.AddItems("gateway", 2, 1)
+	tracker.AddRequest("gateway", 0)
+	tracker.AddRejectedRequest("gateway")
+	tracker.AddRequest("portal", 21)
+
+	now = now.Add(time.Hour)
+	tracker.AddRequest("gateway", 50)
+	tracker.AddItems("gateway", 3, 0)
+
+	tests := []struct {
+		name            string
+		from, to        time.Time
+		label           string
+		expectedRecords []Record
+	}{
+		{
+			name:  "All labels",
+			from:  now.Add(-2 * time.Hour),
+			to:    now.Add(time.Hour),
+			label: "",
+			expectedRecords: []Record{
+				{Label: "gateway", Hour: time.Date(2023, 10, 21, 10, 0, 0, 0, time.UTC), Counters: Counters{Requests: 2, RequestsRejected: 1, ItemsAccepted: 2, ItemsRejected: 1, Bytes: 100}},
+				{Label: "portal", Hour: time.Date(2023, 10, 21, 10, 0, 0, 0, time.UTC), Counters: Counters{Requests: 1, Bytes: 21}},
+				{Label: "gateway", Hour: time.Date(2023, 10, 21, 11, 0, 0, 0, time.UTC), Counters: Counters{Requests: 1, ItemsAccepted: 3, Bytes: 50}},
+			},
+		},
+		{
+			name:  "Single label and hour",
+			from:  now,
+			to:    now.Add(time.Hour),
+			label: "gateway",
+			expectedRecords: []Record{
+				{Label: "gateway", Hour: time.Date(2023, 10, 21, 11, 0, 0, 0, time.UTC), Counters: Counters{Requests: 1, ItemsAccepted: 3, Bytes: 50}},
+			},
+		},
+		{
+			name:            "Out of range",
+			from:            now.Add(time.Hour),
+			to:              now.Add(2 * time.Hour),
+			expectedRecords: []Record{},
+		},
+	}
+
+	for _, tt := range tests {
+		c.Equal(tt.expectedRecords, tracker.Report(tt.from, tt.to, tt.label), tt.name)
+	}
+}
+
+func TestTracker_Persistence(t *testing.T) {
+	c := require.New(t)
+
+	store := NewFileStore(filepath.Join(t.TempDir(), "usage.json"))
+
+	tracker, err := NewTracker(store, 24*time.Hour, zap.NewNop())
+	c.NoError(err)
+
+	now := time.Now().UTC()
+	tracker.AddRequest("gateway", 21)
+
+	// Records past the retention are dropped on flush
+	tracker.now = func() time.Time { return now.Add(-48 * time.Hour) }
+	tracker.AddRequest("old", 7)
+	tracker.now = time.Now
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tracker.Run(ctx, time.Hour)
+
+	reloaded, err := NewTracker(store, 24*time.Hour, zap.NewNop())
+	c.NoError(err)
+
+	records := reloaded.Report(now.Add(-72*time.Hour), now.Add(time.Hour), "")
+	c.Len(records, 1)
+	c.Equal("gateway", records[0].Label)
+	c.Equal(uint64(21), records[0].Bytes)
+}